@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+)
+
+// errorReport is the structure written to stderr with --error-format=json.
+type errorReport struct {
+	Error     string   `json:"error"`
+	Kind      string   `json:"kind"`
+	ExitCode  int      `json:"exit_code"`
+	Completed []string `json:"completed,omitempty"`
+}
+
+// usageError marks errors detected by cobra while parsing the command line,
+// before any subcommand started running.
+func usageError(err error) error {
+	return &mkissue.Error{Kind: mkissue.ErrValidation, Err: err}
+}
+
+// validationError reports an invalid combination of flags.
+func validationError(format string, args ...any) error {
+	return &mkissue.Error{Kind: mkissue.ErrValidation, Err: fmt.Errorf(format, args...)}
+}
+
+// reportError writes err to w in the requested format and returns the exit code.
+func reportError(w io.Writer, err error, format string) int {
+	code := mkissue.ExitCode(err)
+	if format != "json" {
+		fmt.Fprintln(w, err)
+		return code
+	}
+
+	report := errorReport{Error: err.Error(), Kind: mkissue.KindName(err), ExitCode: code}
+	var partialErr *mkissue.PartialError
+	if errors.As(err, &partialErr) {
+		report.Completed = partialErr.Completed
+	}
+	// Encoding a struct of strings and ints cannot fail
+	data, _ := json.Marshal(report)
+	fmt.Fprintln(w, string(data))
+	return code
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/internal/audit"
+	"github.com/lakruzz/gh-utils/internal/config"
+)
+
+// envStubServer points the fake gh at the stub server of a test.
+const envStubServer = "UTILS_TEST_STUB_SERVER"
+
+// TestMain lets the test binary stand in for gh: installFakeGH links it as gh
+// on $PATH, and it then forwards the gh commands to a stub server.
+func TestMain(m *testing.M) {
+	if filepath.Base(os.Args[0]) == "gh" {
+		os.Exit(fakeGH(os.Args[1:]))
+	}
+	os.Exit(m.Run())
+}
+
+// fakeGH runs the gh commands used by mkissue and status against the REST API
+// of the stub server, on the host gh would pick: --hostname, then $GH_HOST.
+func fakeGH(args []string) int {
+	host, method, repo := os.Getenv("GH_HOST"), "GET", os.Getenv("GH_REPO")
+	if host == "" {
+		host = "github.com"
+	}
+	var path string
+	include := false
+	query := url.Values{}
+	fields := map[string]string{}
+	switch {
+	case len(args) > 0 && args[0] == "api":
+		for i := 1; i < len(args); i++ {
+			switch arg := args[i]; arg {
+			case "--hostname":
+				host = args[i+1]
+				i++
+			case "-X", "--method":
+				method = args[i+1]
+				i++
+			case "-H":
+				i++
+			case "-i", "--include":
+				include = true
+			case "-f":
+				k, v, _ := strings.Cut(args[i+1], "=")
+				fields[k] = v
+				i++
+			default:
+				path = arg
+			}
+		}
+	case len(args) > 1 && args[0] == "issue" && args[1] == "create":
+		method, path = "POST", "repos/"+repo+"/issues"
+		for i := 2; i+1 < len(args); i += 2 {
+			fields[strings.TrimPrefix(args[i], "--")] = args[i+1]
+		}
+		body, _ := io.ReadAll(os.Stdin)
+		fields["body"] = string(body)
+	default:
+		fmt.Fprintf(os.Stderr, "fake gh: unsupported command %q\n", args)
+		return 1
+	}
+
+	var body io.Reader
+	if method == "GET" {
+		for k, v := range fields {
+			query.Set(k, v)
+		}
+	} else {
+		data, _ := json.Marshal(fields)
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, os.Getenv(envStubServer)+"/api/v3/"+path+"?"+query.Encode(), body)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	req.Host = host
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error connecting to", host)
+		return 1
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		fmt.Fprintf(os.Stderr, "HTTP %d: %s\n", resp.StatusCode, data)
+		return 1
+	}
+	if include {
+		fmt.Printf("%s %s\n", resp.Proto, resp.Status)
+		for k := range resp.Header {
+			fmt.Printf("%s: %s\r\n", k, resp.Header.Get(k))
+		}
+		fmt.Print("\r\n")
+	}
+	_, _ = os.Stdout.Write(data)
+	return 0
+}
+
+// stubGHES is a GitHub Enterprise Server at ghes.example.com, serving files
+// and taking issues and statuses for the repository o/r.
+type stubGHES struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (s *stubGHES) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.Host+r.URL.Path)
+	s.mu.Unlock()
+	if r.Host != "ghes.example.com" {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	switch r.Method + " " + r.URL.Path {
+	case "GET /api/v3/repos/o/r/contents/specs/a.issue.md":
+		if r.URL.Query().Get("ref") != "release" {
+			http.Error(w, "No commit found for the ref", http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, "---\ntitle: From GHES\n---\nBody\n")
+	case "POST /api/v3/repos/o/r/issues":
+		var issue map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&issue); err != nil || issue["title"] != "From GHES" {
+			http.Error(w, "Validation Failed", http.StatusUnprocessableEntity)
+			return
+		}
+		fmt.Fprintln(w, "https://ghes.example.com/o/r/issues/1")
+	case "GET /api/v3/repos/o/r/commits/abc/status":
+		fmt.Fprint(w, `{"state":"success","sha":"abc","statuses":[{"state":"success","context":"build"}]}`)
+	default:
+		http.Error(w, "Not Found", http.StatusNotFound)
+	}
+}
+
+func (s *stubGHES) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// installFakeGH puts the test binary on $PATH as gh, talking to server.
+func installFakeGH(t *testing.T, server *httptest.Server) {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := t.TempDir()
+	if err := os.Symlink(exe, filepath.Join(bin, "gh")); err != nil {
+		t.Skipf("cannot link a fake gh: %v", err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv(envStubServer, server.URL)
+	t.Setenv("GH_HOST", "")
+	t.Setenv(audit.EnvFile, filepath.Join(t.TempDir(), "audit.jsonl"))
+}
+
+func TestGHESHost(t *testing.T) {
+	stub := &stubGHES{}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	installFakeGH(t, server)
+	t.Setenv("GH_REPO", "o/r")
+
+	path := filepath.Join(t.TempDir(), "utils.yml")
+	if err := os.WriteFile(path, []byte("hosts:\n  ghes.example.com:\n    aliases: [ghes]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(config.EnvFile, path)
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "host in repo",
+			args: []string{"mkissue", "--file", "specs/a.issue.md", "--repo", "ghes.example.com/o/r", "--branch", "release", "--hostname", "ghes"},
+			want: []string{
+				"GET ghes.example.com/api/v3/repos/o/r/contents/specs/a.issue.md",
+				"POST ghes.example.com/api/v3/repos/o/r/issues",
+			},
+		},
+		{
+			name: "host alias in repo",
+			args: []string{"status", "list", "--repo", "ghes/o/r", "--sha", "abc"},
+			want: []string{"GET ghes.example.com/api/v3/repos/o/r/commits/abc/status"},
+		},
+		{
+			name: "hostname flag",
+			args: []string{"status", "list", "--repo", "o/r", "--sha", "abc", "--hostname", "ghes"},
+			want: []string{"GET ghes.example.com/api/v3/repos/o/r/commits/abc/status"},
+		},
+		{
+			name:    "default host",
+			args:    []string{"status", "list", "--repo", "o/r", "--sha", "abc"},
+			want:    []string{"GET github.com/api/v3/repos/o/r/commits/abc/status"},
+			wantErr: "failed to list statuses",
+		},
+		{
+			name:    "conflicting hosts",
+			args:    []string{"status", "list", "--repo", "ghes/o/r", "--sha", "abc", "--hostname", "github.com"},
+			wantErr: "is not on --hostname",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(stub.Requests())
+			err := run(t, tt.args...)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("execute() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("execute() error = %v, want %q", err, tt.wantErr)
+			}
+			got := stub.Requests()[before:]
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("requests =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
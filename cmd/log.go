@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/audit"
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/spf13/cobra"
+)
+
+// auditTimeout bounds the git calls that describe a run for the audit log.
+const auditTimeout = 5 * time.Second
+
+var (
+	logRepo  string
+	logSince string
+	logUntil string
+	logFile  string
+	logJSON  bool
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Show the audit log of the changes utils made on GitHub",
+	Long: `Show the audit log of the changes utils made on GitHub, oldest first.
+
+Every run that changes, or tries to change, something on GitHub appends
+an entry with the command, the source file (with the commit it was read
+from), the repository, each operation (e.g. a created label, or an issue
+with its number and URL) and the outcome. The log is kept in
+$XDG_STATE_HOME/gh-utils/audit.jsonl (~/.local/state/gh-utils/audit.jsonl
+by default), or in the file named by $UTILS_AUDIT_LOG.
+
+Usage:
+  utils log [--repo <owner/repo>] [--since <date>] [--until <date>] [--file <path>] [--json]`,
+	Args: cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		query := audit.Query{Repo: logRepo, File: logFile}
+		var err error
+		if query.Since, err = parseDate(logSince, false); err != nil {
+			return validationError("invalid --since: %v", err)
+		}
+		if query.Until, err = parseDate(logUntil, true); err != nil {
+			return validationError("invalid --until: %v", err)
+		}
+
+		path, err := audit.Path()
+		if err != nil {
+			return err
+		}
+		entries, err := audit.Read(path)
+		if err != nil {
+			return err
+		}
+		entries = audit.Filter(entries, query)
+		if logJSON {
+			for _, e := range entries {
+				// Encoding the entries read from JSON cannot fail
+				data, _ := json.Marshal(e)
+				fmt.Println(string(data))
+			}
+			return nil
+		}
+		for _, e := range entries {
+			printEntry(e)
+		}
+		return nil
+	},
+}
+
+// printEntry writes an entry as a heading line followed by its operations.
+func printEntry(e audit.Entry) {
+	line := []string{e.Time.Local().Format("2006-01-02 15:04:05"), e.Command}
+	if e.Repo != "" {
+		line = append(line, e.Repo)
+	}
+	line = append(line, e.Outcome)
+	if e.Source != nil {
+		source := e.Source.File
+		for _, from := range []string{e.Source.Branch, e.Source.Gist, e.Source.Repo} {
+			if from != "" {
+				source += " from " + from
+			}
+		}
+		if e.Source.SHA != "" {
+			source += " @ " + e.Source.SHA[:min(len(e.Source.SHA), 7)]
+		}
+		line = append(line, source)
+	}
+	fmt.Println(strings.Join(line, "  "))
+	for _, op := range e.Operations {
+		failed := ""
+		if op.Failed {
+			failed = " (failed)"
+		}
+		fmt.Printf("    %s%s %s\n", op.Action, failed, op.URL)
+	}
+	if e.Error != "" {
+		fmt.Printf("    error: %s\n", e.Error)
+	}
+}
+
+// parseDate parses a date (2006-01-02, local time) or a time in RFC 3339
+// format. A date given as the end of a range includes the whole day.
+func parseDate(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("'%s' is not a date (YYYY-MM-DD) or an RFC 3339 time", value)
+	}
+	return t, nil
+}
+
+// recordAudit appends the run to the audit log when it changed, or tried to
+// change, something on GitHub. Failing to write the log only warns.
+func recordAudit(cmd *cobra.Command, args []string, err error) {
+	if auditor == nil || cmd == nil {
+		return
+	}
+	operations := auditor.Operations()
+	if len(operations) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), auditTimeout)
+	defer cancel()
+	e := audit.Entry{
+		Time:       time.Now().UTC(),
+		Command:    strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" "),
+		Args:       args,
+		Source:     auditSource(ctx, cmd),
+		Repo:       auditor.Repo(),
+		Operations: operations,
+		Outcome:    audit.OutcomeSuccess,
+	}
+	if e.Repo == "" {
+		e.Repo = currentRepo(ctx)
+	}
+	if err != nil {
+		e.Outcome = mkissue.KindName(err)
+		e.Error = err.Error()
+	}
+
+	path, auditErr := audit.Path()
+	if auditErr == nil {
+		auditErr = audit.Append(path, e)
+	}
+	if auditErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", auditErr)
+	}
+}
+
+// auditSource describes the markdown file read by commands with a --file
+// flag. Files read through git get the commit they were read from; local
+// files are given relative to the repository root.
+func auditSource(ctx context.Context, cmd *cobra.Command) *audit.Source {
+	flag := func(name string) string {
+		if f := cmd.Flags().Lookup(name); f != nil {
+			return f.Value.String()
+		}
+		return ""
+	}
+	source := &audit.Source{File: flag("file"), Branch: flag("branch"), Gist: flag("gist"), Repo: flag("repo")}
+	if source.File == "" {
+		return nil
+	}
+	if source.Gist != "" || source.Repo != "" {
+		return source
+	}
+
+	rev := "HEAD"
+	if source.Branch != "" {
+		rev = source.Branch
+	} else if prefix, err := git(ctx, "rev-parse", "--show-prefix"); err == nil && !filepath.IsAbs(source.File) {
+		source.File = filepath.ToSlash(filepath.Join(prefix, source.File))
+	}
+	if sha, err := git(ctx, "rev-parse", "--verify", "--quiet", rev+"^{commit}"); err == nil {
+		source.SHA = sha
+	}
+	return source
+}
+
+// currentRepo returns the repository gh works on: $GH_REPO, or the one of
+// the origin remote.
+func currentRepo(ctx context.Context) string {
+	if repo := os.Getenv("GH_REPO"); repo != "" {
+		return repo
+	}
+	url, err := git(ctx, "remote", "get-url", "origin")
+	if err != nil {
+		return ""
+	}
+	return audit.RepoFromRemote(url)
+}
+
+// git runs a local git command directly, bypassing the command runner so the
+// audit never shows up in recorded sessions.
+func git(ctx context.Context, args ...string) (string, error) {
+	res, err := runner.Exec{}.Run(ctx, runner.Command{Name: "git", Args: args})
+	return strings.TrimSpace(string(res.Stdout)), err
+}
+
+func init() {
+	rootCmd.AddCommand(logCmd)
+
+	// Define flags for log command
+	logCmd.Flags().StringVar(&logRepo, "repo", "", "Only show runs that changed this repository (owner/repo)")
+	logCmd.Flags().StringVar(&logSince, "since", "", "Only show runs on or after this date (YYYY-MM-DD or RFC 3339)")
+	logCmd.Flags().StringVar(&logUntil, "until", "", "Only show runs up to and including this date (YYYY-MM-DD or RFC 3339)")
+	logCmd.Flags().StringVar(&logFile, "file", "", "Only show runs that read this file")
+	logCmd.Flags().BoolVar(&logJSON, "json", false, "Print the matching entries as JSON lines")
+}
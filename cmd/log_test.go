@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/audit"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+func TestRecordAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	t.Setenv(audit.EnvFile, path)
+	t.Cleanup(func() { auditor = nil })
+	resetFlags(rootCmd)
+
+	auditor = audit.NewRecorder(runner.Func(func(context.Context, runner.Command) (runner.Result, error) {
+		return runner.Result{Stdout: []byte("https://github.com/owner/repo/issues/9\n")}, nil
+	}))
+	args := []string{"mkissue", "--file", "specs/a.issue.md", "--branch", "main"}
+	if err := mkissueCmd.Flags().Parse(args[1:]); err != nil {
+		t.Fatal(err)
+	}
+
+	// Runs that change nothing on GitHub aren't logged
+	recordAudit(mkissueCmd, args, nil)
+	if entries, _ := audit.Read(path); len(entries) != 0 {
+		t.Fatalf("logged %d entries for a run without changes", len(entries))
+	}
+
+	_, _ = auditor.Run(context.Background(), runner.Command{Name: "gh", Args: []string{"issue", "create"}, Mutating: true})
+	failure := &mkissue.PartialError{Completed: []string{"created issue"}, Err: errors.New("milestone not found")}
+	recordAudit(mkissueCmd, args, failure)
+
+	entries, err := audit.Read(path)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("audit.Read() = %v, %v; want one entry", entries, err)
+	}
+	e := entries[0]
+	if e.Command != "mkissue" || e.Repo != "owner/repo" || e.Outcome != "partial" || e.Error != failure.Error() {
+		t.Errorf("entry = %+v", e)
+	}
+	if e.Source == nil || e.Source.File != "specs/a.issue.md" || e.Source.Branch != "main" {
+		t.Errorf("entry source = %+v", e.Source)
+	}
+	if len(e.Operations) != 1 || e.Operations[0].Number != 9 {
+		t.Errorf("entry operations = %+v", e.Operations)
+	}
+	if time.Since(e.Time) > time.Minute {
+		t.Errorf("entry time = %v", e.Time)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	day := time.Date(2026, 5, 1, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		value   string
+		end     bool
+		want    time.Time
+		wantErr bool
+	}{
+		{value: ""},
+		{value: "2026-05-01", want: day},
+		{value: "2026-05-01", end: true, want: day.AddDate(0, 0, 1)},
+		{value: "2026-05-01T12:00:00Z", end: true, want: time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{value: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseDate(tt.value, tt.end)
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q, %v) = %v, %v; want %v", tt.value, tt.end, got, err, tt.want)
+		}
+	}
+}
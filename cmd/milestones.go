@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/lakruzz/gh-utils/cmd/milestones"
+	"github.com/spf13/cobra"
+)
+
+var (
+	milestonesState       string
+	milestonesOutput      string
+	milestonesKeepPartial bool
+)
+
+var milestonesCmd = &cobra.Command{
+	Use:   "milestones",
+	Short: "Export, import and roll over milestones",
+	Long: `Export, import and roll over the milestones of the current repository.
+
+Milestones are exported as YAML in the format of the milestone field of
+issue files:
+
+  milestones:
+    - title: "v1.0"
+      state: open
+      due_on: 2026-06-30
+      description: "First release"`,
+}
+
+var milestonesExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write the milestones as YAML",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if milestonesOutput == "" {
+			return milestones.Export(cmd.Context(), os.Stdout, milestonesState, milestonesOptions())
+		}
+		// Only write the file when the export succeeded
+		var buf bytes.Buffer
+		if err := milestones.Export(cmd.Context(), &buf, milestonesState, milestonesOptions()); err != nil {
+			return err
+		}
+		if err := os.WriteFile(milestonesOutput, buf.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("failed to write '%s': %w", milestonesOutput, err)
+		}
+		return nil
+	},
+}
+
+var milestonesImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Create and update milestones from a YAML file",
+	Long: `Create the milestones of a file written by 'utils milestones export' that
+don't exist yet, and update the due date, description and state of the ones
+that do. Values missing from the file are left unchanged.
+
+When a run fails, the milestones created and updated so far are rolled
+back, unless --keep-partial is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return validationError("failed to read '%s': %v", args[0], err)
+		}
+		list, err := milestones.Parse(data)
+		if err != nil {
+			return fmt.Errorf("'%s': %w", args[0], err)
+		}
+		return milestones.Import(cmd.Context(), list, milestonesOptions())
+	},
+}
+
+var milestonesRolloverCmd = &cobra.Command{
+	Use:   "rollover <from> <to>",
+	Short: "Move the open issues of a finished milestone to the next and close it",
+	Long: `Move the open issues and pull requests of the milestone <from> to the open
+milestone <to>, then close <from>.
+
+When a run fails, the issues moved so far are moved back, unless
+--keep-partial is given.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return milestones.Rollover(cmd.Context(), args[0], args[1], milestonesOptions())
+	},
+}
+
+func milestonesOptions() milestones.Options {
+	return milestones.Options{KeepPartial: milestonesKeepPartial, Runner: commandRunner}
+}
+
+func init() {
+	rootCmd.AddCommand(milestonesCmd)
+	milestonesCmd.AddCommand(milestonesExportCmd, milestonesImportCmd, milestonesRolloverCmd)
+
+	// Define flags for milestones commands
+	milestonesExportCmd.Flags().StringVar(&milestonesState, "state", milestones.StateOpen, "Milestones to export: open, closed or all")
+	milestonesExportCmd.Flags().StringVarP(&milestonesOutput, "output", "o", "", "File to write to instead of stdout")
+	for _, c := range []*cobra.Command{milestonesImportCmd, milestonesRolloverCmd} {
+		c.Flags().BoolVar(&milestonesKeepPartial, "keep-partial", false, "Keep the changes made on GitHub when the run fails, instead of rolling them back")
+	}
+}
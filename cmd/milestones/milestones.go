@@ -0,0 +1,243 @@
+// Package milestones exports, imports and rolls over the milestones of the
+// current repository. Exported milestones use the format of the milestone
+// field of issue files, so they can be kept in a file and imported elsewhere.
+package milestones
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/config"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// States accepted by Export.
+const (
+	StateOpen   = mkissue.MilestoneOpen
+	StateClosed = mkissue.MilestoneClosed
+	StateAll    = "all"
+)
+
+// Options control how changes made on GitHub are run and rolled back.
+type Options struct {
+	// KeepPartial leaves the changes made on GitHub in place when a run fails,
+	// instead of rolling them back.
+	KeepPartial bool
+	// Runner executes every gh invocation; nil uses runner.Default().
+	Runner runner.Runner
+}
+
+// Export writes the milestones in state (open, closed or all) to w, in the
+// format read by Parse.
+func Export(ctx context.Context, w io.Writer, state string, opts Options) error {
+	if state != StateOpen && state != StateClosed && state != StateAll {
+		return &mkissue.Error{Kind: mkissue.ErrValidation, Err: fmt.Errorf("state must be open, closed or all, got '%s'", state)}
+	}
+	all, err := mkissue.NewSession(opts.Runner).Milestones(ctx)
+	if err != nil {
+		return err
+	}
+	var selected []mkissue.Milestone
+	for _, m := range all {
+		if state == StateAll || m.State == state {
+			selected = append(selected, m)
+		}
+	}
+	_, err = io.WriteString(w, Format(selected))
+	return err
+}
+
+// Format returns milestones as a YAML document with a milestones list.
+func Format(milestones []mkissue.Milestone) string {
+	var b strings.Builder
+	b.WriteString("milestones:")
+	if len(milestones) == 0 {
+		b.WriteString(" []")
+	}
+	b.WriteString("\n")
+	for _, m := range milestones {
+		fmt.Fprintf(&b, "  - title: %s\n", quote(m.Title))
+		if m.State != "" {
+			fmt.Fprintf(&b, "    state: %s\n", m.State)
+		}
+		if m.DueOn != "" {
+			fmt.Fprintf(&b, "    due_on: %s\n", m.DueOn)
+		}
+		if m.Description != "" {
+			fmt.Fprintf(&b, "    description: %s\n", quote(m.Description))
+		}
+	}
+	return b.String()
+}
+
+// quote returns s as a double-quoted YAML scalar.
+func quote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", "")
+	return `"` + r.Replace(s) + `"`
+}
+
+// Parse reads a document with a milestones list, whose items are titles or
+// mappings like the milestone field of issue files.
+func Parse(data []byte) ([]mkissue.Milestone, error) {
+	invalid := func(err error) error {
+		return &mkissue.Error{Kind: mkissue.ErrValidation, Err: err}
+	}
+	tree, err := config.ParseYAML(data)
+	if err != nil {
+		return nil, invalid(err)
+	}
+	root, err := config.Map(tree)
+	if err != nil {
+		return nil, invalid(err)
+	}
+	items, err := config.List(root["milestones"])
+	if err != nil {
+		return nil, invalid(fmt.Errorf("milestones: %w", err))
+	}
+	var milestones []mkissue.Milestone
+	seen := map[string]bool{}
+	for i, item := range items {
+		m, err := mkissue.DecodeMilestone(item)
+		if err != nil {
+			return nil, invalid(fmt.Errorf("milestones[%d]: %w", i, err))
+		}
+		if seen[m.Title] {
+			return nil, invalid(fmt.Errorf("milestones[%d]: '%s' is listed twice", i, m.Title))
+		}
+		seen[m.Title] = true
+		milestones = append(milestones, *m)
+	}
+	return milestones, nil
+}
+
+// Import creates the milestones that don't exist yet and updates the due
+// date, description and state of the ones that do, where they are given and
+// differ. A failed run is rolled back unless opts.KeepPartial is set.
+func Import(ctx context.Context, milestones []mkissue.Milestone, opts Options) error {
+	s := mkissue.NewSession(opts.Runner)
+	existing, err := s.Milestones(ctx)
+	if err != nil {
+		return err
+	}
+
+	changed := 0
+	for _, m := range milestones {
+		current := mkissue.FindMilestone(existing, m.Title)
+		if current == nil {
+			if _, err := s.CreateMilestone(ctx, m); err != nil {
+				return s.Fail(ctx, err, opts.KeepPartial)
+			}
+			changed++
+			continue
+		}
+		if !differs(*current, m) {
+			continue
+		}
+		fmt.Printf("Updating milestone: %s\n", m.Title)
+		if err := s.UpdateMilestone(ctx, *current, m); err != nil {
+			return s.Fail(ctx, err, opts.KeepPartial)
+		}
+		changed++
+	}
+	if changed == 0 {
+		fmt.Println("All milestones are up to date")
+	}
+	return nil
+}
+
+// differs reports whether want sets a due date, description or state other
+// than those of current.
+func differs(current, want mkissue.Milestone) bool {
+	return want.DueOn != "" && want.DueOn != current.DueOn ||
+		want.Description != "" && want.Description != current.Description ||
+		want.State != "" && want.State != current.State
+}
+
+// Rollover moves the open issues and pull requests of the milestone from to
+// the open milestone to, and closes from. A failed run is rolled back unless
+// opts.KeepPartial is set.
+func Rollover(ctx context.Context, from, to string, opts Options) error {
+	invalid := func(format string, args ...any) error {
+		return &mkissue.Error{Kind: mkissue.ErrValidation, Err: fmt.Errorf(format, args...)}
+	}
+	if from == to {
+		return invalid("cannot roll '%s' over to itself", from)
+	}
+	s := mkissue.NewSession(opts.Runner)
+	milestones, err := s.Milestones(ctx)
+	if err != nil {
+		return err
+	}
+	source, target := mkissue.FindMilestone(milestones, from), mkissue.FindMilestone(milestones, to)
+	switch {
+	case source == nil:
+		return invalid("milestone '%s' not found", from)
+	case target == nil:
+		return invalid("milestone '%s' not found", to)
+	case target.State == mkissue.MilestoneClosed:
+		return invalid("milestone '%s' is closed", to)
+	}
+
+	numbers, err := openIssues(ctx, s, source.Number)
+	if err != nil {
+		return err
+	}
+	for _, n := range numbers {
+		if err := setMilestone(ctx, s, n, target.Number); err != nil {
+			return s.Fail(ctx, err, opts.KeepPartial)
+		}
+		s.Record(fmt.Sprintf("moved #%d to '%s'", n, to), runner.Command{
+			Name: "gh",
+			Args: []string{"api", "--method", "PATCH", fmt.Sprintf("repos/{owner}/{repo}/issues/%d", n), "-F", fmt.Sprintf("milestone=%d", source.Number)},
+		})
+	}
+	if source.State != mkissue.MilestoneClosed {
+		if err := s.UpdateMilestone(ctx, *source, mkissue.Milestone{Title: source.Title, State: mkissue.MilestoneClosed}); err != nil {
+			return s.Fail(ctx, err, opts.KeepPartial)
+		}
+	}
+	fmt.Printf("Moved %d open issue(s) from '%s' to '%s' and closed '%s'\n", len(numbers), from, to, from)
+	return nil
+}
+
+// openIssues returns the numbers of the open issues and pull requests in milestone.
+func openIssues(ctx context.Context, s *mkissue.Session, milestone int) ([]int, error) {
+	output, err := s.Run(ctx, runner.Command{
+		Name: "gh",
+		Args: []string{"api", "--paginate", fmt.Sprintf("repos/{owner}/{repo}/issues?milestone=%d&state=open&per_page=100", milestone)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the open issues of the milestone: %w", err)
+	}
+	var numbers []int
+	dec := json.NewDecoder(strings.NewReader(string(output)))
+	for dec.More() {
+		var page []struct {
+			Number int `json:"number"`
+		}
+		if err := dec.Decode(&page); err != nil {
+			return nil, fmt.Errorf("failed to list the open issues of the milestone: %w", err)
+		}
+		for _, issue := range page {
+			numbers = append(numbers, issue.Number)
+		}
+	}
+	return numbers, nil
+}
+
+// setMilestone sets the milestone of issue number n.
+func setMilestone(ctx context.Context, s *mkissue.Session, n, milestone int) error {
+	_, err := s.Run(ctx, runner.Command{
+		Name:     "gh",
+		Args:     []string{"api", "--method", "PATCH", fmt.Sprintf("repos/{owner}/{repo}/issues/%d", n), "-F", fmt.Sprintf("milestone=%d", milestone)},
+		Mutating: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move #%d: %w", n, err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package milestones
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+func gh(stdout string, args ...string) runner.Interaction {
+	return runner.Interaction{Name: "gh", Args: args, Stdout: stdout}
+}
+
+var list = []string{"api", "--paginate", "repos/{owner}/{repo}/milestones?state=all&per_page=100"}
+
+const existing = `[
+  {"number":1,"title":"v1.0","state":"open","due_on":"2026-05-01T07:00:00Z","description":"First \"real\" release","open_issues":2},
+  {"number":2,"title":"v1.1","state":"open","open_issues":0},
+  {"number":3,"title":"v0.9","state":"closed","open_issues":0}
+]`
+
+func TestExportAndParse(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Export(context.Background(), &buf, StateOpen, Options{Runner: testutil.Expect(t, gh(existing, list...))}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	want := `milestones:
+  - title: "v1.0"
+    state: open
+    due_on: 2026-05-01
+    description: "First \"real\" release"
+  - title: "v1.1"
+    state: open
+`
+	if buf.String() != want {
+		t.Errorf("Export() =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	got, err := Parse(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	wantParsed := []mkissue.Milestone{
+		{Title: "v1.0", State: "open", DueOn: "2026-05-01", Description: `First "real" release`},
+		{Title: "v1.1", State: "open"},
+	}
+	if !reflect.DeepEqual(got, wantParsed) {
+		t.Errorf("Parse() = %+v, want %+v", got, wantParsed)
+	}
+
+	if err := Export(context.Background(), &buf, "done", Options{Runner: testutil.Expect(t)}); !errors.Is(err, mkissue.ErrValidation) {
+		t.Errorf("Export(done) error = %v, want a validation error", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"- v1.0\n",
+		"milestones: [{due_on: 2026-05-01}]\n",
+		"milestones: [v1.0, {title: v1.0}]\n",
+	} {
+		if _, err := Parse([]byte(input)); !errors.Is(err, mkissue.ErrValidation) {
+			t.Errorf("Parse(%q) error = %v, want a validation error", input, err)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	r := testutil.Expect(t,
+		gh(existing, list...),
+		gh(`{}`, "api", "--method", "PATCH", "repos/{owner}/{repo}/milestones/2", "-f", "title=v1.1", "-f", "due_on=2026-06-01T00:00:00Z"),
+		gh(`{"number":4,"title":"v2.0"}`, "api", "--method", "POST", "repos/{owner}/{repo}/milestones", "-f", "title=v2.0", "-f", "due_on=2026-09-01T00:00:00Z"),
+	)
+	err := Import(context.Background(), []mkissue.Milestone{
+		{Title: "v1.0", Description: `First "real" release`},
+		{Title: "v1.1", DueOn: "2026-06-01"},
+		{Title: "v2.0", DueOn: "2026-09-01"},
+	}, Options{Runner: r})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+}
+
+func TestImportRollsBack(t *testing.T) {
+	r := testutil.Expect(t,
+		gh(existing, list...),
+		gh(`{}`, "api", "--method", "PATCH", "repos/{owner}/{repo}/milestones/2", "-f", "title=v1.1", "-f", "due_on=2026-06-01T00:00:00Z"),
+		runner.Interaction{
+			Name:     "gh",
+			Args:     []string{"api", "--method", "POST", "repos/{owner}/{repo}/milestones", "-f", "title=v2.0"},
+			Stderr:   "HTTP 422: Validation Failed",
+			ExitCode: 1,
+		},
+		gh(`{}`, "api", "--method", "PATCH", "repos/{owner}/{repo}/milestones/2", "-f", "title=v1.1", "-f", "state=open", "-F", "due_on=null"),
+	)
+	err := Import(context.Background(), []mkissue.Milestone{
+		{Title: "v1.1", DueOn: "2026-06-01"},
+		{Title: "v2.0", Description: ""},
+	}, Options{Runner: r})
+	if err == nil || !strings.Contains(err.Error(), "failed to create milestone 'v2.0'") {
+		t.Errorf("Import() error = %v", err)
+	}
+}
+
+func TestRollover(t *testing.T) {
+	issues := []string{"api", "--paginate", "repos/{owner}/{repo}/issues?milestone=1&state=open&per_page=100"}
+	move := func(issue, milestone string) runner.Interaction {
+		return gh(`{}`, "api", "--method", "PATCH", "repos/{owner}/{repo}/issues/"+issue, "-F", "milestone="+milestone)
+	}
+
+	r := testutil.Expect(t,
+		gh(existing, list...),
+		gh(`[{"number":11},{"number":12}]`, issues...),
+		move("11", "2"),
+		move("12", "2"),
+		gh(`{}`, "api", "--method", "PATCH", "repos/{owner}/{repo}/milestones/1", "-f", "title=v1.0", "-f", "state=closed"),
+	)
+	if err := Rollover(context.Background(), "v1.0", "v1.1", Options{Runner: r}); err != nil {
+		t.Fatalf("Rollover() error = %v", err)
+	}
+
+	// A failure moves the issues back
+	r = testutil.Expect(t,
+		gh(existing, list...),
+		gh(`[{"number":11},{"number":12}]`, issues...),
+		move("11", "2"),
+		runner.Interaction{
+			Name:     "gh",
+			Args:     []string{"api", "--method", "PATCH", "repos/{owner}/{repo}/issues/12", "-F", "milestone=2"},
+			Stderr:   "HTTP 403: Forbidden",
+			ExitCode: 1,
+		},
+		move("11", "1"),
+	)
+	err := Rollover(context.Background(), "v1.0", "v1.1", Options{Runner: r})
+	if err == nil || !strings.Contains(err.Error(), "failed to move #12") {
+		t.Errorf("Rollover() error = %v", err)
+	}
+
+	for _, tt := range []struct{ from, to, want string }{
+		{"v1.0", "v1.0", "over to itself"},
+		{"v3.0", "v1.1", "'v3.0' not found"},
+		{"v1.0", "v0.9", "'v0.9' is closed"},
+	} {
+		r := runner.NewReplayer(gh(existing, list...))
+		err := Rollover(context.Background(), tt.from, tt.to, Options{Runner: r})
+		if !errors.Is(err, mkissue.ErrValidation) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Rollover(%s, %s) error = %v, want %q", tt.from, tt.to, err, tt.want)
+		}
+	}
+}
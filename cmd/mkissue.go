@@ -2,17 +2,24 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/secrets"
 	"github.com/spf13/cobra"
 )
 
 var (
-	issueFile  string
-	branchName string
-	gistID     string
-	repoName   string
+	issueFile    string
+	branchName   string
+	gistID       string
+	repoName     string
+	keepPartial  bool
+	queueIssue   bool
+	queueOffline bool
+	linkRef      string
+	noRewrite    bool
+	strictSize   bool
+	allowSecrets bool
+	mentionFlag  string
 )
 
 var mkissueCmd = &cobra.Command{
@@ -29,21 +36,101 @@ Rules:
   --file is always required
   --branch is optional (defaults to the repo's default branch when used with --repo)
   --gist and --repo are mutually exclusive
-  --branch is not valid with --gist`,
-	RunE: func(_ *cobra.Command, _ []string) error {
-		// Validate that branch and gist are not both specified
-		if branchName != "" && gistID != "" {
-			return fmt.Errorf("cannot use both --branch and --gist flags together")
+  --branch is not valid with --gist
+
+When a run fails, every change it made on GitHub (e.g. created labels) is
+rolled back in reverse order, unless --keep-partial is given.
+
+Relative links and images in a file read with --branch or --repo are
+rewritten to absolute URLs in that repository, pinned to the commit the file
+was read from (--link-ref=sha) or following the branch (--link-ref=branch).
+Use --no-rewrite-links to leave them as they are.
+
+A body over GitHub's limit of 65,536 characters is split at headings or
+paragraphs, and the rest is posted as numbered comments. Use --strict-size
+to fail instead.
+
+The title, body and comments are scanned for tokens, keys and other secrets
+before anything is published, see 'utils scan'. Findings fail the run unless
+--allow-secrets is given.
+
+@user and @org/team mentions outside code would notify people once the
+issue is published. The mention policy, set in the mentions section of
+.utils.yml or with --mentions, decides what happens to mentions that aren't
+assignees or on the allowlist: allow publishes them, warn (the default)
+reports them, escape escapes them and allowlist fails the run.
+
+With --queue the issue is stored in the outbox instead of being created,
+and with --queue-offline only when GitHub can't be reached. The issue is
+queued for the repository gh would create it in ($GH_REPO or the origin
+remote) and its host. Create the queued issues later with
+'utils outbox flush'.`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if err := validateSource(branchName, gistID, repoName); err != nil {
+			return err
+		}
+		repo, err := hostRepo(repoName)
+		if err != nil {
+			return err
 		}
-		// Validate that repo and gist are not both specified
-		if repoName != "" && gistID != "" {
-			return fmt.Errorf("cannot use both --repo and --gist flags together")
+		if queueIssue && queueOffline {
+			return validationError("cannot use both --queue and --queue-offline flags together")
 		}
-		// Call the original mkissue logic with the file path, branch, gist, and repo
-		return mkissue.RunWithFile(issueFile, branchName, gistID, repoName)
+		if linkRef != mkissue.LinkRefSHA && linkRef != mkissue.LinkRefBranch {
+			return validationError("--link-ref must be sha or branch, got '%s'", linkRef)
+		}
+		scanner, err := secrets.New(secretRules)
+		if err != nil {
+			return validationError("%v", err)
+		}
+		policy := mentions.Policy
+		if mentionFlag != "" {
+			policy = mentionFlag
+		}
+		switch policy {
+		case "", mkissue.MentionsAllow, mkissue.MentionsWarn, mkissue.MentionsEscape, mkissue.MentionsAllowlist:
+		default:
+			return validationError("--mentions must be allow, warn, escape or allowlist, got '%s'", policy)
+		}
+		// Create the issue from the file read from the local path, branch, gist or repo
+		return mkissue.Create(cmd.Context(), issueFile, mkissue.Options{
+			Branch:         branchName,
+			Gist:           gistID,
+			Repo:           repo,
+			Runner:         commandRunner,
+			KeepPartial:    keepPartial,
+			Queue:          queueIssue,
+			QueueOffline:   queueOffline,
+			Host:           host,
+			LinkRef:        linkRef,
+			NoRewriteLinks: noRewrite,
+			StrictSize:     strictSize,
+			Secrets:        scanner,
+			AllowSecrets:   allowSecrets,
+			Mentions:       mentionPolicy(policy),
+		})
 	},
 }
 
+// mentionPolicy returns the mention policy of the config with policy, which
+// may come from a flag, as its policy.
+func mentionPolicy(policy string) mkissue.MentionPolicy {
+	return mkissue.MentionPolicy{Policy: policy, Allowlist: mentions.Allowlist, Escape: mentions.Escape}
+}
+
+// validateSource checks the combination of the --branch, --gist and --repo flags.
+func validateSource(branch, gist, repo string) error {
+	// Validate that branch and gist are not both specified
+	if branch != "" && gist != "" {
+		return validationError("cannot use both --branch and --gist flags together")
+	}
+	// Validate that repo and gist are not both specified
+	if repo != "" && gist != "" {
+		return validationError("cannot use both --repo and --gist flags together")
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.CompletionOptions.HiddenDefaultCmd = true
 	rootCmd.AddCommand(mkissueCmd)
@@ -52,6 +139,14 @@ func init() {
 	mkissueCmd.Flags().StringVarP(&issueFile, "file", "f", "", "Path to the markdown file containing issue content (required)")
 	mkissueCmd.Flags().StringVarP(&branchName, "branch", "b", "", "Branch name to get the file from (optional)")
 	mkissueCmd.Flags().StringVarP(&gistID, "gist", "g", "", "Gist ID to get the file from (optional)")
-	mkissueCmd.Flags().StringVarP(&repoName, "repo", "r", "", "Repository to get the file from, in owner/repo format (optional)")
+	mkissueCmd.Flags().StringVarP(&repoName, "repo", "r", "", "Repository to get the file from, in owner/repo or host/owner/repo format (optional)")
+	mkissueCmd.Flags().BoolVar(&keepPartial, "keep-partial", false, "Keep labels and other changes made on GitHub when the run fails, instead of rolling them back")
+	mkissueCmd.Flags().BoolVar(&queueIssue, "queue", false, "Store the issue in the outbox instead of creating it")
+	mkissueCmd.Flags().BoolVar(&queueOffline, "queue-offline", false, "Store the issue in the outbox when GitHub can't be reached")
+	mkissueCmd.Flags().StringVar(&linkRef, "link-ref", mkissue.LinkRefSHA, "Point rewritten relative links at the commit (sha) or the branch (branch)")
+	mkissueCmd.Flags().BoolVar(&noRewrite, "no-rewrite-links", false, "Leave relative links and images as they are")
+	mkissueCmd.Flags().BoolVar(&strictSize, "strict-size", false, "Fail when the body is too long for an issue, instead of continuing it in comments")
+	mkissueCmd.Flags().BoolVar(&allowSecrets, "allow-secrets", false, "Publish the issue even when it contains possible secrets")
+	mkissueCmd.Flags().StringVar(&mentionFlag, "mentions", "", "Mention policy: allow, warn, escape or allowlist (overrides the config)")
 	_ = mkissueCmd.MarkFlagRequired("file")
 }
@@ -0,0 +1,182 @@
+package mkissue
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// Sentinel errors describing why a run failed. Every error returned by this
+// package can be matched against them with errors.Is.
+var (
+	// ErrValidation means the flags or the issue file are invalid.
+	ErrValidation = errors.New("validation failed")
+	// ErrSourceNotFound means the issue file doesn't exist in the requested source.
+	ErrSourceNotFound = errors.New("source not found")
+	// ErrAuth means gh is not authenticated or lacks permissions.
+	ErrAuth = errors.New("authentication failed")
+	// ErrRateLimited means GitHub kept rejecting calls because of rate limits.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrNetwork means GitHub could not be reached or kept failing with server errors.
+	ErrNetwork = errors.New("network error")
+	// ErrPartial means some changes were made on GitHub before the run failed.
+	ErrPartial = errors.New("partially completed")
+)
+
+// Exit codes returned by utils. They are part of the command line contract
+// and must not change.
+const (
+	ExitOK             = 0
+	ExitFailure        = 1
+	ExitValidation     = 2
+	ExitSourceNotFound = 3
+	ExitAuth           = 4
+	ExitRateLimited    = 5
+	ExitNetwork        = 6
+	ExitPartial        = 7
+	ExitTimeout        = 124
+	ExitCancelled      = 130
+)
+
+// Error attaches one of the sentinel kinds to an underlying error. The message
+// is that of the underlying error.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap makes both the kind and the underlying error visible to errors.Is and errors.As.
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// PartialError is returned when the run failed after some changes were
+// already applied on GitHub.
+type PartialError struct {
+	// Completed describes the changes that were applied, in order.
+	Completed []string
+	// Issue is the URL of the issue the run created, when it is still on
+	// GitHub; "" when no issue was created or it was rolled back.
+	Issue string
+	Err   error
+}
+
+func (e *PartialError) Error() string {
+	return e.Err.Error() + " (already applied: " + strings.Join(e.Completed, ", ") + ")"
+}
+
+// Is reports that a PartialError matches ErrPartial.
+func (e *PartialError) Is(target error) bool {
+	return target == ErrPartial
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// newError wraps err with the given kind; a nil kind returns err unchanged.
+func newError(kind, err error) error {
+	if kind == nil {
+		return err
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// commandKind works out which sentinel describes a failed gh or git command,
+// or returns nil when it doesn't fit any of them.
+func commandKind(err error) error {
+	var exitErr *runner.ExitError
+	if !errors.As(err, &exitErr) {
+		return nil
+	}
+
+	res := exitErr.Result
+	stderr := strings.ToLower(string(res.Stderr))
+	if exitErr.Command.Name == "gh" && res.ExitCode == 4 ||
+		strings.Contains(stderr, "http 401") || strings.Contains(stderr, "gh auth login") ||
+		strings.Contains(stderr, "bad credentials") || strings.Contains(stderr, "resource not accessible") {
+		return ErrAuth
+	}
+
+	decision := runner.Classify(exitErr.Command, res, err)
+	if decision.RateLimited {
+		return ErrRateLimited
+	}
+	if decision.Network {
+		return ErrNetwork
+	}
+	return nil
+}
+
+// readKind is like commandKind, but also recognizes a missing file, branch,
+// gist or repository when reading the issue file.
+func readKind(err error) error {
+	if kind := commandKind(err); kind != nil {
+		return kind
+	}
+	stderr := strings.ToLower(runner.Stderr(err))
+	for _, notFound := range []string{"not found", "http 404", "invalid object name", "does not exist", "exists on disk, but not in"} {
+		if strings.Contains(stderr, notFound) {
+			return ErrSourceNotFound
+		}
+	}
+	return nil
+}
+
+// ExitCode maps an error returned by this package to the documented exit code.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrPartial):
+		return ExitPartial
+	case errors.Is(err, context.DeadlineExceeded):
+		return ExitTimeout
+	case errors.Is(err, context.Canceled):
+		return ExitCancelled
+	case errors.Is(err, ErrValidation):
+		return ExitValidation
+	case errors.Is(err, ErrSourceNotFound):
+		return ExitSourceNotFound
+	case errors.Is(err, ErrAuth):
+		return ExitAuth
+	case errors.Is(err, ErrRateLimited):
+		return ExitRateLimited
+	case errors.Is(err, ErrNetwork):
+		return ExitNetwork
+	default:
+		return ExitFailure
+	}
+}
+
+// KindName returns a stable, machine readable name for the kind of err.
+func KindName(err error) string {
+	switch ExitCode(err) {
+	case ExitOK:
+		return ""
+	case ExitPartial:
+		return "partial"
+	case ExitTimeout:
+		return "timeout"
+	case ExitCancelled:
+		return "cancelled"
+	case ExitValidation:
+		return "validation"
+	case ExitSourceNotFound:
+		return "source_not_found"
+	case ExitAuth:
+		return "auth"
+	case ExitRateLimited:
+		return "rate_limited"
+	case ExitNetwork:
+		return "network"
+	default:
+		return "error"
+	}
+}
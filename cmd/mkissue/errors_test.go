@@ -0,0 +1,112 @@
+package mkissue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"no error", nil, ExitOK},
+		{"plain error", errors.New("boom"), ExitFailure},
+		{"validation", newError(ErrValidation, errors.New("bad")), ExitValidation},
+		{"wrapped validation", fmt.Errorf("context: %w", newError(ErrValidation, errors.New("bad"))), ExitValidation},
+		{"source not found", newError(ErrSourceNotFound, errors.New("missing")), ExitSourceNotFound},
+		{"auth", newError(ErrAuth, errors.New("401")), ExitAuth},
+		{"rate limited", newError(ErrRateLimited, errors.New("slow down")), ExitRateLimited},
+		{"network", newError(ErrNetwork, errors.New("502")), ExitNetwork},
+		{"partial wins over its cause", &PartialError{Completed: []string{"x"}, Err: newError(ErrAuth, errors.New("401"))}, ExitPartial},
+		{"timeout", fmt.Errorf("gh: %w", context.DeadlineExceeded), ExitTimeout},
+		{"cancelled", fmt.Errorf("gh: %w", context.Canceled), ExitCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandKind(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  runner.Command
+		res  runner.Result
+		want error
+	}{
+		{"gh auth exit code", runner.Command{Name: "gh"}, runner.Result{ExitCode: 4}, ErrAuth},
+		{"bad credentials", runner.Command{Name: "gh"}, runner.Result{ExitCode: 1, Stderr: []byte("HTTP 401: Bad credentials")}, ErrAuth},
+		{"secondary rate limit", runner.Command{Name: "gh"}, runner.Result{ExitCode: 1, Stderr: []byte("HTTP 403: secondary rate limit")}, ErrRateLimited},
+		{"server error", runner.Command{Name: "gh"}, runner.Result{ExitCode: 1, Stderr: []byte("HTTP 502: Bad Gateway")}, ErrNetwork},
+		{"other failure", runner.Command{Name: "gh"}, runner.Result{ExitCode: 1, Stderr: []byte("HTTP 422: Validation Failed")}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &runner.ExitError{Command: tt.cmd, Result: tt.res}
+			if got := commandKind(err); got != tt.want {
+				t.Errorf("commandKind() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateErrorKinds(t *testing.T) {
+	issueFile := filepath.Join("testdata", "labels.issue.md")
+	tests := []struct {
+		name      string
+		issueFile string
+		opts      func(t *testing.T) Options
+		want      error
+	}{
+		{
+			name:      "missing local file",
+			issueFile: filepath.Join("testdata", "missing.issue.md"),
+			opts:      func(t *testing.T) Options { return Options{Runner: testutil.Expect(t)} },
+			want:      ErrSourceNotFound,
+		},
+		{
+			name:      "missing branch",
+			issueFile: "nonexistent.md",
+			opts: func(t *testing.T) Options {
+				return Options{Branch: "nonexistent-branch", Runner: replay(t, "branch-not-found")}
+			},
+			want: ErrSourceNotFound,
+		},
+		{
+			name:      "invalid gist ID",
+			issueFile: "issue.md",
+			opts:      func(t *testing.T) Options { return Options{Gist: "nope", Runner: testutil.Expect(t)} },
+			want:      ErrValidation,
+		},
+		{
+			name:      "issue creation fails after creating a label",
+			issueFile: issueFile,
+			opts: func(t *testing.T) Options {
+				return Options{KeepPartial: true, Runner: replay(t, "labels-issue-fails")}
+			},
+			want: ErrPartial,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Create(context.Background(), tt.issueFile, tt.opts(t))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Create() error = %v, want errors.Is(%v)", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package mkissue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/lakruzz/gh-utils/internal/config"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// CommentMarker separates the sections of an issue body that are posted as
+// comments, in order, after the issue is created.
+const CommentMarker = "<!-- comment -->"
+
+// Comment is a comment posted on the issue after it is created: inline text,
+// or a file read from the same source as the issue file.
+type Comment struct {
+	Body string `json:"body,omitempty"`
+	// File is resolved relative to the directory of the issue file.
+	File string `json:"file,omitempty"`
+}
+
+// Lock locks the conversation of the issue after it is created.
+type Lock struct {
+	// Reason is off_topic, too_heated, resolved or spam; "" gives no reason.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Issue states and the reasons a created issue can be closed with.
+const (
+	StateOpen             = "open"
+	StateClosed           = "closed"
+	StateReasonCompleted  = "completed"
+	StateReasonNotPlanned = "not planned"
+)
+
+var lockReasons = []string{"off_topic", "too_heated", "resolved", "spam"}
+
+// parseComments parses the comments field: a list of texts, or of mappings
+// with a file, e.g.
+//
+//	comments:
+//	  - "Some context"
+//	  - file: notes/design.md
+func parseComments(lines []string, startIdx int) ([]Comment, int, error) {
+	block, end := fieldBlock(lines, startIdx)
+	tree, err := config.ParseYAML([]byte(block))
+	if err != nil {
+		return nil, startIdx, fmt.Errorf("comments: %w", err)
+	}
+	root, _ := config.Map(tree)
+	items, err := config.List(root["comments"])
+	if err != nil {
+		return nil, startIdx, fmt.Errorf("comments: %w", err)
+	}
+	var comments []Comment
+	for n, item := range items {
+		if text, ok := item.(string); ok {
+			if strings.TrimSpace(text) == "" {
+				return nil, startIdx, fmt.Errorf("comments[%d]: comment is empty", n)
+			}
+			comments = append(comments, Comment{Body: text})
+			continue
+		}
+		m, err := config.Map(item)
+		if err != nil {
+			return nil, startIdx, fmt.Errorf("comments[%d]: expected a text or a mapping", n)
+		}
+		for key := range m {
+			if key != "file" {
+				return nil, startIdx, fmt.Errorf("comments[%d]: unknown key '%s'", n, key)
+			}
+		}
+		file := config.String(m["file"])
+		if file == "" {
+			return nil, startIdx, fmt.Errorf("comments[%d]: 'file' is required", n)
+		}
+		comments = append(comments, Comment{File: file})
+	}
+	return comments, end, nil
+}
+
+// parseLock parses the lock field: true, or a mapping with a reason, e.g.
+//
+//	lock: {reason: resolved}
+func parseLock(lines []string, startIdx int) (*Lock, int, error) {
+	block, end := fieldBlock(lines, startIdx)
+	tree, err := config.ParseYAML([]byte(block))
+	if err != nil {
+		return nil, startIdx, fmt.Errorf("lock: %w", err)
+	}
+	root, _ := config.Map(tree)
+	if _, ok := root["lock"].(map[string]any); !ok {
+		locked, err := config.Bool(root["lock"])
+		if err != nil {
+			return nil, startIdx, errors.New("lock: expected true, false or a mapping with a reason")
+		}
+		if !locked {
+			return nil, end, nil
+		}
+		return &Lock{}, end, nil
+	}
+	m, _ := config.Map(root["lock"])
+	for key := range m {
+		if key != "reason" {
+			return nil, startIdx, fmt.Errorf("lock: unknown key '%s'", key)
+		}
+	}
+	reason := strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(config.String(m["reason"])))
+	if reason != "" && !slices.Contains(lockReasons, reason) {
+		return nil, startIdx, fmt.Errorf("lock: reason must be one of %s, got '%s'", strings.Join(lockReasons, ", "), reason)
+	}
+	return &Lock{Reason: reason}, end, nil
+}
+
+// validateState checks the state and state_reason fields, and normalizes the
+// reason to the form gh expects.
+func validateState(metadata *IssueMetadata) error {
+	metadata.State = strings.ToLower(metadata.State)
+	if metadata.State != "" && metadata.State != StateOpen && metadata.State != StateClosed {
+		return fmt.Errorf("state must be open or closed, got '%s'", metadata.State)
+	}
+	if metadata.StateReason == "" {
+		return nil
+	}
+	if metadata.State != StateClosed {
+		return errors.New("state_reason requires 'state: closed'")
+	}
+	reason := strings.ReplaceAll(strings.ToLower(metadata.StateReason), "_", " ")
+	if reason != StateReasonCompleted && reason != StateReasonNotPlanned {
+		return fmt.Errorf("state_reason must be completed or not_planned, got '%s'", metadata.StateReason)
+	}
+	metadata.StateReason = reason
+	return nil
+}
+
+// splitComments splits the body at the comment markers, and returns the body
+// before the first marker and the sections after it as comments. Empty
+// sections are dropped.
+func splitComments(body string) (string, []Comment) {
+	parts := splitAt(body, CommentMarker)
+	if len(parts) == 1 {
+		return body, nil
+	}
+	var comments []Comment
+	for _, part := range parts[1:] {
+		if part = strings.TrimSpace(part); part != "" {
+			comments = append(comments, Comment{Body: part})
+		}
+	}
+	return strings.TrimSpace(parts[0]), comments
+}
+
+// resolveComments reads the comments given as files from the source of the
+// issue file and expands the includes of all comments, so that a failing read
+// stops the run before anything is created.
+func (c *client) resolveComments(ctx context.Context, issueFile string, comments []Comment, opts Options) error {
+	for i, comment := range comments {
+		file, body := issueFile, comment.Body
+		if comment.File != "" {
+			file = relativeTo(issueFile, comment.File, opts)
+			content, err := c.readSource(ctx, file, opts)
+			if err != nil {
+				return fmt.Errorf("comments[%d]: %w", i, err)
+			}
+			if strings.TrimSpace(string(content)) == "" {
+				return newError(ErrValidation, fmt.Errorf("comments[%d]: '%s' is empty", i, comment.File))
+			}
+			body = string(content)
+		}
+		body, err := c.expandIncludes(ctx, file, body, opts)
+		if err != nil {
+			return fmt.Errorf("comments[%d]: %w", i, err)
+		}
+		comments[i] = Comment{Body: body}
+	}
+	return nil
+}
+
+// followUp posts the rest of an oversized body and the comments on the
+// created issue, in order, then pins, locks and closes it as the metadata
+// asks. Each step is recorded so that a failed run is rolled back.
+func (c *client) followUp(ctx context.Context, url string, metadata *IssueMetadata) error {
+	if err := c.postContinued(ctx, url, metadata.Continued); err != nil {
+		return err
+	}
+	for i, comment := range metadata.Comments {
+		fmt.Printf("Adding comment %d of %d...\n", i+1, len(metadata.Comments))
+		if _, err := c.comment(ctx, url, comment.Body); err != nil {
+			return fmt.Errorf("failed to add comment %d: %w", i+1, err)
+		}
+	}
+
+	if metadata.Pin {
+		if err := c.changeIssue(ctx, url, "pinned", []string{"pin", url}, []string{"unpin", url}); err != nil {
+			return err
+		}
+	}
+	if metadata.Lock != nil {
+		args := []string{"lock", url}
+		if metadata.Lock.Reason != "" {
+			args = append(args, "--reason", metadata.Lock.Reason)
+		}
+		if err := c.changeIssue(ctx, url, "locked", args, []string{"unlock", url}); err != nil {
+			return err
+		}
+	}
+	if metadata.State == StateClosed {
+		args := []string{"close", url}
+		if metadata.StateReason != "" {
+			args = append(args, "--reason", metadata.StateReason)
+		}
+		if err := c.changeIssue(ctx, url, "closed", args, []string{"reopen", url}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// comment posts body as a comment on the issue at url, records its deletion
+// for rollback, and returns the URL of the comment, or "" when gh reports none.
+func (c *client) comment(ctx context.Context, url, body string) (string, error) {
+	output, err := c.run(ctx, runner.Command{
+		Name:     "gh",
+		Args:     []string{"issue", "comment", url, "--body-file", "-"},
+		Stdin:    []byte(body),
+		Mutating: true,
+	})
+	if err != nil {
+		return "", newError(commandKind(err), errors.New(runner.Stderr(err)))
+	}
+	// The comment is removed with the issue when no ID is reported
+	commentURL := strings.TrimSpace(string(output))
+	_, id, ok := strings.Cut(commentURL, "#issuecomment-")
+	if !ok {
+		return "", nil
+	}
+	c.journal.record(fmt.Sprintf("added comment %s", commentURL), runner.Command{
+		Name: "gh",
+		Args: []string{"api", "--method", "DELETE", "repos/{owner}/{repo}/issues/comments/" + id},
+	})
+	return commentURL, nil
+}
+
+// changeIssue runs gh issue with args, and records gh issue with undo for rollback.
+func (c *client) changeIssue(ctx context.Context, url, change string, args, undo []string) error {
+	cmd := append([]string{"issue"}, args...)
+	if _, err := c.run(ctx, runner.Command{Name: "gh", Args: cmd, Mutating: true}); err != nil {
+		return newError(commandKind(err), fmt.Errorf("failed to %s %s: %s", args[0], url, runner.Stderr(err)))
+	}
+	c.journal.record(fmt.Sprintf("%s issue %s", change, url), runner.Command{
+		Name: "gh",
+		Args: append([]string{"issue"}, undo...),
+	})
+	return nil
+}
+
+// relativeTo resolves file relative to the directory of issueFile within the
+// source given in opts. Gists have no directories, so their files are used as is.
+func relativeTo(issueFile, file string, opts Options) string {
+	switch {
+	case opts.Gist != "":
+		return file
+	case opts.Repo != "" || opts.Branch != "":
+		if strings.HasPrefix(file, "/") {
+			return strings.TrimPrefix(file, "/")
+		}
+		return path.Join(path.Dir(issueFile), file)
+	case filepath.IsAbs(file):
+		return file
+	default:
+		return filepath.Join(filepath.Dir(issueFile), file)
+	}
+}
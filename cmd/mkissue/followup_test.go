@@ -0,0 +1,161 @@
+package mkissue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+func TestParseFollowUps(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    IssueMetadata
+		wantErr string
+	}{
+		{
+			name:    "comments",
+			content: "comments:\n  - \"First\"\n  - file: notes.md\n  - |\n    Multi\n    line",
+			want:    IssueMetadata{Comments: []Comment{{Body: "First"}, {File: "notes.md"}, {Body: "Multi\nline\n"}}},
+		},
+		{
+			name:    "closed",
+			content: "state: Closed\nstate_reason: not_planned",
+			want:    IssueMetadata{State: StateClosed, StateReason: StateReasonNotPlanned},
+		},
+		{
+			name:    "lock and pin",
+			content: "lock: {reason: too heated}\npin: true",
+			want:    IssueMetadata{Lock: &Lock{Reason: "too_heated"}, Pin: true},
+		},
+		{name: "lock without reason", content: "lock: true", want: IssueMetadata{Lock: &Lock{}}},
+		{name: "unlocked", content: "lock: false\npin: no", want: IssueMetadata{}},
+		{name: "nested state", content: "milestone:\n  title: v1\n  state: closed", want: IssueMetadata{Milestone: &Milestone{Title: "v1", State: "closed"}}},
+		{name: "empty comment", content: "comments: [\"\"]", wantErr: "comment is empty"},
+		{name: "unknown comment key", content: "comments: [{path: a.md}]", wantErr: "unknown key 'path'"},
+		{name: "invalid state", content: "state: done", wantErr: "state must be open or closed"},
+		{name: "reason without close", content: "state_reason: completed", wantErr: "requires 'state: closed'"},
+		{name: "invalid reason", content: "state: closed\nstate_reason: duplicate", wantErr: "completed or not_planned"},
+		{name: "invalid lock reason", content: "lock: {reason: boring}", wantErr: "reason must be one of"},
+		{name: "invalid lock", content: "lock: forever", wantErr: "expected true, false or a mapping"},
+		{name: "invalid pin", content: "pin: maybe", wantErr: "pin: expected true or false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata, _, err := parseIssueFile("---\n" + tt.content + "\n---\nBody")
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseIssueFile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseIssueFile() error = %v", err)
+			}
+			if !reflect.DeepEqual(*metadata, tt.want) {
+				t.Errorf("parseIssueFile() = %+v, want %+v", *metadata, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitComments(t *testing.T) {
+	body := "Description\n\n" + CommentMarker + "\nFirst\n" + CommentMarker + "\n\n" + CommentMarker +
+		"\n```md\n" + CommentMarker + "\n```\n"
+	got, comments := splitComments(body)
+	if got != "Description" {
+		t.Errorf("body = %q, want %q", got, "Description")
+	}
+	want := []Comment{{Body: "First"}, {Body: "```md\n" + CommentMarker + "\n```"}}
+	if !reflect.DeepEqual(comments, want) {
+		t.Errorf("comments = %q, want %q", comments, want)
+	}
+
+	if got, comments := splitComments("  Body\n"); got != "  Body\n" || comments != nil {
+		t.Errorf("splitComments() = %q, %v, want the body unchanged", got, comments)
+	}
+}
+
+func TestRelativeTo(t *testing.T) {
+	tests := []struct {
+		issueFile, file string
+		opts            Options
+		want            string
+	}{
+		{"specs/a.issue.md", "notes.md", Options{}, filepath.Join("specs", "notes.md")},
+		{"specs/a.issue.md", "../notes.md", Options{Branch: "main"}, "notes.md"},
+		{"specs/a.issue.md", "/docs/notes.md", Options{Repo: "owner/repo"}, "docs/notes.md"},
+		{"a.issue.md", "notes.md", Options{Gist: "0123"}, "notes.md"},
+	}
+	for _, tt := range tests {
+		if got := relativeTo(tt.issueFile, tt.file, tt.opts); got != tt.want {
+			t.Errorf("relativeTo(%q, %q, %+v) = %q, want %q", tt.issueFile, tt.file, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestCreateFollowsUp(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.issue.md")
+	content := "---\ntitle: Done already\ncomments:\n  - file: notes.md\nstate: closed\nstate_reason: completed\nlock: {reason: resolved}\npin: true\n---\n" +
+		"Body\n" + CommentMarker + "\nFrom the body"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.md"), []byte("From a file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	url := "https://github.com/owner/repo/issues/3"
+	gh := func(stdin, stdout string, args ...string) runner.Interaction {
+		return runner.Interaction{Name: "gh", Args: args, Stdin: stdin, Stdout: stdout}
+	}
+
+	r := testutil.Expect(t,
+		gh("Body", url+"\n", "issue", "create", "--title", "Done already", "--body-file", "-"),
+		gh("From the body", url+"#issuecomment-11\n", "issue", "comment", url, "--body-file", "-"),
+		gh("From a file\n", url+"#issuecomment-12\n", "issue", "comment", url, "--body-file", "-"),
+		gh("", "", "issue", "pin", url),
+		gh("", "", "issue", "lock", url, "--reason", "resolved"),
+		gh("", "", "issue", "close", url, "--reason", "completed"),
+	)
+	if err := Create(context.Background(), file, Options{Runner: r}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// A failure rolls back the comments, the pin and the issue
+	r = testutil.Expect(t,
+		gh("Body", url+"\n", "issue", "create", "--title", "Done already", "--body-file", "-"),
+		gh("From the body", url+"#issuecomment-11\n", "issue", "comment", url, "--body-file", "-"),
+		gh("From a file\n", url+"#issuecomment-12\n", "issue", "comment", url, "--body-file", "-"),
+		gh("", "", "issue", "pin", url),
+		runner.Interaction{
+			Name:     "gh",
+			Args:     []string{"issue", "lock", url, "--reason", "resolved"},
+			Stderr:   "HTTP 403: Must have admin rights to Repository.",
+			ExitCode: 1,
+		},
+		gh("", "", "issue", "unpin", url),
+		gh("", "", "api", "--method", "DELETE", "repos/{owner}/{repo}/issues/comments/12"),
+		gh("", "", "api", "--method", "DELETE", "repos/{owner}/{repo}/issues/comments/11"),
+		gh("", "", "issue", "delete", url, "--yes"),
+	)
+	err := Create(context.Background(), file, Options{Runner: r})
+	if err == nil || !strings.Contains(err.Error(), "failed to lock "+url) {
+		t.Errorf("Create() error = %v", err)
+	}
+
+	// A missing comment file fails the run before anything is created
+	if err := os.Remove(filepath.Join(dir, "notes.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Create(context.Background(), file, Options{Runner: testutil.Expect(t)}); !errors.Is(err, ErrSourceNotFound) {
+		t.Errorf("Create() error = %v, want a source not found error", err)
+	}
+}
@@ -0,0 +1,100 @@
+package mkissue
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Frontmatter gives access to the top-level fields of a frontmatter block using
+// the same parsing rules as issue files, so that other file formats (e.g. pull
+// request files) can reuse them.
+type Frontmatter struct {
+	lines []string
+}
+
+// ParseFrontmatter splits content into its frontmatter and its trimmed markdown body.
+func ParseFrontmatter(content string) (*Frontmatter, string, error) {
+	frontmatter, body, err := splitFrontmatter(content)
+	if err != nil {
+		return nil, "", err
+	}
+	return &Frontmatter{lines: strings.Split(frontmatter, "\n")}, body, nil
+}
+
+// splitFrontmatter returns the text between the frontmatter delimiters and the body.
+func splitFrontmatter(content string) (string, string, error) {
+	// Split by frontmatter delimiters
+	parts := strings.Split(content, "---")
+	if len(parts) < 3 {
+		return "", "", newError(ErrValidation, errors.New("invalid format: frontmatter not found"))
+	}
+
+	frontmatter := strings.TrimSpace(parts[1])
+	body := strings.TrimSpace(strings.Join(parts[2:], "---"))
+	return frontmatter, body, nil
+}
+
+// index returns the line number of a top-level key, or -1 when it's missing.
+func (f *Frontmatter) index(key string) int {
+	prefix := key + ":"
+	for i, line := range f.lines {
+		if strings.HasPrefix(line, prefix) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Has reports whether the top-level key is present.
+func (f *Frontmatter) Has(key string) bool {
+	return f.index(key) >= 0
+}
+
+// String returns the scalar value of key, or "" when it's missing.
+func (f *Frontmatter) String(key string) string {
+	i := f.index(key)
+	if i < 0 {
+		return ""
+	}
+	return extractValue(strings.TrimSpace(f.lines[i]), key+":")
+}
+
+// List returns the items of an inline ([a, b]) or multi-line (- a) list.
+func (f *Frontmatter) List(key string) []string {
+	i := f.index(key)
+	if i < 0 {
+		return nil
+	}
+	items, _ := parseListField(f.lines, i, key+":")
+	return items
+}
+
+// Bool returns the boolean value of key; a missing or empty key is false.
+func (f *Frontmatter) Bool(key string) (bool, error) {
+	value := strings.ToLower(f.String(key))
+	switch value {
+	case "":
+		return false, nil
+	case "yes", "on":
+		return true, nil
+	case "no", "off":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, newError(ErrValidation, fmt.Errorf("'%s' must be true or false, got '%s'", key, value))
+	}
+	return b, nil
+}
+
+// Labels returns the labels listed under key, in the format used by issue files.
+func (f *Frontmatter) Labels(key string) []Label {
+	i := f.index(key)
+	if i < 0 {
+		return nil
+	}
+	labels, _ := parseLabels(f.lines, i)
+	return labels
+}
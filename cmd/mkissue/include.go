@@ -0,0 +1,68 @@
+package mkissue
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// includeDirective matches a line that includes a partial, e.g.
+//
+//	<!-- include: partials/definition-of-done.md -->
+var includeDirective = regexp.MustCompile(`^\s*<!--\s*include:\s*(.*?)\s*-->\s*$`)
+
+// expandIncludes replaces the include directives of content, read from file,
+// with the partials they name. Partials are read from the same source as the
+// issue file, relative to the file that includes them, and may include others.
+// Directives inside code blocks are left alone. The relative links of each
+// file are rewritten before it is included.
+func (c *client) expandIncludes(ctx context.Context, file, content string, opts Options) (string, error) {
+	start := filepath.Clean(file)
+	if opts.Repo != "" || opts.Branch != "" {
+		start = path.Clean(file)
+	}
+	return c.expand(ctx, []string{start}, content, opts)
+}
+
+// expand expands the includes of content, read from the last file of chain,
+// which lists the files being expanded to detect cycles.
+func (c *client) expand(ctx context.Context, chain []string, content string, opts Options) (string, error) {
+	file := chain[len(chain)-1]
+	content, err := c.rewriteLinks(ctx, file, content)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(content, "\n")
+	var f fence
+	for i, line := range lines {
+		if f.next(line) {
+			continue
+		}
+		match := includeDirective.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if match[1] == "" {
+			return "", newError(ErrValidation, fmt.Errorf("'%s': include directive without a path", file))
+		}
+		partial := relativeTo(file, match[1], opts)
+		for _, including := range chain {
+			if including == partial {
+				return "", newError(ErrValidation, fmt.Errorf("include cycle: %s -> %s", strings.Join(chain, " -> "), partial))
+			}
+		}
+		data, err := c.readSource(ctx, partial, opts)
+		if err != nil {
+			return "", fmt.Errorf("failed to include '%s' in '%s': %w", match[1], file, err)
+		}
+		expanded, err := c.expand(ctx, append(chain[:len(chain):len(chain)], partial), string(data), opts)
+		if err != nil {
+			return "", err
+		}
+		lines[i] = strings.TrimRight(expanded, "\r\n")
+	}
+	return strings.Join(lines, "\n"), nil
+}
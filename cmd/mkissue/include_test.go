@@ -0,0 +1,104 @@
+package mkissue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExpandIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"partials/dod.md":    "## Definition of Done\n<!-- include: checks.md -->\n",
+		"partials/checks.md": "- [ ] Tested\n",
+		"partials/loop.md":   "<!--include: ../specs/loop.md-->",
+		"specs/loop.md":      "<!-- include: ../partials/loop.md -->",
+	})
+	c := newClient(testutil.Expect(t))
+	issueFile := filepath.Join(dir, "specs", "a.issue.md")
+
+	body := "Intro\n  <!-- include: ../partials/dod.md -->\n```md\n<!-- include: missing.md -->\n```\nOutro"
+	got, err := c.expandIncludes(context.Background(), issueFile, body, Options{})
+	if err != nil {
+		t.Fatalf("expandIncludes() error = %v", err)
+	}
+	want := "Intro\n## Definition of Done\n- [ ] Tested\n```md\n<!-- include: missing.md -->\n```\nOutro"
+	if got != want {
+		t.Errorf("expandIncludes() =\n%s\nwant\n%s", got, want)
+	}
+
+	_, err = c.expandIncludes(context.Background(), issueFile, "<!-- include: loop.md -->", Options{})
+	if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "include cycle") {
+		t.Errorf("expandIncludes() error = %v, want an include cycle", err)
+	}
+
+	_, err = c.expandIncludes(context.Background(), issueFile, "<!-- include: ../partials/none.md -->", Options{})
+	if !errors.Is(err, ErrSourceNotFound) || !strings.Contains(err.Error(), "failed to include '../partials/none.md'") {
+		t.Errorf("expandIncludes() error = %v, want a source not found error", err)
+	}
+
+	_, err = c.expandIncludes(context.Background(), issueFile, "<!-- include: -->", Options{})
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("expandIncludes() error = %v, want a validation error", err)
+	}
+}
+
+func TestExpandIncludesFromSource(t *testing.T) {
+	// Partials are read from the branch of the issue file
+	r := testutil.Expect(t,
+		runner.Interaction{Name: "git", Args: []string{"show", "feature:partials/dod.md"}, Stdout: "Done when <!-- include: x --> is inline\n<!-- include: ./agent.md -->\n"},
+		runner.Interaction{Name: "git", Args: []string{"show", "feature:partials/agent.md"}, Stdout: "Agent instructions\n"},
+	)
+	got, err := newClient(r).expandIncludes(context.Background(), "specs/a.issue.md", "<!-- include: ../partials/dod.md -->", Options{Branch: "feature"})
+	if err != nil {
+		t.Fatalf("expandIncludes() error = %v", err)
+	}
+	if want := "Done when <!-- include: x --> is inline\nAgent instructions"; got != want {
+		t.Errorf("expandIncludes() = %q, want %q", got, want)
+	}
+
+	// Gists have no directories
+	gist := "0123456789abcdef0123456789abcdef"
+	r = testutil.Expect(t, runner.Interaction{Name: "gh", Args: []string{"gist", "view", gist, "-f", "dod.md", "-r"}, Stdout: "Done"})
+	got, err = newClient(r).expandIncludes(context.Background(), "a.issue.md", "<!-- include: dod.md -->", Options{Gist: gist})
+	if err != nil || got != "Done" {
+		t.Errorf("expandIncludes() = %q, %v, want %q", got, err, "Done")
+	}
+}
+
+func TestCreateExpandsIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.issue.md": "---\ntitle: Partials\ncomments: [\"<!-- include: agent.md -->\"]\n---\nBody\n<!-- include: dod.md -->",
+		"dod.md":     "<!-- comment -->\nDone when tested",
+		"agent.md":   "Agent instructions",
+	})
+	url := "https://github.com/owner/repo/issues/4"
+	r := testutil.Expect(t,
+		runner.Interaction{Name: "gh", Args: []string{"issue", "create", "--title", "Partials", "--body-file", "-"}, Stdin: "Body", Stdout: url},
+		runner.Interaction{Name: "gh", Args: []string{"issue", "comment", url, "--body-file", "-"}, Stdin: "Done when tested"},
+		runner.Interaction{Name: "gh", Args: []string{"issue", "comment", url, "--body-file", "-"}, Stdin: "Agent instructions"},
+	)
+	if err := Create(context.Background(), filepath.Join(dir, "a.issue.md"), Options{Runner: r}); err != nil {
+		t.Errorf("Create() error = %v", err)
+	}
+}
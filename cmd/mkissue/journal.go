@@ -0,0 +1,104 @@
+package mkissue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// rollbackTimeout bounds the time spent undoing changes. Rollback runs even when
+// the run itself was cancelled or timed out.
+const rollbackTimeout = 2 * time.Minute
+
+// journalEntry is a change applied on GitHub together with the command that reverts it.
+type journalEntry struct {
+	Description string
+	Undo        runner.Command
+	// Issue is the URL of the issue the change created, if it created one.
+	Issue string
+}
+
+// journal is the transaction log of a run: every change made on GitHub
+// (labels, milestones, issues, comments) is recorded in the order it happened.
+type journal struct {
+	entries []journalEntry
+}
+
+// record adds a change to the journal.
+func (j *journal) record(description string, undo runner.Command) {
+	undo.Mutating = true
+	j.entries = append(j.entries, journalEntry{Description: description, Undo: undo})
+}
+
+// recordIssue adds the creation of an issue to the journal.
+func (j *journal) recordIssue(url string, undo runner.Command) {
+	j.record(fmt.Sprintf("created issue %s", url), undo)
+	j.entries[len(j.entries)-1].Issue = url
+}
+
+// issue returns the URL and description of the issue created in the journal,
+// or "" when there is none.
+func (j *journal) issue() (string, string) {
+	for _, e := range j.entries {
+		if e.Issue != "" {
+			return e.Issue, e.Description
+		}
+	}
+	return "", ""
+}
+
+// completed describes the recorded changes, in order.
+func (j *journal) completed() []string {
+	descriptions := make([]string, 0, len(j.entries))
+	for _, e := range j.entries {
+		descriptions = append(descriptions, e.Description)
+	}
+	return descriptions
+}
+
+// rollback undoes the recorded changes in reverse order. It keeps going when a
+// step fails and returns the changes that could not be undone.
+func (c *client) rollback(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+
+	var remaining []string
+	var errs []error
+	for i := len(c.journal.entries) - 1; i >= 0; i-- {
+		e := c.journal.entries[i]
+		fmt.Printf("Rolling back: %s\n", e.Description)
+		if _, err := c.run(ctx, e.Undo); err != nil {
+			remaining = append([]string{e.Description}, remaining...)
+			errs = append(errs, fmt.Errorf("failed to undo %s: %s", e.Description, runner.Stderr(err)))
+		}
+	}
+	c.journal.entries = nil
+	return remaining, errors.Join(errs...)
+}
+
+// fail handles an error after changes may have been applied on GitHub. Unless
+// keepPartial is set the changes are rolled back. A PartialError is returned
+// when changes remain on GitHub.
+func (c *client) fail(ctx context.Context, err error, keepPartial bool) error {
+	if len(c.journal.entries) == 0 {
+		return err
+	}
+	issue, created := c.journal.issue()
+	if keepPartial {
+		return &PartialError{Completed: c.journal.completed(), Issue: issue, Err: err}
+	}
+
+	remaining, rollbackErr := c.rollback(ctx)
+	if rollbackErr != nil {
+		partial := &PartialError{Completed: remaining, Err: fmt.Errorf("%w; rollback failed: %v", err, rollbackErr)}
+		if slices.Contains(remaining, created) {
+			partial.Issue = issue
+		}
+		return partial
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+package mkissue
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+func TestCreateRollsBack(t *testing.T) {
+	issueFile := filepath.Join("testdata", "labels.issue.md")
+	labelDelete := runner.Interaction{Name: "gh", Args: []string{"label", "delete", "needs-triage", "--yes"}}
+	failedDelete := labelDelete
+	failedDelete.Stderr = "HTTP 403: Must have admin rights to Repository."
+	failedDelete.ExitCode = 1
+
+	tests := []struct {
+		name          string
+		keepPartial   bool
+		undo          []runner.Interaction
+		wantPartial   bool
+		wantCompleted []string
+	}{
+		{
+			name:        "labels are deleted when the issue cannot be created",
+			undo:        []runner.Interaction{labelDelete},
+			wantPartial: false,
+		},
+		{
+			name:          "keep partial leaves labels in place",
+			keepPartial:   true,
+			wantPartial:   true,
+			wantCompleted: []string{"created label 'needs-triage'"},
+		},
+		{
+			name:          "failed rollback reports what is left",
+			undo:          []runner.Interaction{failedDelete},
+			wantPartial:   true,
+			wantCompleted: []string{"created label 'needs-triage'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := append(interactions(t, "labels-issue-fails"), tt.undo...)
+
+			err := Create(context.Background(), issueFile, Options{Runner: testutil.Expect(t, session...), KeepPartial: tt.keepPartial})
+			if err == nil {
+				t.Fatalf("Create() expected error")
+			}
+			if got := errors.Is(err, ErrPartial); got != tt.wantPartial {
+				t.Errorf("Create() partial = %v, want %v (error: %v)", got, tt.wantPartial, err)
+			}
+			var partialErr *PartialError
+			if errors.As(err, &partialErr) && !reflect.DeepEqual(partialErr.Completed, tt.wantCompleted) {
+				t.Errorf("Create() completed = %v, want %v", partialErr.Completed, tt.wantCompleted)
+			}
+		})
+	}
+}
+
+func TestRollbackOrderAndCancellation(t *testing.T) {
+	c := newClient(testutil.Expect(t,
+		runner.Interaction{Name: "gh", Args: []string{"issue", "delete", "https://github.com/owner/repo/issues/7", "--yes"}},
+		runner.Interaction{Name: "gh", Args: []string{"label", "delete", "second", "--yes"}},
+		runner.Interaction{Name: "gh", Args: []string{"label", "delete", "first", "--yes"}},
+	))
+	c.journal.record("created label 'first'", runner.Command{Name: "gh", Args: []string{"label", "delete", "first", "--yes"}})
+	c.journal.record("created label 'second'", runner.Command{Name: "gh", Args: []string{"label", "delete", "second", "--yes"}})
+	c.journal.record("created issue https://github.com/owner/repo/issues/7", runner.Command{
+		Name: "gh",
+		Args: []string{"issue", "delete", "https://github.com/owner/repo/issues/7", "--yes"},
+	})
+
+	// Rollback must still run when the run itself was cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := c.fail(ctx, context.Canceled, false)
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrPartial) {
+		t.Errorf("fail() error = %v, want context.Canceled without partial changes", err)
+	}
+	if len(c.journal.entries) != 0 {
+		t.Errorf("fail() left %d journal entries", len(c.journal.entries))
+	}
+}
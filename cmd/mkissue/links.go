@@ -0,0 +1,148 @@
+package mkissue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/lakruzz/gh-utils/internal/ghhost"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// Refs that rewritten links point at.
+const (
+	// LinkRefSHA pins links to the commit the issue file was read from.
+	LinkRefSHA = "sha"
+	// LinkRefBranch points links at the branch, so they follow later changes.
+	LinkRefBranch = "branch"
+)
+
+// linkRewriter rewrites the relative links of issue files read from a branch
+// or repository to absolute URLs on GitHub. The repository URL and ref are
+// looked up on first use, so files without relative links cost no extra calls.
+type linkRewriter struct {
+	opts Options
+	// base is the web URL of the repository, e.g. https://github.com/owner/repo.
+	base string
+	ref  string
+}
+
+// newLinkRewriter returns the link rewriter for the source in opts, or nil
+// when links are left as they are: for local files and gists, and with
+// opts.NoRewriteLinks.
+func newLinkRewriter(opts Options) (*linkRewriter, error) {
+	if opts.NoRewriteLinks || opts.Repo == "" && opts.Branch == "" {
+		return nil, nil
+	}
+	if opts.LinkRef != "" && opts.LinkRef != LinkRefSHA && opts.LinkRef != LinkRefBranch {
+		return nil, newError(ErrValidation, fmt.Errorf("link ref must be %s or %s, got '%s'", LinkRefBranch, LinkRefSHA, opts.LinkRef))
+	}
+	return &linkRewriter{opts: opts}, nil
+}
+
+// rewriteLinks rewrites the relative links of content, read from file, when
+// the client has a link rewriter.
+func (c *client) rewriteLinks(ctx context.Context, file, content string) (string, error) {
+	if c.links == nil {
+		return content, nil
+	}
+	var err error
+	rewritten := rewriteLinks(content, func(dest string, image bool) string {
+		if err == nil && c.links.base == "" {
+			err = c.resolveLinkBase(ctx)
+		}
+		if err != nil {
+			return dest
+		}
+		return c.links.url(file, dest, image)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to rewrite the links of '%s': %w", file, err)
+	}
+	return rewritten, nil
+}
+
+// resolveLinkBase looks up the web URL of the repository the issue file is
+// read from, and the branch or commit SHA to link to.
+func (c *client) resolveLinkBase(ctx context.Context) error {
+	l := c.links
+	if l.opts.Repo != "" {
+		r, err := ghhost.ParseRepo(l.opts.Repo)
+		if err != nil {
+			return newError(ErrValidation, err)
+		}
+		output, err := c.run(ctx, runner.Command{
+			Name: "gh",
+			Args: append(append([]string{"api"}, r.APIArgs()...), "repos/"+r.FullName(), "--jq", ".html_url, .default_branch"),
+		})
+		if err != nil {
+			return newError(commandKind(err), fmt.Errorf("failed to look up repository '%s': %s", l.opts.Repo, runner.Stderr(err)))
+		}
+		fields := strings.Fields(string(output))
+		if len(fields) != 2 {
+			return fmt.Errorf("unexpected repository '%s': %s", l.opts.Repo, output)
+		}
+		l.base, l.ref = fields[0], l.opts.Branch
+		if l.ref == "" {
+			l.ref = fields[1]
+		}
+		if l.opts.LinkRef == LinkRefBranch {
+			return nil
+		}
+		output, err = c.run(ctx, runner.Command{
+			Name: "gh",
+			Args: append(append([]string{"api"}, r.APIArgs()...), fmt.Sprintf("repos/%s/commits/%s", r.FullName(), l.ref), "--jq", ".sha"),
+		})
+		if err != nil {
+			return newError(commandKind(err), fmt.Errorf("failed to look up the commit of '%s': %s", l.ref, runner.Stderr(err)))
+		}
+		l.ref = strings.TrimSpace(string(output))
+		return nil
+	}
+
+	output, err := c.run(ctx, runner.Command{Name: "gh", Args: []string{"repo", "view", "--json", "url", "--jq", ".url"}})
+	if err != nil {
+		return newError(commandKind(err), fmt.Errorf("failed to look up the current repository: %s", runner.Stderr(err)))
+	}
+	l.base, l.ref = strings.TrimSpace(string(output)), l.opts.Branch
+	if l.base == "" {
+		return errors.New("failed to look up the current repository: no URL")
+	}
+	if l.opts.LinkRef == LinkRefBranch {
+		return nil
+	}
+	output, err = c.run(ctx, runner.Command{Name: "git", Args: []string{"rev-parse", "--verify", l.opts.Branch + "^{commit}"}})
+	if err != nil {
+		return newError(readKind(err), fmt.Errorf("failed to look up the commit of '%s': %s", l.opts.Branch, runner.Stderr(err)))
+	}
+	l.ref = strings.TrimSpace(string(output))
+	return nil
+}
+
+// url returns the absolute URL of dest, a link relative to file or, with a
+// leading slash, to the repository root. Images link to the raw file. Links
+// that leave the repository are returned as they are.
+func (l *linkRewriter) url(file, dest string, image bool) string {
+	target, suffix := dest, ""
+	if i := strings.IndexAny(dest, "?#"); i >= 0 {
+		target, suffix = dest[:i], dest[i:]
+	}
+	if strings.HasPrefix(target, "/") {
+		target = path.Clean(strings.TrimPrefix(target, "/"))
+	} else {
+		target = path.Join(path.Dir(file), target)
+	}
+	if target == ".." || strings.HasPrefix(target, "../") {
+		return dest
+	}
+	if target == "." {
+		return fmt.Sprintf("%s/tree/%s%s", l.base, l.ref, suffix)
+	}
+	kind := "blob"
+	if image {
+		kind = "raw"
+	}
+	return fmt.Sprintf("%s/%s/%s/%s%s", l.base, kind, l.ref, target, suffix)
+}
@@ -0,0 +1,99 @@
+package mkissue
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+func TestLinkURL(t *testing.T) {
+	l := &linkRewriter{base: "https://github.com/owner/repo", ref: "abc123"}
+	tests := []struct {
+		dest  string
+		image bool
+		want  string
+	}{
+		{"../docs/x.md", false, "https://github.com/owner/repo/blob/abc123/docs/x.md"},
+		{"img/a.png", true, "https://github.com/owner/repo/raw/abc123/specs/img/a.png"},
+		{"./x.md#usage", false, "https://github.com/owner/repo/blob/abc123/specs/x.md#usage"},
+		{"/README.md", false, "https://github.com/owner/repo/blob/abc123/README.md"},
+		{"..", false, "https://github.com/owner/repo/tree/abc123"},
+		{"../../outside.md", false, "../../outside.md"},
+	}
+	for _, tt := range tests {
+		if got := l.url("specs/a.issue.md", tt.dest, tt.image); got != tt.want {
+			t.Errorf("url(%q) = %q, want %q", tt.dest, got, tt.want)
+		}
+	}
+}
+
+func TestCreateRewritesLinks(t *testing.T) {
+	content := "---\ntitle: Links\n---\nSee [spec](../docs/x.md) and ![diagram](img/a.png)"
+	url := "https://github.com/owner/repo/issues/5"
+	create := func(body string) runner.Interaction {
+		return runner.Interaction{Name: "gh", Args: []string{"issue", "create", "--title", "Links", "--body-file", "-"}, Stdin: body, Stdout: url}
+	}
+	readRepo := runner.Interaction{
+		Name:   "gh",
+		Args:   []string{"api", "-X", "GET", "-H", "Accept: application/vnd.github.raw", "repos/owner/repo/contents/specs/a.issue.md"},
+		Stdout: content,
+	}
+	lookupRepo := runner.Interaction{
+		Name:   "gh",
+		Args:   []string{"api", "repos/owner/repo", "--jq", ".html_url, .default_branch"},
+		Stdout: "https://github.com/owner/repo\nmain\n",
+	}
+
+	t.Run("repo at sha", func(t *testing.T) {
+		r := testutil.Expect(t,
+			readRepo,
+			lookupRepo,
+			runner.Interaction{Name: "gh", Args: []string{"api", "repos/owner/repo/commits/main", "--jq", ".sha"}, Stdout: "abc123\n"},
+			create("See [spec](https://github.com/owner/repo/blob/abc123/docs/x.md) and ![diagram](https://github.com/owner/repo/raw/abc123/specs/img/a.png)"),
+		)
+		if err := Create(context.Background(), "specs/a.issue.md", Options{Repo: "owner/repo", Runner: r}); err != nil {
+			t.Errorf("Create() error = %v", err)
+		}
+	})
+
+	t.Run("branch", func(t *testing.T) {
+		r := testutil.Expect(t,
+			runner.Interaction{Name: "git", Args: []string{"show", "feature:specs/a.issue.md"}, Stdout: content},
+			runner.Interaction{Name: "gh", Args: []string{"repo", "view", "--json", "url", "--jq", ".url"}, Stdout: "https://ghe.example.com/owner/repo\n"},
+			create("See [spec](https://ghe.example.com/owner/repo/blob/feature/docs/x.md) and ![diagram](https://ghe.example.com/owner/repo/raw/feature/specs/img/a.png)"),
+		)
+		if err := Create(context.Background(), "specs/a.issue.md", Options{Branch: "feature", LinkRef: LinkRefBranch, Runner: r}); err != nil {
+			t.Errorf("Create() error = %v", err)
+		}
+	})
+
+	t.Run("branch at sha", func(t *testing.T) {
+		r := testutil.Expect(t,
+			runner.Interaction{Name: "git", Args: []string{"show", "feature:specs/a.issue.md"}, Stdout: content},
+			runner.Interaction{Name: "gh", Args: []string{"repo", "view", "--json", "url", "--jq", ".url"}, Stdout: "https://github.com/owner/repo\n"},
+			runner.Interaction{Name: "git", Args: []string{"rev-parse", "--verify", "feature^{commit}"}, Stdout: "def456\n"},
+			create("See [spec](https://github.com/owner/repo/blob/def456/docs/x.md) and ![diagram](https://github.com/owner/repo/raw/def456/specs/img/a.png)"),
+		)
+		if err := Create(context.Background(), "specs/a.issue.md", Options{Branch: "feature", Runner: r}); err != nil {
+			t.Errorf("Create() error = %v", err)
+		}
+	})
+
+	t.Run("no rewrite", func(t *testing.T) {
+		r := testutil.Expect(t, readRepo, create("See [spec](../docs/x.md) and ![diagram](img/a.png)"))
+		if err := Create(context.Background(), "specs/a.issue.md", Options{Repo: "owner/repo", NoRewriteLinks: true, Runner: r}); err != nil {
+			t.Errorf("Create() error = %v", err)
+		}
+	})
+
+	t.Run("invalid link ref", func(t *testing.T) {
+		r := testutil.Expect(t, readRepo)
+		err := Create(context.Background(), "specs/a.issue.md", Options{Repo: "owner/repo", LinkRef: "tag", Runner: r})
+		if !errors.Is(err, ErrValidation) {
+			t.Errorf("Create() error = %v, want a validation error", err)
+		}
+	})
+}
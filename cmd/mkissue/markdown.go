@@ -0,0 +1,410 @@
+package mkissue
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// fence tracks whether the lines of a markdown document are inside a fenced
+// code block, so that markers and directives in code examples are left alone.
+type fence struct {
+	// open is the opening fence, e.g. "```" or "~~~~", or "" outside a block.
+	open string
+}
+
+// next reports whether line is part of a code block, including its fences,
+// and updates the state for the following line.
+func (f *fence) next(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return f.open != ""
+	}
+	if f.open != "" {
+		if strings.HasPrefix(trimmed, f.open) && strings.Trim(trimmed, f.open[:1]) == "" {
+			f.open = ""
+		}
+		return true
+	}
+	for _, c := range []string{"`", "~"} {
+		n := len(trimmed) - len(strings.TrimLeft(trimmed, c))
+		if n >= 3 {
+			f.open = strings.Repeat(c, n)
+			return true
+		}
+	}
+	return false
+}
+
+// splitAt splits body at the lines that equal marker outside code blocks.
+// A body without the marker is returned as a single part.
+func splitAt(body, marker string) []string {
+	var parts []string
+	var current []string
+	var f fence
+	for _, line := range strings.Split(body, "\n") {
+		if !f.next(line) && strings.TrimSpace(line) == marker {
+			parts = append(parts, strings.Join(current, "\n"))
+			current = nil
+			continue
+		}
+		current = append(current, line)
+	}
+	return append(parts, strings.Join(current, "\n"))
+}
+
+var (
+	// referenceDefinition matches a link reference definition, capturing the
+	// text before its destination and the destination.
+	referenceDefinition = regexp.MustCompile(`^( {0,3}\[[^\]]+\]:[ \t]*)(<[^>]*>|\S+)`)
+	// htmlLink matches the src of an img or the href of an a tag, capturing the
+	// text before the value and the value.
+	htmlLink = regexp.MustCompile(`(?i)(<(img|a)\b[^>]*?\b(?:src|href)\s*=\s*["'])([^"']*)`)
+	// scheme matches the scheme of an absolute URL, e.g. "https:" or "mailto:".
+	scheme = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+)
+
+var imageExtensions = []string{".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".bmp", ".ico"}
+
+// isRelative reports whether a link destination is a path relative to the
+// document or to the repository root, rather than a URL or an anchor.
+func isRelative(dest string) bool {
+	return dest != "" && !strings.HasPrefix(dest, "#") && !strings.HasPrefix(dest, "//") && !scheme.MatchString(dest)
+}
+
+// rewriteLinks calls rewrite with the relative destinations of the links and
+// images of a markdown document, and replaces them with the results. Inline
+// links, reference definitions and the src and href of HTML img and a tags
+// are rewritten; code blocks and code spans are left alone. Reference
+// definitions count as images when they point at an image file.
+func rewriteLinks(content string, rewrite func(dest string, image bool) string) string {
+	lines := strings.Split(content, "\n")
+	var f fence
+	for i, line := range lines {
+		if f.next(line) {
+			continue
+		}
+		code := codeSpans(line)
+		var edits []edit
+		if m := referenceDefinition.FindStringSubmatchIndex(line); m != nil && !code[m[4]] {
+			start, end := m[4], m[5]
+			if line[start] == '<' {
+				start, end = start+1, end-1
+			}
+			dest := line[start:end]
+			ext := strings.ToLower(path.Ext(strings.SplitN(dest, "?", 2)[0]))
+			edits = append(edits, edit{start, end, slices.Contains(imageExtensions, ext)})
+		} else {
+			edits = inlineLinks(line, code)
+		}
+		for _, m := range htmlLink.FindAllStringSubmatchIndex(line, -1) {
+			if !code[m[0]] {
+				edits = append(edits, edit{m[6], m[7], strings.EqualFold(line[m[4]:m[5]], "img")})
+			}
+		}
+		sort.Slice(edits, func(a, b int) bool { return edits[a].start < edits[b].start })
+
+		var b strings.Builder
+		last := 0
+		for _, e := range edits {
+			dest := line[e.start:e.end]
+			if e.start < last || !isRelative(dest) {
+				continue
+			}
+			b.WriteString(line[last:e.start])
+			b.WriteString(rewrite(dest, e.image))
+			last = e.end
+		}
+		b.WriteString(line[last:])
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// edit is the position of a link destination within a line.
+type edit struct {
+	start, end int
+	image      bool
+}
+
+// inlineLinks returns the destinations of the inline links and images of line,
+// such as [text](dest "title") and ![alt](<dest>).
+func inlineLinks(line string, code []bool) []edit {
+	var edits []edit
+	for i := 0; i+1 < len(line); i++ {
+		if line[i] != ']' || line[i+1] != '(' || code[i] {
+			continue
+		}
+		// Find the opening bracket, skipping nested ones like [![alt](img)](link)
+		open, depth := -1, 0
+		for j := i; j >= 0; j-- {
+			if code[j] {
+				continue
+			}
+			if line[j] == ']' {
+				depth++
+			} else if line[j] == '[' {
+				depth--
+				if depth == 0 {
+					open = j
+					break
+				}
+			}
+		}
+		if open < 0 {
+			continue
+		}
+		image := open > 0 && line[open-1] == '!'
+
+		start := i + 2
+		for start < len(line) && line[start] == ' ' {
+			start++
+		}
+		end := start
+		if start < len(line) && line[start] == '<' {
+			close := strings.IndexByte(line[start:], '>')
+			if close < 0 {
+				continue
+			}
+			start, end = start+1, start+close
+		} else {
+			parens := 0
+			for end < len(line) && line[end] != ' ' && line[end] != '\t' {
+				if line[end] == '(' {
+					parens++
+				} else if line[end] == ')' {
+					if parens == 0 {
+						break
+					}
+					parens--
+				}
+				end++
+			}
+		}
+		edits = append(edits, edit{start, end, image})
+	}
+	return edits
+}
+
+// codeSpans reports for each byte of line whether it is part of a code span.
+func codeSpans(line string) []bool {
+	code := make([]bool, len(line)+1)
+	for i := 0; i < len(line); {
+		if line[i] != '`' {
+			i++
+			continue
+		}
+		n := len(line[i:]) - len(strings.TrimLeft(line[i:], "`"))
+		ticks := line[i : i+n]
+		close := -1
+		for j := i + n; j < len(line); {
+			k := strings.Index(line[j:], ticks)
+			if k < 0 {
+				break
+			}
+			k += j
+			if m := len(line[k:]) - len(strings.TrimLeft(line[k:], "`")); m == n {
+				close = k
+				break
+			} else {
+				j = k + m
+			}
+		}
+		if close < 0 {
+			i += n
+			continue
+		}
+		for j := i; j < close+n; j++ {
+			code[j] = true
+		}
+		i = close + n
+	}
+	return code
+}
+
+// headingLine matches an ATX heading such as "## Usage".
+var headingLine = regexp.MustCompile(`^ {0,3}#{1,6}(\s|$)`)
+
+// splitBody splits body into parts of at most limit characters. Parts end at
+// a heading or paragraph boundary where possible, preferring headings; a code
+// block that has to be cut is closed at the end of one part and reopened at
+// the start of the next, and a line longer than limit is cut into pieces.
+func splitBody(body string, limit int) []string {
+	if utf8.RuneCountInString(body) <= limit {
+		return []string{body}
+	}
+
+	var parts []string
+	var p part
+	flush := func(lines []string) {
+		if text := strings.Trim(strings.Join(lines, "\n"), "\n"); strings.TrimSpace(text) != "" {
+			parts = append(parts, text)
+		}
+	}
+
+	var f fence
+	opener := ""
+	blank := false
+	for _, line := range pieces(body, limit) {
+		open := f.open
+		if f.next(line) && open == "" {
+			opener = line
+		}
+		n := utf8.RuneCountInString(line) + 1
+		if open == "" && (blank || headingLine.MatchString(line)) {
+			p.mark(headingLine.MatchString(line))
+		}
+
+		for p.size+n > limit && len(p.lines) > 0 {
+			if b, ok := p.bestBoundary(limit); ok {
+				flush(p.lines[:b.index])
+				p = p.from(b)
+				continue
+			}
+			// No boundary fits: cut here, reopening a code block the line is in
+			if open != "" {
+				flush(append(p.lines, open))
+				p = part{}
+				p.add(opener)
+			} else {
+				flush(p.lines)
+				p = part{}
+			}
+			break
+		}
+
+		p.add(line)
+		blank = open == "" && strings.TrimSpace(line) == ""
+	}
+	flush(p.lines)
+	return parts
+}
+
+// part is a part of a body being split.
+type part struct {
+	lines []string
+	// size is the number of characters of the lines, with newlines.
+	size int
+	// boundaries are where the part may end, in order.
+	boundaries []boundary
+}
+
+// boundary is a heading or paragraph boundary before lines[index].
+type boundary struct {
+	index, size int
+	heading     bool
+}
+
+// mark records a boundary before the next line, which starts a section when
+// heading is set and a paragraph otherwise.
+func (p *part) mark(heading bool) {
+	if len(p.lines) > 0 {
+		p.boundaries = append(p.boundaries, boundary{index: len(p.lines), size: p.size, heading: heading})
+	}
+}
+
+// add appends line to the part.
+func (p *part) add(line string) {
+	p.lines = append(p.lines, line)
+	p.size += utf8.RuneCountInString(line) + 1
+}
+
+// bestBoundary returns the boundary to end the part at: the last heading that
+// keeps at least half of limit in the part, or else the last boundary.
+func (p *part) bestBoundary(limit int) (boundary, bool) {
+	for i := len(p.boundaries) - 1; i >= 0; i-- {
+		if b := p.boundaries[i]; b.heading && b.size >= limit/2 {
+			return b, true
+		}
+	}
+	if len(p.boundaries) == 0 {
+		return boundary{}, false
+	}
+	return p.boundaries[len(p.boundaries)-1], true
+}
+
+// from returns the rest of the part after boundary b.
+func (p *part) from(b boundary) part {
+	rest := part{lines: append([]string(nil), p.lines[b.index:]...), size: p.size - b.size}
+	for _, later := range p.boundaries {
+		if later.index > b.index {
+			rest.boundaries = append(rest.boundaries, boundary{index: later.index - b.index, size: later.size - b.size, heading: later.heading})
+		}
+	}
+	return rest
+}
+
+// pieces returns the lines of body, with lines longer than limit cut into
+// pieces of at most limit characters.
+func pieces(body string, limit int) []string {
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		for utf8.RuneCountInString(line) > limit {
+			runes := []rune(line)
+			lines = append(lines, string(runes[:limit]))
+			line = string(runes[limit:])
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// mentionPattern matches a user or team mention such as @octocat or
+// @github/docs, capturing the name without the @.
+var mentionPattern = regexp.MustCompile(`@([A-Za-z0-9](?:[A-Za-z0-9-]{0,38})(?:/[A-Za-z0-9._-]+)?)`)
+
+// mention is a mention of a user or team in a markdown document.
+type mention struct {
+	// Name is the login or org/team, without the @.
+	Name string
+	// Line is the 1-based line of the mention.
+	Line int
+	// start and end are the byte offsets of the mention, with the @, in its line.
+	start, end int
+}
+
+// findMentions returns the mentions of a markdown document, in order. Mentions
+// in code blocks and code spans don't notify anyone and are left out, and so
+// are email addresses and paths such as user@example.com and /@scope/name.
+func findMentions(content string) []mention {
+	var mentions []mention
+	var f fence
+	for i, line := range strings.Split(content, "\n") {
+		if f.next(line) {
+			continue
+		}
+		code := codeSpans(line)
+		for _, m := range mentionPattern.FindAllStringSubmatchIndex(line, -1) {
+			if code[m[0]] || m[0] > 0 && (isWordByte(line[m[0]-1]) || strings.IndexByte("`/", line[m[0]-1]) >= 0) {
+				continue
+			}
+			// A name continued by characters a login can't have is something else
+			if m[1] < len(line) && (isWordByte(line[m[1]]) || line[m[1]] == '@') {
+				continue
+			}
+			// A team name doesn't take the full stop ending a sentence
+			end := m[1] - (len(line[m[2]:m[1]]) - len(strings.TrimRight(line[m[2]:m[1]], ".")))
+			mentions = append(mentions, mention{Name: line[m[2]:end], Line: i + 1, start: m[0], end: end})
+		}
+	}
+	return mentions
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// replaceMentions returns content with the given mentions, found by
+// findMentions, replaced by the result of replace.
+func replaceMentions(content string, mentions []mention, replace func(string) string) string {
+	lines := strings.Split(content, "\n")
+	for i := len(mentions) - 1; i >= 0; i-- {
+		m := mentions[i]
+		line := lines[m.Line-1]
+		lines[m.Line-1] = line[:m.start] + replace(line[m.start:m.end]) + line[m.end:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,128 @@
+package mkissue
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRewriteLinks(t *testing.T) {
+	mark := func(dest string, image bool) string {
+		if image {
+			return "IMG(" + dest + ")"
+		}
+		return "URL(" + dest + ")"
+	}
+	tests := []struct {
+		name, content, want string
+	}{
+		{"link", "See [spec](../docs/x.md).", "See [spec](URL(../docs/x.md))."},
+		{"image", "![diagram](img/a.png)", "![diagram](IMG(img/a.png))"},
+		{"title", `[x](a.md "The title")`, `[x](URL(a.md) "The title")`},
+		{"angle brackets", "[x](<my file.md>)", "[x](<URL(my file.md)>)"},
+		{"parentheses", "[x](a_(b).md)", "[x](URL(a_(b).md))"},
+		{"anchor and query", "[x](a.md#usage) [y](b.md?plain=1)", "[x](URL(a.md#usage)) [y](URL(b.md?plain=1))"},
+		{"root relative", "[x](/docs/x.md)", "[x](URL(/docs/x.md))"},
+		{"badge", "[![build](img/b.svg)](ci.md)", "[![build](IMG(img/b.svg))](URL(ci.md))"},
+		{"several", "[a](a.md), [b](https://x.org) and [c](c.md)", "[a](URL(a.md)), [b](https://x.org) and [c](URL(c.md))"},
+		{"absolute", "[x](https://github.com) [m](mailto:a@b.c) [p](//cdn/x.png)", "[x](https://github.com) [m](mailto:a@b.c) [p](//cdn/x.png)"},
+		{"fragment only", "[x](#usage)", "[x](#usage)"},
+		{"code span", "`[x](a.md)` and ``[y](`b`.md)``", "`[x](a.md)` and ``[y](`b`.md)``"},
+		{"unclosed code span", "`[x](a.md)", "`[x](URL(a.md))"},
+		{"no link", "Call f(x) [not a link] (here)", "Call f(x) [not a link] (here)"},
+		{"reference", "[spec]: ../docs/x.md \"Spec\"", "[spec]: URL(../docs/x.md) \"Spec\""},
+		{"image reference", "   [logo]: <img/logo.PNG>", "   [logo]: <IMG(img/logo.PNG)>"},
+		{"html", `<img width="40" src="img/a.png"> <a href='x.md'>x</a>`, `<img width="40" src="IMG(img/a.png)"> <a href='URL(x.md)'>x</a>`},
+		{"html url", `<img src="https://x.org/a.png">`, `<img src="https://x.org/a.png">`},
+		{"code block", "```\n[x](a.md)\n```\n[y](b.md)", "```\n[x](a.md)\n```\n[y](URL(b.md))"},
+		{"indented code fence", "    ```\n[y](b.md)", "    ```\n[y](URL(b.md))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rewriteLinks(tt.content, mark); got != tt.want {
+				t.Errorf("rewriteLinks(%q) =\n%q\nwant\n%q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFence(t *testing.T) {
+	lines := []string{"a", "````md", "```", "still code", "````", "b", "~~~", "c", "~~~~", "d"}
+	want := []bool{false, true, true, true, true, false, true, true, true, false}
+	var f fence
+	for i, line := range lines {
+		if got := f.next(line); got != want[i] {
+			t.Errorf("line %d %q: in code = %v, want %v", i, line, got, want[i])
+		}
+	}
+}
+
+func TestFindMentions(t *testing.T) {
+	content := "Hi @octocat and @github/docs.\n" +
+		"Mail user@example.com, see /@scope/pkg, and `@code`\n" +
+		"```\n@fenced\n```\n" +
+		"Thanks @monalisa-2."
+	var got []string
+	for _, m := range findMentions(content) {
+		got = append(got, fmt.Sprintf("%d:%s", m.Line, m.Name))
+	}
+	want := []string{"1:octocat", "1:github/docs", "6:monalisa-2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findMentions() = %v, want %v", got, want)
+	}
+
+	escaped := replaceMentions(content, findMentions(content), func(m string) string { return "`" + m + "`" })
+	if !strings.HasPrefix(escaped, "Hi `@octocat` and `@github/docs`.\n") || !strings.HasSuffix(escaped, "Thanks `@monalisa-2`.") {
+		t.Errorf("replaceMentions() = %q", escaped)
+	}
+}
+
+func TestSplitBody(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		limit int
+		want  []string
+	}{
+		{name: "fits", body: "# Title\n\nText", limit: 20, want: []string{"# Title\n\nText"}},
+		{
+			name:  "paragraphs",
+			body:  "aaaa aaaa\n\nbbbb bbbb\n\ncccc cccc",
+			limit: 22,
+			want:  []string{"aaaa aaaa\n\nbbbb bbbb", "cccc cccc"},
+		},
+		{
+			name:  "prefers headings",
+			body:  "# One\naaaaaaaaaaaa\n\nbbbb\n# Two\ncccc\n\ndddd",
+			limit: 40,
+			want:  []string{"# One\naaaaaaaaaaaa\n\nbbbb", "# Two\ncccc\n\ndddd"},
+		},
+		{
+			name:  "keeps code blocks",
+			body:  "Intro\n\n```go\na\n\nb\n```\nOutro",
+			limit: 22,
+			want:  []string{"Intro", "```go\na\n\nb\n```\nOutro"},
+		},
+		{
+			name:  "cuts long code blocks",
+			body:  "```go\nline 1\nline 2\nline 3\nline 4\n```",
+			limit: 24,
+			want:  []string{"```go\nline 1\nline 2\n```", "```go\nline 3\nline 4\n```"},
+		},
+		{
+			name:  "cuts long lines",
+			body:  "abcdefghij",
+			limit: 4,
+			want:  []string{"abcd", "efgh", "ij"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitBody(tt.body, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
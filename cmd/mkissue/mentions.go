@@ -0,0 +1,110 @@
+package mkissue
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Mention policies: what happens to the @user and @org/team mentions of an
+// issue that aren't allowed, as they would notify people once published.
+const (
+	// MentionsAllow publishes every mention as it is.
+	MentionsAllow = "allow"
+	// MentionsWarn reports the mentions and publishes them as they are.
+	MentionsWarn = "warn"
+	// MentionsEscape escapes the mentions so they notify no one.
+	MentionsEscape = "escape"
+	// MentionsAllowlist fails the run on mentions that aren't allowed.
+	MentionsAllowlist = "allowlist"
+)
+
+// Ways of escaping a mention.
+const (
+	// EscapeBackticks wraps the mention in a code span: `@octocat`.
+	EscapeBackticks = "backticks"
+	// EscapeZWJ puts a zero-width joiner after the @, which looks unchanged.
+	EscapeZWJ = "zwj"
+)
+
+// MentionPolicy decides what happens to mentions in the body and comments of
+// an issue. The assignees of the issue may always be mentioned.
+type MentionPolicy struct {
+	// Policy is one of the Mentions constants; "" warns.
+	Policy string
+	// Allowlist holds the logins and org/team names that may be mentioned.
+	Allowlist []string
+	// Escape is EscapeBackticks, the default, or EscapeZWJ.
+	Escape string
+}
+
+// validate checks the policy and escape style.
+func (p MentionPolicy) validate() error {
+	switch p.Policy {
+	case "", MentionsAllow, MentionsWarn, MentionsEscape, MentionsAllowlist:
+	default:
+		return fmt.Errorf("mention policy must be allow, warn, escape or allowlist, got '%s'", p.Policy)
+	}
+	if p.Escape != "" && p.Escape != EscapeBackticks && p.Escape != EscapeZWJ {
+		return fmt.Errorf("mention escape must be backticks or zwj, got '%s'", p.Escape)
+	}
+	return nil
+}
+
+// escape returns mention, with its @, escaped so it notifies no one.
+func (p MentionPolicy) escape(mention string) string {
+	if p.Escape == EscapeZWJ {
+		return "@\u200d" + mention[1:]
+	}
+	return "`" + mention + "`"
+}
+
+// applyMentionPolicy applies the policy in opts to the mentions of the body and
+// comments that are neither assignees nor on the allowlist, and returns the
+// body, escaped where the policy asks.
+func applyMentionPolicy(opts Options, metadata *IssueMetadata, body string) (string, error) {
+	policy := opts.Mentions
+	if err := policy.validate(); err != nil {
+		return "", newError(ErrValidation, err)
+	}
+	if policy.Policy == MentionsAllow {
+		return body, nil
+	}
+	allowed := map[string]bool{}
+	for _, name := range append(append([]string(nil), metadata.Assignees...), policy.Allowlist...) {
+		allowed[strings.ToLower(strings.TrimPrefix(name, "@"))] = true
+	}
+
+	var report []string
+	check := func(source, text string) string {
+		var found []mention
+		for _, m := range findMentions(text) {
+			if !allowed[strings.ToLower(m.Name)] {
+				found = append(found, m)
+				report = append(report, fmt.Sprintf("  %s:%d: @%s", source, m.Line, m.Name))
+			}
+		}
+		if policy.Policy != MentionsEscape || len(found) == 0 {
+			return text
+		}
+		return replaceMentions(text, found, policy.escape)
+	}
+	body = check("body", body)
+	for i := range metadata.Comments {
+		metadata.Comments[i].Body = check(fmt.Sprintf("comment %d", i+1), metadata.Comments[i].Body)
+	}
+	if len(report) == 0 {
+		return body, nil
+	}
+
+	switch policy.Policy {
+	case MentionsEscape:
+		fmt.Fprintf(os.Stderr, "Escaped mentions:\n%s\n", strings.Join(report, "\n"))
+	case MentionsAllowlist:
+		return "", newError(ErrValidation, errors.New("mentions that aren't on the allowlist or assigned; remove or escape them, or add them to the allowlist:\n"+strings.Join(report, "\n")))
+	default:
+		fmt.Fprintf(os.Stderr, "Warning: these mentions will notify people:\n%s\n", strings.Join(report, "\n"))
+	}
+	return body, nil
+}
@@ -0,0 +1,64 @@
+package mkissue
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+func TestCreateAppliesMentionPolicy(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.issue.md")
+	content := "---\ntitle: Ping\nassign: [Octocat]\ncomments: [\"cc @acme/docs\"]\n---\nFor @octocat and @monalisa, not `@code`."
+	writeFiles(t, dir, map[string]string{"a.issue.md": content})
+	url := "https://github.com/owner/repo/issues/8"
+	create := func(body string) runner.Interaction {
+		return runner.Interaction{
+			Name:   "gh",
+			Args:   []string{"issue", "create", "--title", "Ping", "--body-file", "-", "--assignee", "Octocat"},
+			Stdin:  body,
+			Stdout: url,
+		}
+	}
+	comment := func(body string) runner.Interaction {
+		return runner.Interaction{Name: "gh", Args: []string{"issue", "comment", url, "--body-file", "-"}, Stdin: body}
+	}
+
+	tests := []struct {
+		name    string
+		policy  MentionPolicy
+		body    string
+		comment string
+	}{
+		{"warn", MentionPolicy{}, "For @octocat and @monalisa, not `@code`.", "cc @acme/docs"},
+		{"escape", MentionPolicy{Policy: MentionsEscape}, "For @octocat and `@monalisa`, not `@code`.", "cc `@acme/docs`"},
+		{"zwj", MentionPolicy{Policy: MentionsEscape, Escape: EscapeZWJ}, "For @octocat and @\u200dmonalisa, not `@code`.", "cc @\u200dacme/docs"},
+		{"allowlisted", MentionPolicy{Policy: MentionsAllowlist, Allowlist: []string{"MonaLisa", "@acme/docs"}}, "For @octocat and @monalisa, not `@code`.", "cc @acme/docs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := testutil.Expect(t, create(tt.body), comment(tt.comment))
+			if err := Create(context.Background(), file, Options{Runner: r, Mentions: tt.policy}); err != nil {
+				t.Errorf("Create() error = %v", err)
+			}
+		})
+	}
+
+	err := Create(context.Background(), file, Options{Runner: testutil.Expect(t), Mentions: MentionPolicy{Policy: MentionsAllowlist}})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("Create() error = %v, want a validation error", err)
+	}
+	for _, want := range []string{"body:1: @monalisa", "comment 1:1: @acme/docs"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Create() error = %v, want it to report %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "@octocat") {
+		t.Errorf("Create() error = %v, reports the assignee", err)
+	}
+}
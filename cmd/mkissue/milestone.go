@@ -0,0 +1,280 @@
+package mkissue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lakruzz/gh-utils/internal/config"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// Milestone is the milestone of an issue. A milestone given by title only must
+// already exist, which is checked before anything is created; one that also
+// sets a due date, description or state is created when it doesn't exist yet,
+// like a label with a color.
+type Milestone struct {
+	Title string `json:"title"`
+	// DueOn is the due date in YYYY-MM-DD format.
+	DueOn       string `json:"due_on,omitempty"`
+	Description string `json:"description,omitempty"`
+	// State is "open" or "closed"; "" is open.
+	State string `json:"state,omitempty"`
+	// Number identifies a milestone that exists on GitHub.
+	Number int `json:"-"`
+	// OpenIssues is the number of open issues of a milestone that exists on GitHub.
+	OpenIssues int `json:"-"`
+}
+
+// Milestone states.
+const (
+	MilestoneOpen   = "open"
+	MilestoneClosed = "closed"
+)
+
+// ensured reports whether the milestone is created when it doesn't exist.
+func (m *Milestone) ensured() bool {
+	return m.DueOn != "" || m.Description != "" || m.State != ""
+}
+
+// DecodeMilestone decodes a milestone from a parsed YAML value: a title, or a
+// mapping with title, due_on, description and state.
+func DecodeMilestone(v any) (*Milestone, error) {
+	if title, ok := v.(string); ok {
+		return &Milestone{Title: title}, nil
+	}
+	fields, err := config.Map(v)
+	if err != nil {
+		return nil, errors.New("expected a title or a mapping")
+	}
+	m := &Milestone{
+		Title:       config.String(fields["title"]),
+		DueOn:       config.String(fields["due_on"]),
+		Description: config.String(fields["description"]),
+		State:       strings.ToLower(config.String(fields["state"])),
+	}
+	for key := range fields {
+		switch key {
+		case "title", "due_on", "description", "state":
+		default:
+			return nil, fmt.Errorf("unknown key '%s'", key)
+		}
+	}
+	if m.Title == "" {
+		return nil, errors.New("'title' is required")
+	}
+	if m.DueOn != "" {
+		due, err := parseDueOn(m.DueOn)
+		if err != nil {
+			return nil, err
+		}
+		m.DueOn = due
+	}
+	if m.State != "" && m.State != MilestoneOpen && m.State != MilestoneClosed {
+		return nil, fmt.Errorf("state must be open or closed, got '%s'", m.State)
+	}
+	return m, nil
+}
+
+// parseDueOn normalizes a due date, given as YYYY-MM-DD or as an RFC 3339
+// timestamp, to YYYY-MM-DD.
+func parseDueOn(value string) (string, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t.Format(time.DateOnly), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC().Format(time.DateOnly), nil
+	}
+	return "", fmt.Errorf("due_on must be a date in YYYY-MM-DD format, got '%s'", value)
+}
+
+// parseMilestone parses the milestone field: a title, or a mapping such as
+//
+//	milestone: {title: v1.0, due_on: 2026-06-30}
+func parseMilestone(lines []string, startIdx int) (*Milestone, int, error) {
+	block, end := fieldBlock(lines, startIdx)
+	value := extractValue(strings.TrimSpace(lines[startIdx]), "milestone:")
+	if end == startIdx && !strings.HasPrefix(value, "{") {
+		if value == "" {
+			return nil, startIdx, nil
+		}
+		return &Milestone{Title: value}, startIdx, nil
+	}
+
+	tree, err := config.ParseYAML([]byte(block))
+	if err != nil {
+		return nil, startIdx, fmt.Errorf("milestone: %w", err)
+	}
+	root, _ := config.Map(tree)
+	m, err := DecodeMilestone(root["milestone"])
+	if err != nil {
+		return nil, startIdx, fmt.Errorf("milestone: %w", err)
+	}
+	return m, end, nil
+}
+
+// remoteMilestone is a milestone as returned by the REST API.
+type remoteMilestone struct {
+	Number      int    `json:"number"`
+	Title       string `json:"title"`
+	State       string `json:"state"`
+	Description string `json:"description"`
+	DueOn       string `json:"due_on"`
+	OpenIssues  int    `json:"open_issues"`
+}
+
+// listMilestones returns the open and closed milestones of the current repository.
+func (c *client) listMilestones(ctx context.Context) ([]Milestone, error) {
+	output, err := c.run(ctx, runner.Command{
+		Name: "gh",
+		Args: []string{"api", "--paginate", "repos/{owner}/{repo}/milestones?state=all&per_page=100"},
+	})
+	if err != nil {
+		return nil, newError(commandKind(err), fmt.Errorf("failed to list milestones: %s", runner.Stderr(err)))
+	}
+	// --paginate concatenates the JSON arrays of all pages
+	var milestones []Milestone
+	dec := json.NewDecoder(strings.NewReader(string(output)))
+	for dec.More() {
+		var page []remoteMilestone
+		if err := dec.Decode(&page); err != nil {
+			return nil, fmt.Errorf("failed to list milestones: %w", err)
+		}
+		for _, r := range page {
+			m := Milestone{Title: r.Title, Description: r.Description, State: r.State, Number: r.Number, OpenIssues: r.OpenIssues}
+			if r.DueOn != "" {
+				m.DueOn, _ = parseDueOn(r.DueOn)
+			}
+			milestones = append(milestones, m)
+		}
+	}
+	return milestones, nil
+}
+
+// FindMilestone returns the milestone with title, or nil.
+func FindMilestone(milestones []Milestone, title string) *Milestone {
+	for i := range milestones {
+		if milestones[i].Title == title {
+			return &milestones[i]
+		}
+	}
+	return nil
+}
+
+// ensureMilestone creates the milestone when it sets more than a title and
+// doesn't exist yet. The created milestone is recorded for rollback. A
+// milestone given by title only that doesn't exist fails validation, with the
+// closest titles as suggestions.
+func (c *client) ensureMilestone(ctx context.Context, m *Milestone) error {
+	if m == nil {
+		return nil
+	}
+	milestones, err := c.listMilestones(ctx)
+	if err != nil {
+		return err
+	}
+	if FindMilestone(milestones, m.Title) != nil {
+		return nil
+	}
+	if !m.ensured() {
+		titles := make([]string, len(milestones))
+		for i, existing := range milestones {
+			titles[i] = existing.Title
+		}
+		hint := suggest(m.Title, titles)
+		if closest := closestTitles(m.Title, titles, 3); hint == "" && len(closest) > 0 {
+			hint = "; the closest are " + strings.Join(closest, ", ")
+		}
+		return newError(ErrValidation, fmt.Errorf("milestone '%s' not found%s", m.Title, hint))
+	}
+	_, err = c.createMilestone(ctx, *m)
+	return err
+}
+
+// closestTitles returns at most n of titles, quoted, in order of their
+// distance to title.
+func closestTitles(title string, titles []string, n int) []string {
+	sorted := append([]string(nil), titles...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return distance(strings.ToLower(title), strings.ToLower(sorted[i])) < distance(strings.ToLower(title), strings.ToLower(sorted[j]))
+	})
+	var closest []string
+	for _, t := range sorted[:min(n, len(sorted))] {
+		closest = append(closest, "'"+t+"'")
+	}
+	return closest
+}
+
+// createMilestone creates m, records it for rollback and returns its number.
+func (c *client) createMilestone(ctx context.Context, m Milestone) (int, error) {
+	fmt.Printf("Creating milestone: %s\n", m.Title)
+	args := append([]string{"api", "--method", "POST", "repos/{owner}/{repo}/milestones"}, milestoneFields(m)...)
+	output, err := c.run(ctx, runner.Command{Name: "gh", Args: args, Mutating: true})
+	if err != nil {
+		return 0, newError(commandKind(err), fmt.Errorf("failed to create milestone '%s': %s", m.Title, runner.Stderr(err)))
+	}
+	var created remoteMilestone
+	if err := json.Unmarshal(output, &created); err != nil || created.Number == 0 {
+		return 0, fmt.Errorf("failed to create milestone '%s': unexpected response", m.Title)
+	}
+	c.journal.record(fmt.Sprintf("created milestone '%s'", m.Title), runner.Command{
+		Name: "gh",
+		Args: []string{"api", "--method", "DELETE", fmt.Sprintf("repos/{owner}/{repo}/milestones/%d", created.Number)},
+	})
+	return created.Number, nil
+}
+
+// milestoneFields returns the gh api fields that set the title, due date,
+// description and state of m.
+func milestoneFields(m Milestone) []string {
+	args := []string{"-f", "title=" + m.Title}
+	if m.DueOn != "" {
+		args = append(args, "-f", "due_on="+m.DueOn+"T00:00:00Z")
+	}
+	if m.Description != "" {
+		args = append(args, "-f", "description="+m.Description)
+	}
+	if m.State != "" {
+		args = append(args, "-f", "state="+m.State)
+	}
+	return args
+}
+
+// fieldBlock returns the text of the top-level field at startIdx, including
+// the indented or list lines that follow it, and the index of its last line.
+func fieldBlock(lines []string, startIdx int) (string, int) {
+	end := startIdx + 1
+	for end < len(lines) && (strings.TrimSpace(lines[end]) == "" || strings.HasPrefix(lines[end], " ") ||
+		strings.HasPrefix(lines[end], "\t") || strings.HasPrefix(lines[end], "-")) {
+		end++
+	}
+	// Trailing blank lines belong to no field
+	for end-1 > startIdx && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return strings.Join(lines[startIdx:end], "\n"), end - 1
+}
+
+// updateMilestone changes the milestone from to the values of to, and records
+// the previous values for rollback. Empty fields of to are left unchanged.
+func (c *client) updateMilestone(ctx context.Context, from, to Milestone) error {
+	endpoint := fmt.Sprintf("repos/{owner}/{repo}/milestones/%d", from.Number)
+	args := append([]string{"api", "--method", "PATCH", endpoint}, milestoneFields(to)...)
+	if _, err := c.run(ctx, runner.Command{Name: "gh", Args: args, Mutating: true}); err != nil {
+		return newError(commandKind(err), fmt.Errorf("failed to update milestone '%s': %s", from.Title, runner.Stderr(err)))
+	}
+
+	undo := append([]string{"api", "--method", "PATCH", endpoint}, milestoneFields(from)...)
+	if from.DueOn == "" && to.DueOn != "" {
+		undo = append(undo, "-F", "due_on=null")
+	}
+	if from.Description == "" && to.Description != "" {
+		undo = append(undo, "-f", "description=")
+	}
+	c.journal.record(fmt.Sprintf("updated milestone '%s'", from.Title), runner.Command{Name: "gh", Args: undo})
+	return nil
+}
@@ -0,0 +1,161 @@
+package mkissue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+func TestParseMilestone(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *Milestone
+		wantErr string
+	}{
+		{name: "title", content: `milestone: "v1.0" # next release`, want: &Milestone{Title: "v1.0"}},
+		{name: "empty", content: "milestone:", want: nil},
+		{
+			name:    "inline mapping",
+			content: "milestone: {title: v1.0, due_on: 2026-06-30, state: Open}",
+			want:    &Milestone{Title: "v1.0", DueOn: "2026-06-30", State: "open"},
+		},
+		{
+			name: "block mapping",
+			content: `milestone:
+  title: "v1.0"
+  due_on: 2026-06-30T12:00:00Z
+  description: |
+    First release
+labels: [bug]`,
+			want: &Milestone{Title: "v1.0", DueOn: "2026-06-30", Description: "First release\n"},
+		},
+		{name: "missing title", content: "milestone: {due_on: 2026-06-30}", wantErr: "'title' is required"},
+		{name: "invalid date", content: "milestone: {title: v1, due_on: June}", wantErr: "due_on must be a date"},
+		{name: "invalid state", content: "milestone: {title: v1, state: done}", wantErr: "state must be open or closed"},
+		{name: "unknown key", content: "milestone: {title: v1, due: 2026-06-30}", wantErr: "unknown key 'due'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata, _, err := parseIssueFile("---\ntitle: T\n" + tt.content + "\n---\nBody")
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseIssueFile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseIssueFile() error = %v", err)
+			}
+			if !reflect.DeepEqual(metadata.Milestone, tt.want) {
+				t.Errorf("Milestone = %+v, want %+v", metadata.Milestone, tt.want)
+			}
+		})
+	}
+}
+
+func listMilestones(stdout string) runner.Interaction {
+	return runner.Interaction{
+		Name:   "gh",
+		Args:   []string{"api", "--paginate", "repos/{owner}/{repo}/milestones?state=all&per_page=100"},
+		Stdout: stdout,
+	}
+}
+
+func TestListMilestones(t *testing.T) {
+	c := newClient(testutil.Expect(t, listMilestones(
+		`[{"number":1,"title":"v1.0","state":"closed","due_on":"2026-05-01T07:00:00Z","open_issues":0}]`+
+			`[{"number":2,"title":"v1.1","state":"open","description":"Next","due_on":null,"open_issues":3}]`)))
+	got, err := c.listMilestones(context.Background())
+	if err != nil {
+		t.Fatalf("listMilestones() error = %v", err)
+	}
+	want := []Milestone{
+		{Title: "v1.0", State: "closed", DueOn: "2026-05-01", Number: 1},
+		{Title: "v1.1", State: "open", Description: "Next", Number: 2, OpenIssues: 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("listMilestones() = %+v, want %+v", got, want)
+	}
+}
+
+func TestEnsureMilestone(t *testing.T) {
+	existing := listMilestones(`[{"number":1,"title":"v1.0","state":"open"}]`)
+
+	// Title-only milestones must exist
+	if err := newClient(testutil.Expect(t, existing)).ensureMilestone(context.Background(), &Milestone{Title: "v1.0"}); err != nil {
+		t.Errorf("ensureMilestone() error = %v", err)
+	}
+	err := newClient(testutil.Expect(t, existing)).ensureMilestone(context.Background(), &Milestone{Title: "v1.O"})
+	if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "did you mean 'v1.0'?") {
+		t.Errorf("ensureMilestone() error = %v, want validation error with a suggestion", err)
+	}
+	if err := newClient(testutil.Expect(t, existing)).ensureMilestone(context.Background(), &Milestone{Title: "v1.0", DueOn: "2026-06-30"}); err != nil {
+		t.Errorf("ensureMilestone() error = %v", err)
+	}
+
+	c := newClient(testutil.Expect(t, existing, runner.Interaction{
+		Name: "gh",
+		Args: []string{"api", "--method", "POST", "repos/{owner}/{repo}/milestones",
+			"-f", "title=v2.0", "-f", "due_on=2026-06-30T00:00:00Z", "-f", "description=Second"},
+		Stdout: `{"number":7,"title":"v2.0"}`,
+	}))
+	if err := c.ensureMilestone(context.Background(), &Milestone{Title: "v2.0", DueOn: "2026-06-30", Description: "Second"}); err != nil {
+		t.Fatalf("ensureMilestone() error = %v", err)
+	}
+	want := []journalEntry{{
+		Description: "created milestone 'v2.0'",
+		Undo:        runner.Command{Name: "gh", Args: []string{"api", "--method", "DELETE", "repos/{owner}/{repo}/milestones/7"}, Mutating: true},
+	}}
+	if !reflect.DeepEqual(c.journal.entries, want) {
+		t.Errorf("journal = %+v, want %+v", c.journal.entries, want)
+	}
+}
+
+func TestCreateChecksMilestoneFirst(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.issue.md")
+	content := "---\ntitle: Ship it\nlabels: [{name: needs-triage, color: fbca04}]\nmilestone: v2\n---\nBody"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing is created for a milestone that doesn't exist
+	r := testutil.Expect(t, listMilestones(`[{"number":1,"title":"v1.0","state":"open"},{"number":2,"title":"v2.0","state":"open"}]`))
+	err := Create(context.Background(), file, Options{Runner: r})
+	if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "milestone 'v2' not found; the closest are 'v2.0', 'v1.0'") {
+		t.Errorf("Create() error = %v, want validation error", err)
+	}
+}
+
+func TestCreateRollsBackMilestone(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.issue.md")
+	content := "---\ntitle: Ship it\nmilestone: {title: v2.0, due_on: 2026-06-30}\n---\nBody"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := testutil.Expect(t,
+		listMilestones(`[]`),
+		runner.Interaction{
+			Name:   "gh",
+			Args:   []string{"api", "--method", "POST", "repos/{owner}/{repo}/milestones", "-f", "title=v2.0", "-f", "due_on=2026-06-30T00:00:00Z"},
+			Stdout: `{"number":7}`,
+		},
+		runner.Interaction{
+			Name:     "gh",
+			Args:     []string{"issue", "create", "--title", "Ship it", "--body-file", "-", "--milestone", "v2.0"},
+			Stdin:    "Body",
+			Stderr:   "HTTP 403: Resource not accessible by integration",
+			ExitCode: 1,
+		},
+		runner.Interaction{Name: "gh", Args: []string{"api", "--method", "DELETE", "repos/{owner}/{repo}/milestones/7"}},
+	)
+	if err := Create(context.Background(), file, Options{Runner: r}); err == nil {
+		t.Error("Create() expected an error")
+	}
+}
@@ -1,30 +1,115 @@
 package mkissue
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
+
+	"github.com/lakruzz/gh-utils/internal/config"
+	"github.com/lakruzz/gh-utils/internal/ghhost"
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/schedule"
+	"github.com/lakruzz/gh-utils/internal/secrets"
 )
 
 type IssueMetadata struct {
-	Title     string
-	Assignees []string
-	Labels    []Label
-	Milestone string
-	Projects  []string
+	Title     string     `json:"title"`
+	Assignees []string   `json:"assignees,omitempty"`
+	Labels    []Label    `json:"labels,omitempty"`
+	Milestone *Milestone `json:"milestone,omitempty"`
+	Projects  []Project  `json:"projects,omitempty"`
+	// Continued holds the rest of a body too long for one issue, posted as
+	// numbered comments before Comments.
+	Continued []string `json:"continued,omitempty"`
+	// Comments are posted after the issue is created, in order.
+	Comments []Comment `json:"comments,omitempty"`
+	// State "closed" closes the issue after it is created, with StateReason.
+	State       string `json:"state,omitempty"`
+	StateReason string `json:"state_reason,omitempty"`
+	Lock        *Lock  `json:"lock,omitempty"`
+	Pin         bool   `json:"pin,omitempty"`
+	// Schedule is when the issue recurs, see utils recur. The title is
+	// rendered before an issue is queued, so it isn't stored.
+	Schedule *schedule.Schedule `json:"-"`
 }
 
 type Label struct {
-	Name  string
-	Color string
-	Desc  string
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+	Desc  string `json:"desc,omitempty"`
+}
+
+// Options control where an issue file is read from and how external commands are run.
+type Options struct {
+	// Branch is the git branch to read the file from (or the ref within Repo).
+	Branch string
+	// Gist is the ID of the gist to read the file from.
+	Gist string
+	// Repo is the GitHub repository (owner/repo) to read the file from.
+	Repo string
+	// Runner executes every gh and git invocation. Nil uses runner.Default(),
+	// which retries transient GitHub failures.
+	Runner runner.Runner
+	// KeepPartial leaves the changes made on GitHub in place when a run fails,
+	// instead of rolling them back.
+	KeepPartial bool
+	// Queue stores the issue in the outbox instead of creating it; see Outbox.
+	Queue bool
+	// QueueOffline stores the issue in the outbox when GitHub can't be reached.
+	QueueOffline bool
+	// Host is the host gh runs against, from --hostname or the configuration;
+	// a queued issue is created there when its repository names no host.
+	Host string
+	// LinkRef is what the relative links of a file read from Branch or Repo
+	// point at once rewritten: LinkRefSHA (the default) or LinkRefBranch.
+	LinkRef string
+	// NoRewriteLinks leaves relative links as they are.
+	NoRewriteLinks bool
+	// StrictSize fails a run whose body is over MaxBodySize, instead of
+	// continuing the body in comments.
+	StrictSize bool
+	// Secrets scans the issue for secrets before it is published; nil uses
+	// the built-in rules only.
+	Secrets *secrets.Scanner
+	// AllowSecrets publishes an issue with possible secrets, after reporting them.
+	AllowSecrets bool
+	// Mentions decides what happens to mentions that would notify people.
+	Mentions MentionPolicy
+	// Occurrence is the scheduled time the issue is created for, which the
+	// placeholders of the title are rendered with. Zero uses the latest
+	// occurrence of the schedule of the file, or the current time.
+	Occurrence time.Time
+}
+
+// client runs the gh and git commands needed to create an issue, and keeps a
+// journal of the changes it made on GitHub.
+type client struct {
+	runner  runner.Runner
+	journal journal
+	// links rewrites relative links; nil leaves them as they are.
+	links *linkRewriter
+	// created is called with the URL of the issue as soon as it's created;
+	// nil does nothing.
+	created func(url string) error
+}
+
+func newClient(r runner.Runner) *client {
+	if r == nil {
+		r = runner.Default()
+	}
+	return &client{runner: r}
 }
 
-var repoNamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+/[a-zA-Z0-9._-]+$`)
+// run runs an external command and returns its stdout.
+func (c *client) run(ctx context.Context, cmd runner.Command) ([]byte, error) {
+	res, err := c.runner.Run(ctx, cmd)
+	return res.Stdout, err
+}
 
 func Run(args []string) {
 	if len(args) < 1 {
@@ -33,7 +118,7 @@ func Run(args []string) {
 	}
 
 	issueFile := args[0]
-	if err := RunWithFile(issueFile, "", "", ""); err != nil {
+	if err := RunWithFile(context.Background(), issueFile, "", "", ""); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -44,31 +129,18 @@ func Run(args []string) {
 // If branch is provided, the file will be read from that git branch.
 // If gist is provided, the file will be read from that gist.
 // If repo is provided (owner/repo format), the file will be read from that GitHub repository.
-func RunWithFile(issueFile, branch, gist, repo string) error {
-	var content []byte
-	var err error
+// Cancelling ctx kills any running gh or git process and aborts the run.
+func RunWithFile(ctx context.Context, issueFile, branch, gist, repo string) error {
+	return Create(ctx, issueFile, Options{Branch: branch, Gist: gist, Repo: repo})
+}
 
-	// Read the file from the specified source
-	if gist != "" {
-		content, err = readFileFromGist(issueFile, gist)
-		if err != nil {
-			return fmt.Errorf("failed to read file from gist '%s': %w", gist, err)
-		}
-	} else if repo != "" {
-		content, err = readFileFromRepo(issueFile, repo, branch)
-		if err != nil {
-			return fmt.Errorf("failed to read file from repo '%s': %w", repo, err)
-		}
-	} else if branch != "" {
-		content, err = readFileFromBranch(issueFile, branch)
-		if err != nil {
-			return fmt.Errorf("failed to read file from branch '%s': %w", branch, err)
-		}
-	} else {
-		content, err = os.ReadFile(issueFile)
-		if err != nil {
-			return fmt.Errorf("file '%s' not found: %w", issueFile, err)
-		}
+// Create reads an issue file from the source described by opts and creates the issue.
+func Create(ctx context.Context, issueFile string, opts Options) error {
+	c := newClient(opts.Runner)
+
+	content, err := c.readSource(ctx, issueFile, opts)
+	if err != nil {
+		return err
 	}
 
 	// Parse the file
@@ -79,101 +151,210 @@ func RunWithFile(issueFile, branch, gist, repo string) error {
 
 	// Validate required fields
 	if metadata.Title == "" {
-		return fmt.Errorf("'title' is required in frontmatter")
+		return newError(ErrValidation, errors.New("'title' is required in frontmatter"))
+	}
+	if err := renderTitle(metadata, opts); err != nil {
+		return err
 	}
 
-	// Create or verify labels
-	for _, label := range metadata.Labels {
-		if label.Color != "" || label.Desc != "" {
-			if err := ensureLabelExists(label); err != nil {
-				return fmt.Errorf("error creating label: %w", err)
-			}
+	c.links, err = newLinkRewriter(opts)
+	if err != nil {
+		return err
+	}
+	// Expand the includes before splitting, so partials can hold comments too
+	body, err = c.expandIncludes(ctx, issueFile, body, opts)
+	if err != nil {
+		return err
+	}
+	// Sections after a comment marker are posted before the comments field
+	expanded := body
+	body, sections := splitComments(expanded)
+	metadata.Comments = append(sections, metadata.Comments...)
+	if err := c.resolveComments(ctx, issueFile, metadata.Comments, opts); err != nil {
+		return err
+	}
+	if err := checkSecrets(opts, metadata.Title, expanded, metadata.Comments[len(sections):]); err != nil {
+		return err
+	}
+	body, err = applyMentionPolicy(opts, metadata, body)
+	if err != nil {
+		return err
+	}
+	body, err = fitBody(metadata, body, opts.StrictSize)
+	if err != nil {
+		return err
+	}
+
+	// Resolve the request up front when it may be queued, so an issue created
+	// just before the network failed carries the marker a flush looks for
+	var request *Request
+	if opts.Queue || opts.QueueOffline {
+		request = newRequest(issueFile, metadata, body)
+		if request.Repo, err = c.queueTarget(ctx, opts.Host); err != nil {
+			return err
 		}
+		body = request.Body
+	}
+	if opts.Queue {
+		return queue(request)
 	}
 
-	// Create the issue
-	if err := createIssue(metadata, body); err != nil {
-		return fmt.Errorf("error creating issue: %w", err)
+	if _, err := c.publish(ctx, metadata, body); err != nil {
+		return c.fallback(ctx, err, request, opts)
 	}
 
 	fmt.Println("Issue created successfully!")
 	return nil
 }
 
+// publish creates the milestone, labels and the issue, sets its project
+// fields, which are resolved first so that a typo fails before anything is
+// created, and posts its comments and changes its state.
+func (c *client) publish(ctx context.Context, metadata *IssueMetadata, body string) (string, error) {
+	fields, err := c.resolveProjectFields(ctx, metadata.Projects)
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.ensureMilestone(ctx, metadata.Milestone); err != nil {
+		return "", err
+	}
+
+	// Create or verify labels
+	if err := c.ensureLabels(ctx, metadata.Labels); err != nil {
+		return "", err
+	}
+
+	// Create the issue
+	url, err := c.createIssue(ctx, metadata, body)
+	if err != nil {
+		return "", fmt.Errorf("error creating issue: %w", err)
+	}
+	if err := c.setProjectFields(ctx, url, fields); err != nil {
+		return url, err
+	}
+	if err := c.followUp(ctx, url, metadata); err != nil {
+		return url, err
+	}
+	return url, nil
+}
+
+// fallback queues the request when GitHub can't be reached and a request was
+// resolved for queueing; otherwise, or when queueing fails, the run fails.
+// Changes already made on GitHub are kept for the queued issue.
+func (c *client) fallback(ctx context.Context, err error, request *Request, opts Options) error {
+	if request != nil && errors.Is(err, ErrNetwork) {
+		fmt.Fprintf(os.Stderr, "GitHub can't be reached: %v\n", err)
+		queueErr := queue(request)
+		if queueErr == nil {
+			return nil
+		}
+		err = errors.Join(err, queueErr)
+	}
+	return c.fail(ctx, err, opts.KeepPartial)
+}
+
+// readSource reads the issue file from the gist, repository, branch or local
+// path given in opts, in that order of precedence.
+func (c *client) readSource(ctx context.Context, issueFile string, opts Options) ([]byte, error) {
+	switch {
+	case opts.Gist != "":
+		content, err := c.readFileFromGist(ctx, issueFile, opts.Gist)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file from gist '%s': %w", opts.Gist, err)
+		}
+		return content, nil
+	case opts.Repo != "":
+		content, err := c.readFileFromRepo(ctx, issueFile, opts.Repo, opts.Branch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file from repo '%s': %w", opts.Repo, err)
+		}
+		return content, nil
+	case opts.Branch != "":
+		content, err := c.readFileFromBranch(ctx, issueFile, opts.Branch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file from branch '%s': %w", opts.Branch, err)
+		}
+		return content, nil
+	default:
+		content, err := os.ReadFile(issueFile)
+		if err != nil {
+			return nil, newError(ErrSourceNotFound, fmt.Errorf("file '%s' not found: %w", issueFile, err))
+		}
+		return content, nil
+	}
+}
+
 // readFileFromBranch reads a file from a specific git branch without checking it out.
 // It uses `git show <branch>:<file>` to retrieve the file content.
-func readFileFromBranch(filePath, branch string) ([]byte, error) {
+func (c *client) readFileFromBranch(ctx context.Context, filePath, branch string) ([]byte, error) {
 	// Basic validation: ensure branch name doesn't contain null bytes or newlines
 	// which could cause issues with git commands
 	if strings.ContainsAny(branch, "\x00\n\r") {
-		return nil, fmt.Errorf("invalid branch name: contains prohibited characters")
+		return nil, newError(ErrValidation, errors.New("invalid branch name: contains prohibited characters"))
 	}
 	if strings.ContainsAny(filePath, "\x00\n\r") {
-		return nil, fmt.Errorf("invalid file path: contains prohibited characters")
+		return nil, newError(ErrValidation, errors.New("invalid file path: contains prohibited characters"))
 	}
 
 	// Use git show to read the file from the specified branch
-	// Note: exec.Command passes arguments separately, not through shell, preventing injection
-	cmd := exec.Command("git", "show", fmt.Sprintf("%s:%s", branch, filePath))
-	output, err := cmd.Output()
+	output, err := c.run(ctx, runner.Command{
+		Name: "git",
+		Args: []string{"show", fmt.Sprintf("%s:%s", branch, filePath)},
+	})
 	if err != nil {
-		// Check if it's an exit error and provide more context
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("failed to read file from branch: %s", string(exitErr.Stderr))
-		}
-		return nil, fmt.Errorf("failed to read file from branch: %w", err)
+		return nil, newError(readKind(err), fmt.Errorf("failed to read file from branch: %s", runner.Stderr(err)))
 	}
 	return output, nil
 }
 
 // readFileFromGist reads a file from a GitHub gist using the gh CLI.
 // It uses `gh gist view <gist-id> -f <filename> -r` to retrieve the file content.
-func readFileFromGist(fileName, gistID string) ([]byte, error) {
+func (c *client) readFileFromGist(ctx context.Context, fileName, gistID string) ([]byte, error) {
 	// Validate gist ID - GitHub gist IDs are hexadecimal strings (32 characters)
 	// Using a positive pattern for security and maintainability
 	gistIDPattern := regexp.MustCompile(`^[a-f0-9]{32}$`)
 	if !gistIDPattern.MatchString(gistID) {
-		return nil, fmt.Errorf("invalid gist ID: must be a 32-character hexadecimal string")
+		return nil, newError(ErrValidation, errors.New("invalid gist ID: must be a 32-character hexadecimal string"))
 	}
 
 	// Validate file name - allow alphanumeric, dots, hyphens, underscores
 	// GitHub gists use flat file structure (no subdirectories), so reject path separators
 	fileNamePattern := regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
 	if !fileNamePattern.MatchString(fileName) {
-		return nil, fmt.Errorf("invalid file name: only alphanumeric characters, dots, hyphens, and underscores are allowed")
+		return nil, newError(ErrValidation, errors.New("invalid file name: only alphanumeric characters, dots, hyphens, and underscores are allowed"))
 	}
 
 	// Use gh gist view to read the file from the specified gist
-	// Note: exec.Command passes arguments separately, not through shell, preventing injection
-	cmd := exec.Command("gh", "gist", "view", gistID, "-f", fileName, "-r")
-	output, err := cmd.Output()
+	output, err := c.run(ctx, runner.Command{
+		Name: "gh",
+		Args: []string{"gist", "view", gistID, "-f", fileName, "-r"},
+	})
 	if err != nil {
-		// Check if it's an exit error and provide more context
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("failed to read file from gist: %s", string(exitErr.Stderr))
-		}
-		return nil, fmt.Errorf("failed to read file from gist: %w", err)
+		return nil, newError(readKind(err), fmt.Errorf("failed to read file from gist: %s", runner.Stderr(err)))
 	}
 	return output, nil
 }
 
 // readFileFromRepo reads a file from a GitHub repository using the gh CLI.
 // It uses `gh api repos/{owner}/{repo}/contents/{path}` with optional ref to retrieve the file content.
-// If branch is empty, the repository's default branch is used.
-func readFileFromRepo(filePath, repo, branch string) ([]byte, error) {
-	// Validate repo format: must be "owner/repo"
-	if !repoNamePattern.MatchString(repo) {
-		return nil, fmt.Errorf("invalid repository format: must be 'owner/repo'")
+// If branch is empty, the repository's default branch is used. A repository
+// in host/owner/repo format is read from that host.
+func (c *client) readFileFromRepo(ctx context.Context, filePath, repo, branch string) ([]byte, error) {
+	// Validate repo format: must be "owner/repo" or "host/owner/repo"
+	r, err := ghhost.ParseRepo(repo)
+	if err != nil {
+		return nil, newError(ErrValidation, err)
 	}
 
 	// Validate file path
 	if strings.ContainsAny(filePath, "\x00\n\r") {
-		return nil, fmt.Errorf("invalid file path: contains prohibited characters")
+		return nil, newError(ErrValidation, errors.New("invalid file path: contains prohibited characters"))
 	}
 
 	// Validate branch name if provided
 	if branch != "" && strings.ContainsAny(branch, "\x00\n\r") {
-		return nil, fmt.Errorf("invalid branch name: contains prohibited characters")
+		return nil, newError(ErrValidation, errors.New("invalid branch name: contains prohibited characters"))
 	}
 
 	// Normalize file path to remove leading ./ and other path inconsistencies
@@ -184,36 +365,29 @@ func readFileFromRepo(filePath, repo, branch string) ([]byte, error) {
 	}
 
 	// Build the gh api command to fetch raw file content
-	endpoint := fmt.Sprintf("repos/%s/contents/%s", repo, cleanPath)
-	args := []string{"api", "-X", "GET", "-H", "Accept: application/vnd.github.raw", endpoint}
+	endpoint := fmt.Sprintf("repos/%s/contents/%s", r.FullName(), cleanPath)
+	args := append([]string{"api"}, r.APIArgs()...)
+	args = append(args, "-X", "GET", "-H", "Accept: application/vnd.github.raw", endpoint)
 
 	if branch != "" {
 		args = append(args, "-f", fmt.Sprintf("ref=%s", branch))
 	}
 
-	// Note: exec.Command passes arguments separately, not through shell, preventing injection
-	cmd := exec.Command("gh", args...)
-	output, err := cmd.Output()
+	output, err := c.run(ctx, runner.Command{Name: "gh", Args: args})
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("failed to read file from repo: %s", string(exitErr.Stderr))
-		}
-		return nil, fmt.Errorf("failed to read file from repo: %w", err)
+		return nil, newError(readKind(err), fmt.Errorf("failed to read file from repo: %s", runner.Stderr(err)))
 	}
 	return output, nil
 }
 
 func parseIssueFile(content string) (*IssueMetadata, string, error) {
-	// Split by frontmatter delimiters
-	parts := strings.Split(content, "---")
-	if len(parts) < 3 {
-		return nil, "", fmt.Errorf("invalid format: frontmatter not found")
+	frontmatter, body, err := splitFrontmatter(content)
+	if err != nil {
+		return nil, "", err
 	}
 
-	frontmatter := strings.TrimSpace(parts[1])
-	body := strings.TrimSpace(strings.Join(parts[2:], "---"))
-
 	metadata := &IssueMetadata{}
+	var scheduleExpr, scheduleStart string
 
 	// Parse frontmatter
 	lines := strings.Split(frontmatter, "\n")
@@ -229,13 +403,48 @@ func parseIssueFile(content string) (*IssueMetadata, string, error) {
 		} else if strings.HasPrefix(trimmed, "labels:") {
 			metadata.Labels, i = parseLabels(lines, i)
 		} else if strings.HasPrefix(trimmed, "milestone:") {
-			metadata.Milestone = extractValue(trimmed, "milestone:")
+			metadata.Milestone, i, err = parseMilestone(lines, i)
+			if err != nil {
+				return nil, "", newError(ErrValidation, err)
+			}
 		} else if strings.HasPrefix(trimmed, "projects:") {
-			metadata.Projects, i = parseListField(lines, i, "projects:")
+			metadata.Projects, i, err = parseProjects(lines, i)
+			if err != nil {
+				return nil, "", newError(ErrValidation, err)
+			}
+		} else if strings.HasPrefix(line, "comments:") {
+			metadata.Comments, i, err = parseComments(lines, i)
+			if err != nil {
+				return nil, "", newError(ErrValidation, err)
+			}
+		} else if strings.HasPrefix(line, "state:") {
+			metadata.State = extractValue(trimmed, "state:")
+		} else if strings.HasPrefix(line, "state_reason:") {
+			metadata.StateReason = extractValue(trimmed, "state_reason:")
+		} else if strings.HasPrefix(line, "lock:") {
+			metadata.Lock, i, err = parseLock(lines, i)
+			if err != nil {
+				return nil, "", newError(ErrValidation, err)
+			}
+		} else if strings.HasPrefix(line, "schedule:") {
+			scheduleExpr = extractValue(trimmed, "schedule:")
+		} else if strings.HasPrefix(line, "schedule_start:") {
+			scheduleStart = extractValue(trimmed, "schedule_start:")
+		} else if strings.HasPrefix(line, "pin:") {
+			metadata.Pin, err = config.Bool(extractValue(trimmed, "pin:"))
+			if err != nil {
+				return nil, "", newError(ErrValidation, fmt.Errorf("pin: %w", err))
+			}
 		}
 
 		i++
 	}
+	if err := validateState(metadata); err != nil {
+		return nil, "", newError(ErrValidation, err)
+	}
+	if metadata.Schedule, err = parseSchedule(scheduleExpr, scheduleStart); err != nil {
+		return nil, "", newError(ErrValidation, err)
+	}
 
 	return metadata, body, nil
 }
@@ -243,10 +452,10 @@ func parseIssueFile(content string) (*IssueMetadata, string, error) {
 func extractValue(line, prefix string) string {
 	value := strings.TrimPrefix(line, prefix)
 	value = strings.TrimSpace(value)
-	
+
 	// Strip inline comments (but preserve # inside quotes)
 	value = stripYAMLComment(value)
-	
+
 	value = strings.Trim(value, `"'`)
 	return value
 }
@@ -256,34 +465,34 @@ func stripYAMLComment(value string) string {
 	inSingleQuote := false
 	inDoubleQuote := false
 	var escaped bool
-	
+
 	for i, ch := range value {
 		if escaped {
 			escaped = false
 			continue
 		}
-		
+
 		if ch == '\\' {
 			escaped = true
 			continue
 		}
-		
+
 		if ch == '\'' && !inDoubleQuote {
 			inSingleQuote = !inSingleQuote
 			continue
 		}
-		
+
 		if ch == '"' && !inSingleQuote {
 			inDoubleQuote = !inDoubleQuote
 			continue
 		}
-		
+
 		// If we find # outside of quotes, strip from here onwards
 		if ch == '#' && !inSingleQuote && !inDoubleQuote {
 			return strings.TrimSpace(value[:i])
 		}
 	}
-	
+
 	return strings.TrimSpace(value)
 }
 
@@ -295,7 +504,7 @@ func parseListField(lines []string, startIdx int, prefix string) ([]string, int)
 	if strings.Contains(trimmed, "[") {
 		content := strings.TrimPrefix(trimmed, prefix)
 		content = strings.TrimSpace(content)
-		
+
 		// Strip comments before processing
 		content = stripYAMLComment(content)
 		content = strings.Trim(content, "[]")
@@ -328,10 +537,10 @@ func parseListField(lines []string, startIdx int, prefix string) ([]string, int)
 		item := strings.TrimSpace(line)
 		item = strings.TrimPrefix(item, "-")
 		item = strings.TrimSpace(item)
-		
+
 		// Strip comments from list items
 		item = stripYAMLComment(item)
-		
+
 		item = strings.Trim(item, `"'@`)
 		if item != "" {
 			items = append(items, item)
@@ -393,18 +602,35 @@ func parseLabels(lines []string, startIdx int) ([]Label, int) {
 	return labels, i - 1
 }
 
-func ensureLabelExists(label Label) error {
+// ensureLabels creates the labels that define a color or description, when they
+// don't exist yet. Labels given by name only must already exist.
+func (c *client) ensureLabels(ctx context.Context, labels []Label) error {
+	for _, label := range labels {
+		if label.Color != "" || label.Desc != "" {
+			if _, err := c.ensureLabelExists(ctx, label); err != nil {
+				return fmt.Errorf("error creating label: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// ensureLabelExists creates the label unless it already exists, and reports
+// whether it was created.
+func (c *client) ensureLabelExists(ctx context.Context, label Label) (bool, error) {
 	// Check if label exists
-	cmd := exec.Command("gh", "label", "list", "--json", "name", "--jq", ".[].name")
-	output, err := cmd.Output()
+	output, err := c.run(ctx, runner.Command{
+		Name: "gh",
+		Args: []string{"label", "list", "--json", "name", "--jq", ".[].name"},
+	})
 	if err != nil {
-		return fmt.Errorf("failed to list labels: %w", err)
+		return false, newError(commandKind(err), fmt.Errorf("failed to list labels: %w", err))
 	}
 
 	existingLabels := strings.Split(strings.TrimSpace(string(output)), "\n")
 	for _, existing := range existingLabels {
 		if strings.TrimSpace(existing) == label.Name {
-			return nil // Label already exists
+			return false, nil // Label already exists
 		}
 	}
 
@@ -420,32 +646,27 @@ func ensureLabelExists(label Label) error {
 		args = append(args, "--description", label.Desc)
 	}
 
-	cmd = exec.Command("gh", args...)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to create label: %w", err)
+	if _, err := c.run(ctx, runner.Command{Name: "gh", Args: args, Mutating: true}); err != nil {
+		return false, newError(commandKind(err), fmt.Errorf("failed to create label: %w", err))
 	}
+	c.journal.record(fmt.Sprintf("created label '%s'", label.Name), runner.Command{
+		Name: "gh",
+		Args: []string{"label", "delete", label.Name, "--yes"},
+	})
 
-	return nil
+	return true, nil
 }
 
-func createIssue(metadata *IssueMetadata, body string) error {
+// createIssue creates the issue and returns its URL.
+func (c *client) createIssue(ctx context.Context, metadata *IssueMetadata, body string) (string, error) {
 	args := []string{"issue", "create", "--title", metadata.Title}
 
-	// Add body
+	// Add body; it is passed on stdin so no temporary file is needed and the
+	// whole invocation can be recorded and replayed
+	var stdin []byte
 	if body != "" {
-		// Write body to temp file
-		tmpFile, err := os.CreateTemp("", "issue-body-*.md")
-		if err != nil {
-			return fmt.Errorf("failed to create temp file: %w", err)
-		}
-		defer os.Remove(tmpFile.Name())
-
-		if _, err := tmpFile.WriteString(body); err != nil {
-			return fmt.Errorf("failed to write body: %w", err)
-		}
-		tmpFile.Close()
-
-		args = append(args, "--body-file", tmpFile.Name())
+		stdin = []byte(body)
+		args = append(args, "--body-file", "-")
 	}
 
 	// Add assignees
@@ -463,28 +684,43 @@ func createIssue(metadata *IssueMetadata, body string) error {
 	}
 
 	// Add milestone
-	if metadata.Milestone != "" {
-		args = append(args, "--milestone", metadata.Milestone)
+	if metadata.Milestone != nil {
+		args = append(args, "--milestone", metadata.Milestone.Title)
 	}
 
 	// Add projects
 	for _, project := range metadata.Projects {
-		args = append(args, "--project", project)
+		args = append(args, "--project", project.Title)
 	}
 
 	fmt.Println("Creating issue...")
-	return runGhCommand(args)
-}
+	output, err := c.runGhCommand(ctx, args, stdin)
+	if err != nil {
+		return "", err
+	}
 
-func runGhCommand(args []string) error {
-	cmd := exec.Command("gh", args...)
-	var stderr bytes.Buffer
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = &stderr
+	url := strings.TrimSpace(string(output))
+	if i := strings.LastIndex(url, "\n"); i >= 0 {
+		url = url[i+1:]
+	}
+	c.journal.recordIssue(url, runner.Command{
+		Name: "gh",
+		Args: []string{"issue", "delete", url, "--yes"},
+	})
+	if c.created != nil {
+		if err := c.created(url); err != nil {
+			return "", err
+		}
+	}
+	return url, nil
+}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("gh command failed: %w\nStderr: %s", err, stderr.String())
+func (c *client) runGhCommand(ctx context.Context, args []string, stdin []byte) ([]byte, error) {
+	output, err := c.run(ctx, runner.Command{Name: "gh", Args: args, Stdin: stdin, Mutating: true})
+	if err != nil {
+		return nil, newError(commandKind(err), fmt.Errorf("gh command failed: %w", err))
 	}
+	_, _ = os.Stdout.Write(output)
 
-	return nil
+	return output, nil
 }
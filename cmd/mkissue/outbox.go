@@ -0,0 +1,395 @@
+package mkissue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lakruzz/gh-utils/internal/ghhost"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// EnvOutbox overrides the directory of the outbox.
+const EnvOutbox = "UTILS_OUTBOX"
+
+// outboxDir is the name of the outbox directory inside the git directory.
+const outboxDir = "utils-outbox"
+
+// Request is an issue creation that was queued in the outbox. It holds
+// everything needed to create the issue, so flushing doesn't read the issue
+// file again.
+type Request struct {
+	ID       string        `json:"id"`
+	QueuedAt time.Time     `json:"queued_at"`
+	File     string        `json:"file"`
+	Metadata IssueMetadata `json:"metadata"`
+	// Body ends with the marker of the request, see Marker.
+	Body string `json:"body"`
+	// Repo is the repository the issue is created in, in HOST/OWNER/REPO
+	// format, resolved when the request is queued.
+	Repo string `json:"repo,omitempty"`
+	// URL is the issue created for the request, recorded as soon as it's
+	// created, while the request is still in the outbox.
+	URL string `json:"url,omitempty"`
+}
+
+// Marker returns the HTML comment embedded in the body of a queued issue. It
+// is invisible on GitHub and lets a flush find issues that were already
+// created, when a crash kept the URL from being recorded.
+func (r Request) Marker() string {
+	return "<!-- utils-outbox: " + r.ID + " -->"
+}
+
+// newRequest resolves an issue into a request with a new ID and the marker
+// appended to its body.
+func newRequest(file string, metadata *IssueMetadata, body string) *Request {
+	random := make([]byte, 4)
+	// crypto/rand.Read doesn't fail on supported platforms
+	_, _ = rand.Read(random)
+	now := time.Now().UTC()
+	r := &Request{
+		ID:       now.Format("20060102T150405Z") + "-" + hex.EncodeToString(random),
+		QueuedAt: now,
+		File:     file,
+		Metadata: *metadata,
+	}
+	r.Body = strings.TrimSpace(body + "\n\n" + r.Marker())
+	return r
+}
+
+// Outbox is a directory of queued issue creations, one JSON file per request.
+type Outbox struct {
+	Dir string
+}
+
+// OpenOutbox returns the outbox of the current repository, kept in its git
+// directory, or in the user cache directory outside a repository.
+// $UTILS_OUTBOX overrides the location.
+func OpenOutbox() (*Outbox, error) {
+	if dir := os.Getenv(EnvOutbox); dir != "" {
+		return &Outbox{Dir: dir}, nil
+	}
+	if gitDir := findGitDir(); gitDir != "" {
+		return &Outbox{Dir: filepath.Join(gitDir, outboxDir)}, nil
+	}
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the outbox: %w", err)
+	}
+	return &Outbox{Dir: filepath.Join(cache, "gh-utils", "outbox")}, nil
+}
+
+// findGitDir returns the git directory of the working directory or its
+// closest parent, following the .git file of worktrees and submodules.
+func findGitDir() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ".git")
+		if info, err := os.Stat(path); err == nil {
+			if info.IsDir() {
+				return path
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return ""
+			}
+			gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+			if !ok {
+				return ""
+			}
+			gitDir = strings.TrimSpace(gitDir)
+			if !filepath.IsAbs(gitDir) {
+				gitDir = filepath.Join(dir, gitDir)
+			}
+			return gitDir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Add stores a request in the outbox.
+func (o *Outbox) Add(r *Request) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(o.Dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create the outbox: %w", err)
+	}
+	// Write to a temporary file first so a crash never leaves half a request
+	tmp, err := os.CreateTemp(o.Dir, ".queue-*")
+	if err != nil {
+		return fmt.Errorf("failed to queue the issue: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to queue the issue: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to queue the issue: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), o.path(r.ID)); err != nil {
+		return fmt.Errorf("failed to queue the issue: %w", err)
+	}
+	return nil
+}
+
+// List returns the queued requests, oldest first.
+func (o *Outbox) List() ([]Request, error) {
+	entries, err := os.ReadDir(o.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the outbox: %w", err)
+	}
+	var requests []Request
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(o.Dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the outbox: %w", err)
+		}
+		var r Request
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("failed to read '%s' in the outbox: %w", e.Name(), err)
+		}
+		requests = append(requests, r)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if !requests[i].QueuedAt.Equal(requests[j].QueuedAt) {
+			return requests[i].QueuedAt.Before(requests[j].QueuedAt)
+		}
+		return requests[i].ID < requests[j].ID
+	})
+	return requests, nil
+}
+
+// Drop removes a request from the outbox without creating its issue.
+func (o *Outbox) Drop(id string) error {
+	if strings.ContainsAny(id, `/\`) || id == "" {
+		return newError(ErrValidation, fmt.Errorf("invalid outbox ID '%s'", id))
+	}
+	err := os.Remove(o.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return newError(ErrValidation, fmt.Errorf("'%s' is not in the outbox", id))
+	}
+	return err
+}
+
+// Flush creates the issues of the queued requests, oldest first, in the
+// repository each was queued for, and removes them from the outbox. A request
+// whose issue already exists, because an earlier flush failed after creating
+// it, is only removed; the comments, pin, lock and state it would have added
+// are reported as not applied. Flushing stops at the first network error;
+// other failures are reported and the remaining requests are still flushed.
+func (o *Outbox) Flush(ctx context.Context, opts Options) ([]string, error) {
+	requests, err := o.List()
+	if err != nil {
+		return nil, err
+	}
+	var created []string
+	var errs []error
+	for _, r := range requests {
+		url, err := o.flushRequest(ctx, r, opts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s (%s): %w", r.ID, r.Metadata.Title, err))
+		}
+		if url == "" {
+			if errors.Is(err, ErrNetwork) || ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		created = append(created, url)
+		if err := o.Drop(r.ID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return created, errors.Join(errs...)
+}
+
+// flushRequest creates the issue of a request unless it already exists, and
+// returns its URL. The URL is written to the request as soon as the issue is
+// created, so a flush that fails later doesn't create it again.
+func (o *Outbox) flushRequest(ctx context.Context, r Request, opts Options) (string, error) {
+	c := newClient(opts.Runner)
+	// Requests queued by older versions are created in the current repository
+	target := r.Repo
+	if target == "" {
+		var err error
+		if target, err = c.queueTarget(ctx, opts.Host); err != nil {
+			return "", err
+		}
+	}
+	repo, err := ghhost.ParseRepo(target)
+	if err != nil {
+		return "", newError(ErrValidation, fmt.Errorf("invalid repository '%s': %w", target, err))
+	}
+	c.runner = ghhost.WithRepo(c.runner, repo)
+
+	url := r.URL
+	if url == "" {
+		if url, err = c.findQueued(ctx, r, repo); err != nil {
+			return "", err
+		}
+	}
+	if url != "" {
+		fmt.Printf("Already created: %s\n", url)
+		// The run that created it may have failed before setting the fields
+		fields, err := c.resolveProjectFields(ctx, r.Metadata.Projects)
+		if err == nil {
+			err = c.setProjectFields(ctx, url, fields)
+		}
+		if err != nil {
+			return "", err
+		}
+		// Whether it got to the follow-up steps isn't known, and running them
+		// again could post the comments twice, so they're left to check
+		if steps := followUpSteps(&r.Metadata); len(steps) > 0 {
+			return url, &PartialError{
+				Completed: []string{"created issue " + url},
+				Issue:     url,
+				Err:       fmt.Errorf("the issue was created by an earlier flush; check that it has its %s", strings.Join(steps, ", ")),
+			}
+		}
+		return url, nil
+	}
+
+	c.created = func(url string) error {
+		r.URL = url
+		return o.Add(&r)
+	}
+	url, err = c.publish(ctx, &r.Metadata, r.Body)
+	if err != nil {
+		err = c.fail(ctx, err, opts.KeepPartial)
+		// An issue that was rolled back is created again by the next flush
+		var partial *PartialError
+		if r.URL != "" && !(errors.As(err, &partial) && partial.Issue != "") {
+			r.URL = ""
+			if addErr := o.Add(&r); addErr != nil {
+				err = errors.Join(err, addErr)
+			}
+		}
+		return "", err
+	}
+	return url, nil
+}
+
+// findQueued returns the URL of the issue created from r in repo, or "" when
+// there is none. Issues are searched for by the ID in their marker; the search
+// index lags behind new issues, so the latest issues are looked through too.
+func (c *client) findQueued(ctx context.Context, r Request, repo ghhost.Repo) (string, error) {
+	for _, args := range [][]string{
+		{"search", "issues", `"` + r.ID + `"`, "--repo", repo.FullName(), "--match", "body", "--json", "url,body,createdAt", "--limit", "100"},
+		{"issue", "list", "--state", "all", "--json", "url,body,createdAt", "--limit", "100"},
+	} {
+		output, err := c.run(ctx, runner.Command{Name: "gh", Args: args})
+		if err != nil {
+			return "", newError(commandKind(err), fmt.Errorf("failed to look for issues created from the outbox: %w", err))
+		}
+		var issues []struct {
+			URL       string    `json:"url"`
+			Body      string    `json:"body"`
+			CreatedAt time.Time `json:"createdAt"`
+		}
+		if err := json.Unmarshal(output, &issues); err != nil {
+			return "", fmt.Errorf("failed to look for issues created from the outbox: %w", err)
+		}
+		// The first issue created from the request is the one to keep
+		sort.SliceStable(issues, func(i, j int) bool { return issues[i].CreatedAt.Before(issues[j].CreatedAt) })
+		for _, issue := range issues {
+			if strings.Contains(issue.Body, r.Marker()) {
+				return issue.URL, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// queueTarget returns the repository gh creates issues in, in HOST/OWNER/REPO
+// format: $GH_REPO, on host unless it names one, or the repository of the
+// origin remote.
+func (c *client) queueTarget(ctx context.Context, host string) (string, error) {
+	if env := os.Getenv(ghhost.EnvRepo); env != "" {
+		repo, err := ghhost.ParseRepo(env)
+		if err != nil {
+			return "", newError(ErrValidation, fmt.Errorf("invalid %s '%s': %w", ghhost.EnvRepo, env, err))
+		}
+		if repo.Host == "" {
+			repo.Host = host
+		}
+		if repo.Host == "" {
+			repo.Host = os.Getenv(ghhost.EnvHost)
+		}
+		if repo.Host == "" {
+			repo.Host = ghhost.Default
+		}
+		return repo.String(), nil
+	}
+	output, err := c.run(ctx, runner.Command{Name: "git", Args: []string{"remote", "get-url", "origin"}})
+	if err != nil {
+		return "", newError(ErrValidation, fmt.Errorf("can't tell which repository the issue is for; run in a clone of it or set %s", ghhost.EnvRepo))
+	}
+	repo, err := ghhost.ParseRemote(string(output))
+	if err != nil {
+		return "", newError(ErrValidation, fmt.Errorf("can't tell which repository the issue is for: %w; set %s", err, ghhost.EnvRepo))
+	}
+	return repo.String(), nil
+}
+
+// followUpSteps describes the steps followUp takes for metadata.
+func followUpSteps(metadata *IssueMetadata) []string {
+	var steps []string
+	if n := len(metadata.Continued); n > 0 {
+		steps = append(steps, fmt.Sprintf("%d continuation comment(s)", n))
+	}
+	if n := len(metadata.Comments); n > 0 {
+		steps = append(steps, fmt.Sprintf("%d comment(s)", n))
+	}
+	if metadata.Pin {
+		steps = append(steps, "pin")
+	}
+	if metadata.Lock != nil {
+		steps = append(steps, "lock")
+	}
+	if metadata.State == StateClosed {
+		steps = append(steps, "closed state")
+	}
+	return steps
+}
+
+// queue stores the request in the outbox of the current repository.
+func queue(r *Request) error {
+	o, err := OpenOutbox()
+	if err != nil {
+		return err
+	}
+	if err := o.Add(r); err != nil {
+		return err
+	}
+	fmt.Printf("Issue '%s' queued as %s; run 'gh utils outbox flush' to create it\n", r.Metadata.Title, r.ID)
+	return nil
+}
+
+func (o *Outbox) path(id string) string {
+	return filepath.Join(o.Dir, id+".json")
+}
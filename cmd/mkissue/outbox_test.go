@@ -0,0 +1,381 @@
+package mkissue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+// fakeGitHub answers gh commands with respond and records them.
+type fakeGitHub struct {
+	calls   []runner.Command
+	respond func(cmd runner.Command) (string, string)
+}
+
+func (f *fakeGitHub) Run(_ context.Context, cmd runner.Command) (runner.Result, error) {
+	f.calls = append(f.calls, cmd)
+	stdout, stderr := f.respond(cmd)
+	if stderr != "" {
+		res := runner.Result{Stderr: []byte(stderr), ExitCode: 1}
+		return res, &runner.ExitError{Command: cmd, Result: res}
+	}
+	return runner.Result{Stdout: []byte(stdout)}, nil
+}
+
+func (f *fakeGitHub) called(args ...string) []runner.Command {
+	var matching []runner.Command
+	for _, cmd := range f.calls {
+		if len(cmd.Args) >= len(args) && reflect.DeepEqual(cmd.Args[:len(args)], args) {
+			matching = append(matching, cmd)
+		}
+	}
+	return matching
+}
+
+const unreachable = "error connecting to api.github.com: dial tcp: lookup api.github.com: no such host"
+
+func testOutbox(t *testing.T) *Outbox {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv(EnvOutbox, dir)
+	t.Setenv("GH_REPO", "o/r")
+	t.Setenv("GH_HOST", "")
+	return &Outbox{Dir: dir}
+}
+
+func TestCreateQueue(t *testing.T) {
+	outbox := testOutbox(t)
+	issueFile := filepath.Join("testdata", "labels.issue.md")
+
+	if err := Create(context.Background(), issueFile, Options{Runner: testutil.Expect(t), Queue: true}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	requests, err := outbox.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("List() = %d requests, want 1", len(requests))
+	}
+	r := requests[0]
+	if r.File != issueFile || r.Metadata.Title != "Support labels from frontmatter" || r.Metadata.Milestone == nil || r.Metadata.Milestone.Title != "v1.0" {
+		t.Errorf("queued request = %+v", r)
+	}
+	if want := []Label{{Name: "bug"}, {Name: "needs-triage", Color: "fbca04", Desc: "Waiting for triage"}}; !reflect.DeepEqual(r.Metadata.Labels, want) {
+		t.Errorf("queued labels = %+v, want %+v", r.Metadata.Labels, want)
+	}
+	if !strings.HasPrefix(r.Body, "## Description") || !strings.HasSuffix(r.Body, "\n\n"+r.Marker()) {
+		t.Errorf("queued body = %q", r.Body)
+	}
+	if r.Repo != "github.com/o/r" {
+		t.Errorf("queued repo = %q, want github.com/o/r", r.Repo)
+	}
+}
+
+func TestCreateQueueResolvesRepo(t *testing.T) {
+	issueFile := filepath.Join("testdata", "labels.issue.md")
+	tests := []struct {
+		name   string
+		repo   string
+		host   string
+		remote []runner.Interaction
+		want   string
+	}{
+		{name: "GH_REPO on --hostname", repo: "o/r", host: "ghes.example.com", want: "ghes.example.com/o/r"},
+		{name: "GH_REPO with a host", repo: "ghes.example.com/o/r", host: "other.example.com", want: "ghes.example.com/o/r"},
+		{
+			name:   "origin remote",
+			host:   "other.example.com",
+			remote: []runner.Interaction{testutil.Git("git@ghes.example.com:o/r.git\n", "remote", "get-url", "origin")},
+			want:   "ghes.example.com/o/r",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := testOutbox(t)
+			t.Setenv("GH_REPO", tt.repo)
+			if err := Create(context.Background(), issueFile, Options{Runner: testutil.Expect(t, tt.remote...), Queue: true, Host: tt.host}); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if requests, _ := outbox.List(); len(requests) != 1 || requests[0].Repo != tt.want {
+				t.Errorf("queued requests = %+v, want one for %s", requests, tt.want)
+			}
+		})
+	}
+
+	// Outside a repository the issue isn't queued for an unknown one
+	outbox := testOutbox(t)
+	t.Setenv("GH_REPO", "")
+	noRemote := runner.Interaction{Name: "git", Args: []string{"remote", "get-url", "origin"}, Stderr: "error: No such remote 'origin'", ExitCode: 2}
+	if err := Create(context.Background(), issueFile, Options{Runner: testutil.Expect(t, noRemote), Queue: true}); !errors.Is(err, ErrValidation) {
+		t.Errorf("Create() error = %v, want validation error", err)
+	}
+	if requests, _ := outbox.List(); len(requests) != 0 {
+		t.Errorf("outbox has %d requests, want none", len(requests))
+	}
+}
+
+func TestCreateQueueOffline(t *testing.T) {
+	tests := []struct {
+		name string
+		// failing is the gh command that can't reach GitHub
+		failing    string
+		wantLabels int
+	}{
+		{name: "before any change", failing: "label"},
+		{name: "after creating a label", failing: "issue", wantLabels: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := testOutbox(t)
+			gh := &fakeGitHub{respond: func(cmd runner.Command) (string, string) {
+				if cmd.Args[0] == tt.failing {
+					return "", unreachable
+				}
+				if cmd.Args[0] == "api" {
+					return `[{"number":1,"title":"v1.0","state":"open"}]`, ""
+				}
+				return "bug\n", ""
+			}}
+
+			err := Create(context.Background(), filepath.Join("testdata", "labels.issue.md"), Options{Runner: gh, QueueOffline: true})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			requests, _ := outbox.List()
+			if len(requests) != 1 {
+				t.Fatalf("outbox has %d requests, want 1", len(requests))
+			}
+			// Labels are kept for the queued issue
+			if got := len(gh.called("label", "create")); got != tt.wantLabels {
+				t.Errorf("created %d labels, want %d", got, tt.wantLabels)
+			}
+			if got := gh.called("label", "delete"); len(got) != 0 {
+				t.Errorf("labels were rolled back: %v", got)
+			}
+			// The attempt carried the marker, in case the issue was created after all
+			for _, cmd := range gh.called("issue", "create") {
+				if !strings.Contains(string(cmd.Stdin), requests[0].Marker()) {
+					t.Errorf("issue body %q has no marker", cmd.Stdin)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateWithoutQueueOfflineFails(t *testing.T) {
+	outbox := testOutbox(t)
+	gh := &fakeGitHub{respond: func(runner.Command) (string, string) { return "", unreachable }}
+
+	err := Create(context.Background(), filepath.Join("testdata", "labels.issue.md"), Options{Runner: gh})
+	if !errors.Is(err, ErrNetwork) {
+		t.Errorf("Create() error = %v, want network error", err)
+	}
+	if requests, _ := outbox.List(); len(requests) != 0 {
+		t.Errorf("outbox has %d requests, want none", len(requests))
+	}
+}
+
+func TestFlush(t *testing.T) {
+	outbox := testOutbox(t)
+	pending := newRequest("a.issue.md", &IssueMetadata{Title: "Pending", Labels: []Label{{Name: "bug"}}}, "Not created yet")
+	pending.Repo = "ghes.example.com/o/r"
+	done := newRequest("b.issue.md", &IssueMetadata{Title: "Done"}, "Created by an earlier flush")
+	old := newRequest("c.issue.md", &IssueMetadata{Title: "Old"}, "Created long ago")
+	for _, r := range []*Request{pending, done, old} {
+		if err := outbox.Add(r); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	gh := &fakeGitHub{respond: func(cmd runner.Command) (string, string) {
+		switch cmd.Args[0] + " " + cmd.Args[1] {
+		case "search issues":
+			// Only the marker counts, not a mention of the ID
+			return `[{"url":"https://github.com/o/r/issues/3","body":"Mentions ` + pending.ID + `","createdAt":"2026-10-18T10:00:00Z"},` +
+				`{"url":"https://github.com/o/r/issues/4","body":"Created long ago\n\n` + old.Marker() + `","createdAt":"2026-10-01T09:00:00Z"}]`, ""
+		case "issue list":
+			// The search index doesn't have the latest issues yet
+			return `[{"url":"https://github.com/o/r/issues/1","body":"Created by an earlier flush\n\n` + done.Marker() + `","createdAt":"2026-10-18T09:00:00Z"}]`, ""
+		}
+		return "https://ghes.example.com/o/r/issues/2\n", ""
+	}}
+
+	created, err := outbox.Flush(context.Background(), Options{Runner: gh})
+	if err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	want := []string{"https://ghes.example.com/o/r/issues/2", "https://github.com/o/r/issues/1", "https://github.com/o/r/issues/4"}
+	if !reflect.DeepEqual(created, want) {
+		t.Errorf("Flush() = %v, want %v", created, want)
+	}
+	creates := gh.called("issue", "create")
+	if len(creates) != 1 || string(creates[0].Stdin) != pending.Body {
+		t.Errorf("issue create calls = %v, want one with the queued body", creates)
+	}
+	// Each request goes to the repository it was queued for
+	for _, cmd := range gh.calls {
+		if slices.Contains(cmd.Env, "GH_REPO=ghes.example.com/o/r") {
+			if !slices.Contains(cmd.Env, "GH_HOST=ghes.example.com") {
+				t.Errorf("%v runs with %v, want GH_HOST", cmd.Args, cmd.Env)
+			}
+		} else if !slices.Contains(cmd.Env, "GH_REPO=github.com/o/r") {
+			t.Errorf("%v runs with %v, want GH_REPO", cmd.Args, cmd.Env)
+		}
+		if cmd.Args[0] == "search" && !slices.Contains(cmd.Args, "o/r") {
+			t.Errorf("search %v, want it limited to o/r", cmd.Args)
+		}
+	}
+	if requests, _ := outbox.List(); len(requests) != 0 {
+		t.Errorf("outbox has %d requests after flush, want none", len(requests))
+	}
+}
+
+func TestFlushRecordsCreatedIssue(t *testing.T) {
+	url := "https://github.com/o/r/issues/2"
+	tests := []struct {
+		name        string
+		keepPartial bool
+		wantURL     string
+	}{
+		{"keep partial", true, url},
+		{"rolled back", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := testOutbox(t)
+			r := newRequest("a.issue.md", &IssueMetadata{Title: "Commented", Comments: []Comment{{Body: "Agenda"}}}, "Body")
+			if err := outbox.Add(r); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+			var recorded string
+			gh := &fakeGitHub{respond: func(cmd runner.Command) (string, string) {
+				switch cmd.Args[1] {
+				case "issues", "list":
+					return "[]", ""
+				case "create":
+					return url + "\n", ""
+				case "comment":
+					// The URL is recorded before the follow-up steps
+					requests, _ := outbox.List()
+					recorded = requests[0].URL
+					return "", "HTTP 422: Validation Failed"
+				}
+				return "", ""
+			}}
+
+			if _, err := outbox.Flush(context.Background(), Options{Runner: gh, KeepPartial: tt.keepPartial}); err == nil {
+				t.Fatal("Flush() expected error")
+			}
+			if recorded != url {
+				t.Errorf("URL when commenting = %q, want %q", recorded, url)
+			}
+			requests, _ := outbox.List()
+			if len(requests) != 1 || requests[0].URL != tt.wantURL {
+				t.Fatalf("outbox = %+v, want the request with URL %q", requests, tt.wantURL)
+			}
+			if tt.wantURL == "" {
+				return
+			}
+
+			// The next flush uses the recorded issue without looking for it, and
+			// reports the comment it can't tell was posted
+			gh.calls = nil
+			created, err := outbox.Flush(context.Background(), Options{Runner: gh})
+			var partial *PartialError
+			if !errors.As(err, &partial) || partial.Issue != url || !strings.Contains(err.Error(), "1 comment(s)") {
+				t.Errorf("Flush() error = %v, want the comment reported", err)
+			}
+			if !reflect.DeepEqual(created, []string{url}) || len(gh.calls) != 0 {
+				t.Errorf("Flush() = %v after %v, want the recorded issue only", created, gh.calls)
+			}
+			if requests, _ := outbox.List(); len(requests) != 0 {
+				t.Errorf("outbox has %d requests, want none", len(requests))
+			}
+		})
+	}
+}
+
+func TestFlushStopsOnNetworkError(t *testing.T) {
+	outbox := testOutbox(t)
+	for _, title := range []string{"First", "Second"} {
+		if err := outbox.Add(newRequest("", &IssueMetadata{Title: title}, "")); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+	gh := &fakeGitHub{respond: func(runner.Command) (string, string) { return "", unreachable }}
+
+	created, err := outbox.Flush(context.Background(), Options{Runner: gh})
+	if !errors.Is(err, ErrNetwork) || len(created) != 0 {
+		t.Errorf("Flush() = %v, %v; want network error", created, err)
+	}
+	if len(gh.calls) != 1 {
+		t.Errorf("Flush() ran %d commands, want to stop after the first", len(gh.calls))
+	}
+	if requests, _ := outbox.List(); len(requests) != 2 {
+		t.Errorf("outbox has %d requests, want both kept", len(requests))
+	}
+}
+
+func TestDrop(t *testing.T) {
+	outbox := testOutbox(t)
+	r := newRequest("", &IssueMetadata{Title: "Dropped"}, "")
+	if err := outbox.Add(r); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := outbox.Drop(r.ID); err != nil {
+		t.Errorf("Drop() error = %v", err)
+	}
+	for _, id := range []string{r.ID, "../config", ""} {
+		if err := outbox.Drop(id); !errors.Is(err, ErrValidation) {
+			t.Errorf("Drop(%q) error = %v, want validation error", id, err)
+		}
+	}
+}
+
+func TestOpenOutbox(t *testing.T) {
+	t.Setenv(EnvOutbox, "")
+	root := t.TempDir()
+	// A worktree points at its git directory with a .git file
+	gitDir := filepath.Join(root, "main", ".git", "worktrees", "wt")
+	worktree := filepath.Join(root, "wt")
+	for _, dir := range []string{gitDir, filepath.Join(worktree, "sub")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+gitDir+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	for dir, want := range map[string]string{
+		filepath.Join(root, "main"):    filepath.Join(root, "main", ".git", outboxDir),
+		filepath.Join(worktree, "sub"): filepath.Join(gitDir, outboxDir),
+	} {
+		if err := os.Chdir(dir); err != nil {
+			t.Fatal(err)
+		}
+		outbox, err := OpenOutbox()
+		if err != nil {
+			t.Fatalf("OpenOutbox() error = %v", err)
+		}
+		if outbox.Dir != want {
+			t.Errorf("OpenOutbox() in %s = %s, want %s", dir, outbox.Dir, want)
+		}
+	}
+}
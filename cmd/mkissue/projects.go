@@ -0,0 +1,399 @@
+package mkissue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lakruzz/gh-utils/internal/config"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// Project is a Projects (v2) board the issue is added to, with the values of
+// the custom fields to set on its item, by field name.
+type Project struct {
+	Title  string            `json:"title"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// Iteration values that are resolved relative to today.
+const (
+	IterationCurrent = "@current"
+	IterationNext    = "@next"
+)
+
+// now is replaced in tests to pin the current iteration.
+var now = time.Now
+
+// parseProjects parses the projects field: a list of project titles, or of
+// mappings with a title and fields, e.g.
+//
+//	projects: [{title: Roadmap, fields: {Status: Todo, Estimate: 3}}]
+func parseProjects(lines []string, startIdx int) ([]Project, int, error) {
+	block, end := fieldBlock(lines, startIdx)
+	if !strings.Contains(block, "{") && !strings.Contains(block, "title:") {
+		titles, i := parseListField(lines, startIdx, "projects:")
+		projects := make([]Project, len(titles))
+		for j, title := range titles {
+			projects[j] = Project{Title: title}
+		}
+		return projects, i, nil
+	}
+
+	tree, err := config.ParseYAML([]byte(block))
+	if err != nil {
+		return nil, startIdx, fmt.Errorf("projects: %w", err)
+	}
+	root, _ := config.Map(tree)
+	items, err := config.List(root["projects"])
+	if err != nil {
+		return nil, startIdx, fmt.Errorf("projects: %w", err)
+	}
+	var projects []Project
+	for n, item := range items {
+		if title, ok := item.(string); ok {
+			projects = append(projects, Project{Title: title})
+			continue
+		}
+		m, err := config.Map(item)
+		if err != nil {
+			return nil, startIdx, fmt.Errorf("projects[%d]: expected a title or a mapping", n)
+		}
+		p := Project{Title: config.String(m["title"])}
+		if p.Title == "" {
+			return nil, startIdx, fmt.Errorf("projects[%d]: 'title' is required", n)
+		}
+		fields, err := config.Map(m["fields"])
+		if err != nil {
+			return nil, startIdx, fmt.Errorf("projects[%d].fields: %w", n, err)
+		}
+		for name, v := range fields {
+			value, ok := v.(string)
+			if !ok {
+				return nil, startIdx, fmt.Errorf("projects[%d].fields.%s: expected a single value", n, name)
+			}
+			if p.Fields == nil {
+				p.Fields = map[string]string{}
+			}
+			p.Fields[name] = value
+		}
+		projects = append(projects, p)
+	}
+	return projects, end, nil
+}
+
+// fieldUpdate is a project field value resolved to the IDs GitHub expects.
+type fieldUpdate struct {
+	projectID    string
+	projectTitle string
+	fieldID      string
+	fieldName    string
+	// key is the member of ProjectV2FieldValue to set, e.g. singleSelectOptionId.
+	key   string
+	value string
+	// number values are passed as numbers instead of strings.
+	number bool
+}
+
+type projectNode struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Fields struct {
+		Nodes []projectField `json:"nodes"`
+	} `json:"fields"`
+}
+
+type projectField struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	DataType string `json:"dataType"`
+	Options  []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"options"`
+	Configuration struct {
+		Iterations []struct {
+			ID        string `json:"id"`
+			Title     string `json:"title"`
+			StartDate string `json:"startDate"`
+			Duration  int    `json:"duration"`
+		} `json:"iterations"`
+	} `json:"configuration"`
+}
+
+const projectsQuery = `query($owner: String!, $repo: String!, $title: String!) {
+  repository(owner: $owner, name: $repo) {
+    projectsV2(first: 20, query: $title) { nodes { ...project } }
+    owner { ... on ProjectV2Owner { projectsV2(first: 20, query: $title) { nodes { ...project } } } }
+  }
+}
+fragment project on ProjectV2 {
+  id
+  title
+  fields(first: 100) {
+    nodes {
+      ... on ProjectV2FieldCommon { id name dataType }
+      ... on ProjectV2SingleSelectField { options { id name } }
+      ... on ProjectV2IterationField { configuration { iterations { id title startDate duration } } }
+    }
+  }
+}`
+
+// resolveProjectFields looks up the fields of the projects that set any, and
+// resolves their values. Unknown projects, fields and options are validation
+// errors with suggestions, reported before anything is changed on GitHub.
+func (c *client) resolveProjectFields(ctx context.Context, projects []Project) ([]fieldUpdate, error) {
+	var updates []fieldUpdate
+	var errs []error
+	for _, p := range projects {
+		if len(p.Fields) == 0 {
+			continue
+		}
+		project, err := c.findProject(ctx, p.Title)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(p.Fields))
+		for name := range p.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			u, err := resolveField(project, name, p.Fields[name])
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			updates = append(updates, u)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, newError(ErrValidation, errors.Join(errs...))
+	}
+	return updates, nil
+}
+
+// findProject returns the project with title, owned by the current repository or its owner.
+func (c *client) findProject(ctx context.Context, title string) (*projectNode, error) {
+	output, err := c.run(ctx, runner.Command{
+		Name: "gh",
+		Args: []string{"api", "graphql", "-f", "query=" + projectsQuery, "-F", "owner={owner}", "-F", "repo={repo}", "-f", "title=" + title},
+	})
+	if err != nil {
+		return nil, newError(commandKind(err), fmt.Errorf("failed to look up project '%s': %s", title, runner.Stderr(err)))
+	}
+	var resp struct {
+		Data struct {
+			Repository struct {
+				ProjectsV2 struct {
+					Nodes []projectNode `json:"nodes"`
+				} `json:"projectsV2"`
+				Owner struct {
+					ProjectsV2 struct {
+						Nodes []projectNode `json:"nodes"`
+					} `json:"projectsV2"`
+				} `json:"owner"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return nil, fmt.Errorf("failed to look up project '%s': %w", title, err)
+	}
+	repo := resp.Data.Repository
+	candidates := append(repo.ProjectsV2.Nodes, repo.Owner.ProjectsV2.Nodes...)
+	titles := make([]string, len(candidates))
+	for i, p := range candidates {
+		if strings.EqualFold(p.Title, title) {
+			return &candidates[i], nil
+		}
+		titles[i] = p.Title
+	}
+	return nil, newError(ErrValidation, fmt.Errorf("project '%s' not found%s", title, suggest(title, titles)))
+}
+
+// resolveField resolves the value of the named field of project.
+func resolveField(project *projectNode, name, value string) (fieldUpdate, error) {
+	var field *projectField
+	names := make([]string, 0, len(project.Fields.Nodes))
+	for i, f := range project.Fields.Nodes {
+		if f.ID == "" {
+			continue
+		}
+		if strings.EqualFold(f.Name, name) {
+			field = &project.Fields.Nodes[i]
+			break
+		}
+		names = append(names, f.Name)
+	}
+	if field == nil {
+		return fieldUpdate{}, fmt.Errorf("project '%s' has no field '%s'%s", project.Title, name, suggest(name, names))
+	}
+
+	u := fieldUpdate{projectID: project.ID, projectTitle: project.Title, fieldID: field.ID, fieldName: field.Name, value: value}
+	invalid := func(format string, args ...any) (fieldUpdate, error) {
+		return fieldUpdate{}, fmt.Errorf("field '%s' of project '%s': %s", field.Name, project.Title, fmt.Sprintf(format, args...))
+	}
+	switch field.DataType {
+	case "SINGLE_SELECT":
+		options := make([]string, len(field.Options))
+		for i, o := range field.Options {
+			if strings.EqualFold(o.Name, value) {
+				u.key, u.value = "singleSelectOptionId", o.ID
+				return u, nil
+			}
+			options[i] = o.Name
+		}
+		return invalid("unknown option '%s'%s (options: %s)", value, suggest(value, options), strings.Join(options, ", "))
+	case "NUMBER":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return invalid("'%s' is not a number", value)
+		}
+		u.key, u.number = "number", true
+	case "DATE":
+		if _, err := time.Parse(time.DateOnly, value); err != nil {
+			return invalid("'%s' is not a date in YYYY-MM-DD format", value)
+		}
+		u.key = "date"
+	case "TEXT":
+		u.key = "text"
+	case "ITERATION":
+		id, err := findIteration(field, value)
+		if err != nil {
+			return invalid("%v", err)
+		}
+		u.key, u.value = "iterationId", id
+	default:
+		return invalid("fields of type %s can't be set from frontmatter", field.DataType)
+	}
+	return u, nil
+}
+
+// findIteration returns the ID of the iteration titled value, or of the
+// current or next iteration for @current and @next.
+func findIteration(field *projectField, value string) (string, error) {
+	today := now().Format(time.DateOnly)
+	titles := make([]string, 0, len(field.Configuration.Iterations))
+	for _, it := range field.Configuration.Iterations {
+		start, err := time.Parse(time.DateOnly, it.StartDate)
+		if err != nil {
+			continue
+		}
+		end := start.AddDate(0, 0, it.Duration).Format(time.DateOnly)
+		switch {
+		case value == IterationCurrent && it.StartDate <= today && today < end,
+			value == IterationNext && it.StartDate > today,
+			strings.EqualFold(it.Title, value):
+			// Iterations are listed in order, so the first upcoming one is next
+			return it.ID, nil
+		}
+		titles = append(titles, it.Title)
+	}
+	if value == IterationCurrent || value == IterationNext {
+		return "", fmt.Errorf("there is no %s iteration", strings.TrimPrefix(value, "@"))
+	}
+	return "", fmt.Errorf("unknown iteration '%s'%s", value, suggest(value, titles))
+}
+
+const itemsQuery = `query($url: URI!) {
+  resource(url: $url) { ... on Issue { projectItems(first: 50) { nodes { id project { id } } } } }
+}`
+
+const updateFieldMutation = `mutation($project: ID!, $item: ID!, $field: ID!, $value: ProjectV2FieldValue!) {
+  updateProjectV2ItemFieldValue(input: {projectId: $project, itemId: $item, fieldId: $field, value: $value}) { projectV2Item { id } }
+}`
+
+// setProjectFields sets the resolved fields on the project items of the issue at url.
+func (c *client) setProjectFields(ctx context.Context, url string, updates []fieldUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+	output, err := c.run(ctx, runner.Command{
+		Name: "gh",
+		Args: []string{"api", "graphql", "-f", "query=" + itemsQuery, "-f", "url=" + url},
+	})
+	if err != nil {
+		return newError(commandKind(err), fmt.Errorf("failed to find the project items of %s: %s", url, runner.Stderr(err)))
+	}
+	var resp struct {
+		Data struct {
+			Resource struct {
+				ProjectItems struct {
+					Nodes []struct {
+						ID      string `json:"id"`
+						Project struct {
+							ID string `json:"id"`
+						} `json:"project"`
+					} `json:"nodes"`
+				} `json:"projectItems"`
+			} `json:"resource"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(output, &resp); err != nil {
+		return fmt.Errorf("failed to find the project items of %s: %w", url, err)
+	}
+	items := map[string]string{}
+	for _, item := range resp.Data.Resource.ProjectItems.Nodes {
+		items[item.Project.ID] = item.ID
+	}
+
+	for _, u := range updates {
+		item, ok := items[u.projectID]
+		if !ok {
+			return fmt.Errorf("issue %s was not added to project '%s'", url, u.projectTitle)
+		}
+		flag := "-f"
+		if u.number {
+			flag = "-F"
+		}
+		args := []string{"api", "graphql", "-f", "query=" + updateFieldMutation,
+			"-f", "project=" + u.projectID, "-f", "item=" + item, "-f", "field=" + u.fieldID,
+			flag, fmt.Sprintf("value[%s]=%s", u.key, u.value)}
+		if _, err := c.run(ctx, runner.Command{Name: "gh", Args: args, Mutating: true}); err != nil {
+			return newError(commandKind(err), fmt.Errorf("failed to set field '%s' in project '%s': %s", u.fieldName, u.projectTitle, runner.Stderr(err)))
+		}
+	}
+	return nil
+}
+
+// suggest returns a "did you mean" hint with the candidates closest to value,
+// or "" when none is close.
+func suggest(value string, candidates []string) string {
+	limit := len(value)/3 + 1
+	var matches []string
+	for _, c := range candidates {
+		if d := distance(strings.ToLower(value), strings.ToLower(c)); d <= limit {
+			matches = append(matches, "'"+c+"'")
+		}
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+	return "; did you mean " + strings.Join(matches, " or ") + "?"
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,281 @@
+package mkissue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+func TestParseProjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Project
+		wantErr bool
+	}{
+		{
+			name:    "titles",
+			content: "projects: [Roadmap, \"Team Board\"]\nmilestone: v1",
+			want:    []Project{{Title: "Roadmap"}, {Title: "Team Board"}},
+		},
+		{
+			name:    "inline fields",
+			content: "projects: [{title: Roadmap, fields: {Status: Todo, Priority: P1, Iteration: \"@current\", Estimate: 3}}]\nmilestone: v1",
+			want: []Project{{Title: "Roadmap", Fields: map[string]string{
+				"Status": "Todo", "Priority": "P1", "Iteration": "@current", "Estimate": "3",
+			}}},
+		},
+		{
+			name: "block fields",
+			content: `projects:
+  - Team Board
+  - title: Roadmap
+    fields:
+      Status: In Progress # where it starts
+      Target: 2026-06-30
+milestone: v1`,
+			want: []Project{
+				{Title: "Team Board"},
+				{Title: "Roadmap", Fields: map[string]string{"Status": "In Progress", "Target": "2026-06-30"}},
+			},
+		},
+		{
+			name:    "missing title",
+			content: "projects: [{fields: {Status: Todo}}]",
+			wantErr: true,
+		},
+		{
+			name:    "list as field value",
+			content: "projects: [{title: Roadmap, fields: {Status: [Todo, Done]}}]",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata, _, err := parseIssueFile("---\ntitle: T\n" + tt.content + "\n---\nBody")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIssueFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrValidation) {
+					t.Errorf("parseIssueFile() error = %v, want a validation error", err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(metadata.Projects, tt.want) {
+				t.Errorf("Projects = %+v, want %+v", metadata.Projects, tt.want)
+			}
+			if metadata.Milestone == nil || metadata.Milestone.Title != "v1" {
+				t.Errorf("Milestone = %+v, fields after projects must still be parsed", metadata.Milestone)
+			}
+		})
+	}
+}
+
+// roadmap is the response to the project lookup of the "Roadmap" project.
+const roadmap = `{"data":{"repository":{"projectsV2":{"nodes":[]},"owner":{"projectsV2":{"nodes":[{
+  "id": "PVT_1", "title": "Roadmap",
+  "fields": {"nodes": [
+    {"id": "F_title", "name": "Title", "dataType": "TITLE"},
+    {"id": "F_status", "name": "Status", "dataType": "SINGLE_SELECT", "options": [{"id": "O_todo", "name": "Todo"}, {"id": "O_done", "name": "Done"}]},
+    {"id": "F_estimate", "name": "Estimate", "dataType": "NUMBER"},
+    {"id": "F_target", "name": "Target", "dataType": "DATE"},
+    {"id": "F_notes", "name": "Notes", "dataType": "TEXT"},
+    {"id": "F_iteration", "name": "Iteration", "dataType": "ITERATION", "configuration": {"iterations": [
+      {"id": "I_1", "title": "Sprint 1", "startDate": "2026-05-04", "duration": 14},
+      {"id": "I_2", "title": "Sprint 2", "startDate": "2026-05-18", "duration": 14}
+    ]}},
+    {}
+  ]}
+}]}}}}}`
+
+func lookupProject(title, stdout string) runner.Interaction {
+	return runner.Interaction{
+		Name:   "gh",
+		Args:   []string{"api", "graphql", "-f", "query=" + projectsQuery, "-F", "owner={owner}", "-F", "repo={repo}", "-f", "title=" + title},
+		Stdout: stdout,
+	}
+}
+
+func pinNow(t *testing.T, day string) {
+	t.Helper()
+	today, err := time.Parse(time.DateOnly, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = func() time.Time { return today }
+	t.Cleanup(func() { now = time.Now })
+}
+
+func TestResolveProjectFields(t *testing.T) {
+	pinNow(t, "2026-05-20")
+	c := newClient(testutil.Expect(t, lookupProject("roadmap", roadmap)))
+	got, err := c.resolveProjectFields(context.Background(), []Project{
+		{Title: "Team Board"},
+		{Title: "roadmap", Fields: map[string]string{
+			"Status": "todo", "Estimate": "3", "Target": "2026-06-30", "notes": "from spec", "Iteration": "@current",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("resolveProjectFields() error = %v", err)
+	}
+	update := func(field, name, key, value string) fieldUpdate {
+		return fieldUpdate{projectID: "PVT_1", projectTitle: "Roadmap", fieldID: field, fieldName: name, key: key, value: value}
+	}
+	estimate := update("F_estimate", "Estimate", "number", "3")
+	estimate.number = true
+	want := []fieldUpdate{
+		estimate,
+		update("F_iteration", "Iteration", "iterationId", "I_2"),
+		update("F_status", "Status", "singleSelectOptionId", "O_todo"),
+		update("F_target", "Target", "date", "2026-06-30"),
+		update("F_notes", "Notes", "text", "from spec"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveProjectFields() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestResolveProjectFieldsErrors(t *testing.T) {
+	pinNow(t, "2026-06-10")
+	tests := []struct {
+		name   string
+		fields map[string]string
+		want   []string
+	}{
+		{name: "unknown field", fields: map[string]string{"Stauts": "Todo"}, want: []string{"has no field 'Stauts'; did you mean 'Status'?"}},
+		{name: "unknown option", fields: map[string]string{"Status": "Doen"}, want: []string{"unknown option 'Doen'; did you mean 'Done'? (options: Todo, Done)"}},
+		{name: "invalid number", fields: map[string]string{"Estimate": "three"}, want: []string{"'three' is not a number"}},
+		{name: "invalid date", fields: map[string]string{"Target": "30/06/2026"}, want: []string{"not a date"}},
+		{name: "no current iteration", fields: map[string]string{"Iteration": "@current"}, want: []string{"there is no current iteration"}},
+		{name: "unknown iteration", fields: map[string]string{"Iteration": "Sprint 3"}, want: []string{"'Sprint 3'; did you mean 'Sprint 1' or 'Sprint 2'?"}},
+		{name: "unsupported type", fields: map[string]string{"Title": "x"}, want: []string{"fields of type TITLE can't be set"}},
+		{
+			name:   "all errors at once",
+			fields: map[string]string{"Status": "Doen", "Estimate": "three"},
+			want:   []string{"unknown option 'Doen'", "'three' is not a number"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newClient(testutil.Expect(t, lookupProject("Roadmap", roadmap)))
+			_, err := c.resolveProjectFields(context.Background(), []Project{{Title: "Roadmap", Fields: tt.fields}})
+			if !errors.Is(err, ErrValidation) {
+				t.Fatalf("resolveProjectFields() error = %v, want a validation error", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error = %q, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+
+	t.Run("unknown project", func(t *testing.T) {
+		c := newClient(testutil.Expect(t, lookupProject("Roadmp", roadmap)))
+		_, err := c.resolveProjectFields(context.Background(), []Project{{Title: "Roadmp", Fields: map[string]string{"Status": "Todo"}}})
+		if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "project 'Roadmp' not found; did you mean 'Roadmap'?") {
+			t.Errorf("resolveProjectFields() error = %v", err)
+		}
+	})
+}
+
+func TestSetProjectFields(t *testing.T) {
+	url := "https://github.com/owner/repo/issues/1"
+	items := runner.Interaction{
+		Name:   "gh",
+		Args:   []string{"api", "graphql", "-f", "query=" + itemsQuery, "-f", "url=" + url},
+		Stdout: `{"data":{"resource":{"projectItems":{"nodes":[{"id":"PVTI_9","project":{"id":"PVT_1"}}]}}}}`,
+	}
+	set := func(flag, value string) runner.Interaction {
+		return runner.Interaction{
+			Name: "gh",
+			Args: []string{"api", "graphql", "-f", "query=" + updateFieldMutation,
+				"-f", "project=PVT_1", "-f", "item=PVTI_9", "-f", "field=F", flag, value},
+			Stdout: `{}`,
+		}
+	}
+	c := newClient(testutil.Expect(t, items, set("-F", "value[number]=3"), set("-f", "value[singleSelectOptionId]=O_todo")))
+	err := c.setProjectFields(context.Background(), url, []fieldUpdate{
+		{projectID: "PVT_1", fieldID: "F", key: "number", value: "3", number: true},
+		{projectID: "PVT_1", fieldID: "F", key: "singleSelectOptionId", value: "O_todo"},
+	})
+	if err != nil {
+		t.Fatalf("setProjectFields() error = %v", err)
+	}
+
+	c = newClient(testutil.Expect(t, items))
+	err = c.setProjectFields(context.Background(), url, []fieldUpdate{{projectID: "PVT_2", projectTitle: "Other", key: "text", value: "x"}})
+	if err == nil || !strings.Contains(err.Error(), "was not added to project 'Other'") {
+		t.Errorf("setProjectFields() error = %v", err)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		value      string
+		candidates []string
+		want       string
+	}{
+		{value: "Stauts", candidates: []string{"Status", "Estimate"}, want: "; did you mean 'Status'?"},
+		{value: "priority", candidates: []string{"Priority"}, want: "; did you mean 'Priority'?"},
+		{value: "Owner", candidates: []string{"Status", "Estimate"}, want: ""},
+	}
+	for _, tt := range tests {
+		if got := suggest(tt.value, tt.candidates); got != tt.want {
+			t.Errorf("suggest(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCreateSetsProjectFields(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.issue.md")
+	content := "---\ntitle: Plan it\nprojects: [{title: Roadmap, fields: {Status: Done}}]\n---\nBody"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	url := "https://github.com/owner/repo/issues/3"
+	create := runner.Interaction{
+		Name:   "gh",
+		Args:   []string{"issue", "create", "--title", "Plan it", "--body-file", "-", "--project", "Roadmap"},
+		Stdin:  "Body",
+		Stdout: url + "\n",
+	}
+
+	r := testutil.Expect(t,
+		lookupProject("Roadmap", roadmap),
+		create,
+		runner.Interaction{
+			Name:   "gh",
+			Args:   []string{"api", "graphql", "-f", "query=" + itemsQuery, "-f", "url=" + url},
+			Stdout: `{"data":{"resource":{"projectItems":{"nodes":[{"id":"PVTI_3","project":{"id":"PVT_1"}}]}}}}`,
+		},
+		runner.Interaction{
+			Name: "gh",
+			Args: []string{"api", "graphql", "-f", "query=" + updateFieldMutation,
+				"-f", "project=PVT_1", "-f", "item=PVTI_3", "-f", "field=F_status", "-f", "value[singleSelectOptionId]=O_done"},
+			Stdout: `{}`,
+		},
+	)
+	if err := Create(context.Background(), file, Options{Runner: r}); err != nil {
+		t.Errorf("Create() error = %v", err)
+	}
+
+	// A typo fails the run before the issue is created
+	content = strings.Replace(content, "Done", "Dne", 1)
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r = testutil.Expect(t, lookupProject("Roadmap", roadmap))
+	if err := Create(context.Background(), file, Options{Runner: r}); !errors.Is(err, ErrValidation) {
+		t.Errorf("Create() error = %v, want a validation error", err)
+	}
+}
@@ -0,0 +1,39 @@
+package mkissue
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lakruzz/gh-utils/internal/secrets"
+)
+
+// checkSecrets scans the title, the body with its includes expanded and the
+// comments of the comments field for possible secrets. Findings fail the run
+// before anything is published, unless opts.AllowSecrets is set, in which case
+// they are only reported.
+func checkSecrets(opts Options, title, body string, comments []Comment) error {
+	scanner := opts.Secrets
+	if scanner == nil {
+		scanner = secrets.Default()
+	}
+	findings := scanner.Scan("title", title)
+	findings = append(findings, scanner.Scan("body", body)...)
+	for i, comment := range comments {
+		findings = append(findings, scanner.Scan(fmt.Sprintf("comments[%d]", i), comment.Body)...)
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	lines := make([]string, len(findings))
+	for i, f := range findings {
+		lines[i] = "  " + f.String()
+	}
+	report := strings.Join(lines, "\n")
+	if opts.AllowSecrets {
+		fmt.Fprintf(os.Stderr, "Warning: publishing possible secrets:\n%s\n", report)
+		return nil
+	}
+	return newError(ErrValidation, fmt.Errorf("possible secrets found; remove them, allow them in the secrets section of the config, or use --allow-secrets:\n%s", report))
+}
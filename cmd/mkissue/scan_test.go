@@ -0,0 +1,43 @@
+package mkissue
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+func TestCreateBlocksSecrets(t *testing.T) {
+	// Built at run time, so the source doesn't trip scanners
+	token := "ghp" + "_" + strings.Repeat("a1B2c3D4e5", 4)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.issue.md")
+	content := "---\ntitle: Leak\ncomments: [\"Retried with " + token + "\"]\n---\nLog:\n<!-- include: log.md -->"
+	writeFiles(t, dir, map[string]string{"a.issue.md": content, "log.md": "first\nAKIA" + "IOSFODNN7EXAMPLE"})
+
+	err := Create(context.Background(), file, Options{Runner: testutil.Expect(t)})
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("Create() error = %v, want a validation error", err)
+	}
+	for _, want := range []string{"body:3: AWS access key (AKIA********)", "comments[0]:1: GitHub token (ghp_********)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Create() error = %v, want it to report %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), token) {
+		t.Errorf("Create() error = %v, reveals the secret", err)
+	}
+
+	url := "https://github.com/owner/repo/issues/7"
+	r := testutil.Expect(t,
+		runner.Interaction{Name: "gh", Args: []string{"issue", "create", "--title", "Leak", "--body-file", "-"}, Stdin: "Log:\nfirst\nAKIA" + "IOSFODNN7EXAMPLE", Stdout: url},
+		runner.Interaction{Name: "gh", Args: []string{"issue", "comment", url, "--body-file", "-"}, Stdin: "Retried with " + token},
+	)
+	if err := Create(context.Background(), file, Options{Runner: r, AllowSecrets: true}); err != nil {
+		t.Errorf("Create() error = %v", err)
+	}
+}
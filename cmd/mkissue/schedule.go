@@ -0,0 +1,110 @@
+package mkissue
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lakruzz/gh-utils/internal/schedule"
+)
+
+// titlePlaceholder matches a placeholder of a title template, such as {{date}}.
+var titlePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z]+)\s*\}\}`)
+
+// parseSchedule parses the schedule and schedule_start fields of an issue
+// file; it returns nil when there is no schedule.
+func parseSchedule(expr, start string) (*schedule.Schedule, error) {
+	if expr == "" {
+		if start != "" {
+			return nil, errors.New("schedule_start needs a schedule")
+		}
+		return nil, nil
+	}
+	var from time.Time
+	if start != "" {
+		var err error
+		if from, err = schedule.ParseTime(start); err != nil {
+			return nil, fmt.Errorf("schedule_start: %w", err)
+		}
+	}
+	s, err := schedule.Parse(expr, from)
+	if err != nil {
+		return nil, fmt.Errorf("schedule: %w", err)
+	}
+	return s, nil
+}
+
+// Schedule returns the schedule of the frontmatter, or nil when it has none.
+func (f *Frontmatter) Schedule() (*schedule.Schedule, error) {
+	s, err := parseSchedule(f.String("schedule"), f.String("schedule_start"))
+	if err != nil {
+		return nil, newError(ErrValidation, err)
+	}
+	return s, nil
+}
+
+// RenderTitle replaces the placeholders of a title template with the values
+// of the occurrence at: {{date}} (2026-10-19), {{year}}, {{month}} (October),
+// {{week}} (the ISO week number) and {{iteration}}, the number of the
+// occurrence counted from the start of the schedule s. Other text in braces
+// is left as it is.
+func RenderTitle(title string, s *schedule.Schedule, at time.Time) (string, error) {
+	at = at.UTC()
+	var errs []error
+	rendered := titlePlaceholder.ReplaceAllStringFunc(title, func(placeholder string) string {
+		switch name := strings.ToLower(titlePlaceholder.FindStringSubmatch(placeholder)[1]); name {
+		case "date":
+			return at.Format(time.DateOnly)
+		case "year":
+			return strconv.Itoa(at.Year())
+		case "month":
+			return at.Month().String()
+		case "week":
+			_, week := at.ISOWeek()
+			return strconv.Itoa(week)
+		case "iteration":
+			if s == nil {
+				errs = append(errs, errors.New("{{iteration}} needs a schedule"))
+				return placeholder
+			}
+			if s.Start.IsZero() {
+				errs = append(errs, errors.New("{{iteration}} needs a schedule_start to count from"))
+				return placeholder
+			}
+			n, err := s.Iteration(at)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("{{iteration}}: %w", err))
+				return placeholder
+			}
+			return strconv.Itoa(n)
+		default:
+			return placeholder
+		}
+	})
+	return rendered, errors.Join(errs...)
+}
+
+// renderTitle renders the title template of a scheduled issue for the
+// occurrence in opts, or else the latest occurrence of its schedule. The
+// titles of other issues are taken as they are written.
+func renderTitle(metadata *IssueMetadata, opts Options) error {
+	if metadata.Schedule == nil && opts.Occurrence.IsZero() {
+		return nil
+	}
+	at := opts.Occurrence
+	if at.IsZero() {
+		at = time.Now()
+		if latest := metadata.Schedule.Latest(at); !latest.IsZero() {
+			at = latest
+		}
+	}
+	title, err := RenderTitle(metadata.Title, metadata.Schedule, at)
+	if err != nil {
+		return newError(ErrValidation, err)
+	}
+	metadata.Title = title
+	return nil
+}
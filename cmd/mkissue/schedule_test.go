@@ -0,0 +1,95 @@
+package mkissue
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+func TestRenderTitle(t *testing.T) {
+	s, err := parseSchedule("0 9 * * 1", "2026-10-05")
+	if err != nil {
+		t.Fatal(err)
+	}
+	occurrence := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	got, err := RenderTitle("Week {{week}} of {{ year }}: check {{iteration}} ({{date}}, {{month}}) for {{team}}", s, occurrence)
+	if want := "Week 43 of 2026: check 3 (2026-10-19, October) for {{team}}"; err != nil || got != want {
+		t.Errorf("RenderTitle() = %q, %v, want %q", got, err, want)
+	}
+
+	for _, tt := range []struct{ title, schedule, start string }{
+		{"Check {{iteration}}", "", ""},
+		{"Check {{iteration}}", "0 9 * * 1", ""},
+	} {
+		s, err := parseSchedule(tt.schedule, tt.start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := RenderTitle(tt.title, s, occurrence); err == nil {
+			t.Errorf("RenderTitle(%q) with schedule %q expected error", tt.title, tt.schedule)
+		}
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	for _, content := range []string{
+		"---\ntitle: T\nschedule: \"0 9 * *\"\n---\nBody",
+		"---\ntitle: T\nschedule: FREQ=WEEKLY\n---\nBody",
+		"---\ntitle: T\nschedule_start: 2026-01-05\n---\nBody",
+		"---\ntitle: T\nschedule: \"@weekly\"\nschedule_start: monday\n---\nBody",
+	} {
+		if _, _, err := parseIssueFile(content); !errors.Is(err, ErrValidation) {
+			t.Errorf("parseIssueFile(%q) error = %v, want a validation error", content, err)
+		}
+	}
+
+	metadata, _, err := parseIssueFile("---\ntitle: T\nschedule: FREQ=WEEKLY;BYDAY=MO\nschedule_start: 2026-01-05T09:00\n---\nBody")
+	if err != nil || metadata.Schedule == nil || !metadata.Schedule.Start.Equal(time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseIssueFile() = %+v, %v", metadata, err)
+	}
+}
+
+func TestCreateRendersTitle(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.issue.md")
+	writeFiles(t, dir, map[string]string{"a.issue.md": "---\ntitle: Retro {{date}}\nschedule: \"0 9 * * 5\"\n---\nNotes"})
+	r := testutil.Expect(t, runner.Interaction{
+		Name:   "gh",
+		Args:   []string{"issue", "create", "--title", "Retro 2026-10-16", "--body-file", "-"},
+		Stdin:  "Notes",
+		Stdout: "https://github.com/owner/repo/issues/9",
+	})
+	err := Create(context.Background(), file, Options{Runner: r, Occurrence: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Errorf("Create() error = %v", err)
+	}
+
+	writeFiles(t, dir, map[string]string{"a.issue.md": "---\ntitle: Retro {{iteration}}\nschedule: \"0 9 * * 5\"\n---\nNotes"})
+	err = Create(context.Background(), file, Options{Runner: testutil.Expect(t)})
+	if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "{{iteration}}") {
+		t.Errorf("Create() error = %v, want a validation error", err)
+	}
+}
+
+func TestCreateKeepsTitlesWithoutSchedule(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.issue.md")
+	for _, title := range []string{"Support {{name}} in templates", "Fix {{date}} in docs"} {
+		writeFiles(t, dir, map[string]string{"a.issue.md": "---\ntitle: " + title + "\n---\nNotes"})
+		r := testutil.Expect(t, runner.Interaction{
+			Name:   "gh",
+			Args:   []string{"issue", "create", "--title", title, "--body-file", "-"},
+			Stdin:  "Notes",
+			Stdout: "https://github.com/owner/repo/issues/9",
+		})
+		if err := Create(context.Background(), file, Options{Runner: r}); err != nil {
+			t.Errorf("Create() with title %q error = %v", title, err)
+		}
+	}
+}
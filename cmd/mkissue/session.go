@@ -0,0 +1,93 @@
+package mkissue
+
+import (
+	"context"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// Session exposes the machinery behind mkissue to other commands that work with
+// markdown files and frontmatter: reading files from a branch, gist or repo,
+// ensuring labels, and rolling back changes made on GitHub when a run fails.
+type Session struct {
+	c *client
+}
+
+// NewSession returns a Session that runs commands with r; nil uses runner.Default().
+func NewSession(r runner.Runner) *Session {
+	return &Session{c: newClient(r)}
+}
+
+// ReadFile reads file from the source described by opts (gist, repo, branch or local path).
+func (s *Session) ReadFile(ctx context.Context, file string, opts Options) ([]byte, error) {
+	return s.c.readSource(ctx, file, opts)
+}
+
+// EnsureLabels creates the labels that define a color or description and
+// don't exist yet. Created labels are recorded for rollback.
+func (s *Session) EnsureLabels(ctx context.Context, labels []Label) error {
+	return s.c.ensureLabels(ctx, labels)
+}
+
+// Run runs cmd and returns its stdout. Failures are classified with the
+// sentinel errors of this package.
+func (s *Session) Run(ctx context.Context, cmd runner.Command) ([]byte, error) {
+	output, err := s.c.run(ctx, cmd)
+	if err != nil {
+		return nil, newError(commandKind(err), err)
+	}
+	return output, nil
+}
+
+// Record adds a change made on GitHub to the journal, with the command that undoes it.
+func (s *Session) Record(description string, undo runner.Command) {
+	s.c.journal.record(description, undo)
+}
+
+// Fail rolls back the recorded changes (unless keepPartial is set) and returns
+// the error to report; see Options.KeepPartial.
+func (s *Session) Fail(ctx context.Context, err error, keepPartial bool) error {
+	return s.c.fail(ctx, err, keepPartial)
+}
+
+// ProjectFields are project field values resolved by ResolveProjectFields.
+type ProjectFields struct {
+	updates []fieldUpdate
+}
+
+// ResolveProjectFields looks up the fields the projects set, like the projects
+// of an issue file, so that unknown fields and options fail before anything
+// is changed on GitHub.
+func (s *Session) ResolveProjectFields(ctx context.Context, projects []Project) (*ProjectFields, error) {
+	updates, err := s.c.resolveProjectFields(ctx, projects)
+	if err != nil {
+		return nil, err
+	}
+	return &ProjectFields{updates: updates}, nil
+}
+
+// SetProjectFields sets the resolved fields on the items of the issue at url,
+// which must already be in the projects.
+func (s *Session) SetProjectFields(ctx context.Context, url string, fields *ProjectFields) error {
+	if fields == nil {
+		return nil
+	}
+	return s.c.setProjectFields(ctx, url, fields.updates)
+}
+
+// Milestones returns the open and closed milestones of the current repository.
+func (s *Session) Milestones(ctx context.Context) ([]Milestone, error) {
+	return s.c.listMilestones(ctx)
+}
+
+// CreateMilestone creates m and returns its number. The milestone is recorded
+// for rollback.
+func (s *Session) CreateMilestone(ctx context.Context, m Milestone) (int, error) {
+	return s.c.createMilestone(ctx, m)
+}
+
+// UpdateMilestone changes the existing milestone from to the values set in
+// to. The previous values are recorded for rollback.
+func (s *Session) UpdateMilestone(ctx context.Context, from, to Milestone) error {
+	return s.c.updateMilestone(ctx, from, to)
+}
@@ -0,0 +1,65 @@
+package mkissue
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+)
+
+// MaxBodySize is the most characters GitHub accepts in an issue body or comment.
+const MaxBodySize = 65536
+
+// bodyReserve is left free in each part of a split body for the navigation
+// links and the outbox marker.
+const bodyReserve = 1024
+
+// fitBody checks the size of the body and comments against MaxBodySize. An
+// oversized body is split into the issue body and the parts continued in
+// comments, stored in metadata.Continued, unless strict is set, in which case
+// the run fails before anything is created. Oversized comments always fail.
+func fitBody(metadata *IssueMetadata, body string, strict bool) (string, error) {
+	for i, comment := range metadata.Comments {
+		if n := utf8.RuneCountInString(comment.Body); n > MaxBodySize {
+			return "", newError(ErrValidation, fmt.Errorf("comment %d is %d characters, over GitHub's limit of %d", i+1, n, MaxBodySize))
+		}
+	}
+	n := utf8.RuneCountInString(body)
+	if n <= MaxBodySize-bodyReserve {
+		return body, nil
+	}
+	if strict {
+		return "", newError(ErrValidation, fmt.Errorf("the body is %d characters, over GitHub's limit of %d; shorten it or run without --strict-size to continue it in comments", n, MaxBodySize-bodyReserve))
+	}
+
+	parts := splitBody(body, MaxBodySize-bodyReserve)
+	metadata.Continued = parts[1:]
+	fmt.Printf("The body is %d characters; continuing it in %d comment(s)\n", n, len(metadata.Continued))
+	return fmt.Sprintf("%s\n\n---\n*Part 1 of %d. The description continues in the comments below.*", parts[0], len(parts)), nil
+}
+
+// postContinued posts the parts of an oversized body as numbered comments,
+// each linking to the start of the description and to the previous part.
+func (c *client) postContinued(ctx context.Context, url string, continued []string) error {
+	total := len(continued) + 1
+	previous := url
+	for i, text := range continued {
+		n := i + 2
+		nav := fmt.Sprintf("*Part %d of %d* · [Start](%s)", n, total, url)
+		if n > 2 && previous != url {
+			nav += fmt.Sprintf(" · [Part %d](%s)", n-1, previous)
+		}
+		if n < total {
+			text += fmt.Sprintf("\n\n---\n*Continued in part %d below.*", n+1)
+		}
+		fmt.Printf("Adding part %d of %d...\n", n, total)
+		commentURL, err := c.comment(ctx, url, nav+"\n\n"+text)
+		if err != nil {
+			return fmt.Errorf("failed to add part %d of the body: %w", n, err)
+		}
+		previous = commentURL
+		if previous == "" {
+			previous = url
+		}
+	}
+	return nil
+}
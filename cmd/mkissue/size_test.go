@@ -0,0 +1,57 @@
+package mkissue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+func TestCreateSplitsOversizedBody(t *testing.T) {
+	section := func(title string) string {
+		return "## " + title + "\n\n" + strings.TrimSpace(strings.Repeat("word ", 8000))
+	}
+	one, two, three := section("One"), section("Two"), section("Three")
+	file := filepath.Join(t.TempDir(), "a.issue.md")
+	content := "---\ntitle: Big\ncomments: [Thanks]\n---\n" + one + "\n" + two + "\n" + three
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	url := "https://github.com/owner/repo/issues/6"
+	comment := func(body, stdout string) runner.Interaction {
+		return runner.Interaction{Name: "gh", Args: []string{"issue", "comment", url, "--body-file", "-"}, Stdin: body, Stdout: stdout}
+	}
+
+	r := testutil.Expect(t,
+		runner.Interaction{
+			Name:   "gh",
+			Args:   []string{"issue", "create", "--title", "Big", "--body-file", "-"},
+			Stdin:  one + "\n\n---\n*Part 1 of 3. The description continues in the comments below.*",
+			Stdout: url,
+		},
+		comment("*Part 2 of 3* · [Start]("+url+")\n\n"+two+"\n\n---\n*Continued in part 3 below.*", url+"#issuecomment-21"),
+		comment("*Part 3 of 3* · [Start]("+url+") · [Part 2]("+url+"#issuecomment-21)\n\n"+three, url+"#issuecomment-22"),
+		comment("Thanks", url+"#issuecomment-23"),
+	)
+	if err := Create(context.Background(), file, Options{Runner: r}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	err := Create(context.Background(), file, Options{Runner: testutil.Expect(t), StrictSize: true})
+	if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "over GitHub's limit") {
+		t.Errorf("Create() error = %v, want a validation error", err)
+	}
+}
+
+func TestFitBodyRejectsOversizedComments(t *testing.T) {
+	metadata := &IssueMetadata{Comments: []Comment{{Body: "ok"}, {Body: strings.Repeat("x", MaxBodySize+1)}}}
+	_, err := fitBody(metadata, "Body", false)
+	if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "comment 2 is") {
+		t.Errorf("fitBody() error = %v, want a validation error", err)
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/cmd/mkpr"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prFile        string
+	prBranch      string
+	prGist        string
+	prRepo        string
+	prKeepPartial bool
+)
+
+var mkprCmd = &cobra.Command{
+	Use:   "mkpr",
+	Short: "Create a GitHub pull request from a markdown file",
+	Long: `Create a GitHub pull request from a markdown file with frontmatter support.
+The file uses the same format as mkissue files, with these frontmatter fields:
+title, base, head, draft, reviewers, team_reviewers, labels, assign,
+milestone, projects and closes.
+
+When 'closes' is not given, it is filled in from the issue number at the
+start of the head branch name (e.g. 42-add-feature closes #42).
+
+Usage variants:
+  utils mkpr --file <file> [--branch <branch>] [--repo <owner/repo>]
+  utils mkpr --file <file> [--gist <gist-id>]
+
+Rules:
+  --file is always required
+  --branch is optional (defaults to the repo's default branch when used with --repo)
+  --gist and --repo are mutually exclusive
+  --branch is not valid with --gist`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if err := validateSource(prBranch, prGist, prRepo); err != nil {
+			return err
+		}
+		repo, err := hostRepo(prRepo)
+		if err != nil {
+			return err
+		}
+		// Create the pull request from the file read from the local path, branch, gist or repo
+		return mkpr.Create(cmd.Context(), prFile, mkissue.Options{
+			Branch:      prBranch,
+			Gist:        prGist,
+			Repo:        repo,
+			Runner:      commandRunner,
+			KeepPartial: prKeepPartial,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mkprCmd)
+
+	// Define flags for mkpr command
+	mkprCmd.Flags().StringVarP(&prFile, "file", "f", "", "Path to the markdown file containing pull request content (required)")
+	mkprCmd.Flags().StringVarP(&prBranch, "branch", "b", "", "Branch name to get the file from (optional)")
+	mkprCmd.Flags().StringVarP(&prGist, "gist", "g", "", "Gist ID to get the file from (optional)")
+	mkprCmd.Flags().StringVarP(&prRepo, "repo", "r", "", "Repository to get the file from, in owner/repo or host/owner/repo format (optional)")
+	mkprCmd.Flags().BoolVar(&prKeepPartial, "keep-partial", false, "Keep labels and other changes made on GitHub when the run fails, instead of rolling them back")
+	_ = mkprCmd.MarkFlagRequired("file")
+}
@@ -0,0 +1,222 @@
+// Package mkpr creates GitHub pull requests from markdown files with frontmatter.
+// It shares the file sources and frontmatter rules of the mkissue package.
+package mkpr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// PRMetadata holds the frontmatter fields of a pull request file.
+type PRMetadata struct {
+	Title         string
+	Base          string
+	Head          string
+	Draft         bool
+	Reviewers     []string
+	TeamReviewers []string
+	Labels        []mkissue.Label
+	Assignees     []string
+	Milestone     string
+	Projects      []string
+	// Closes lists the issue numbers the pull request closes.
+	Closes []string
+	// closesSet is true when the file sets closes explicitly, even to an empty list.
+	closesSet bool
+}
+
+// issueNumberPattern matches the issue number at the start of a branch name,
+// the same convention as the 'issue-number' git alias.
+var issueNumberPattern = regexp.MustCompile(`^[0-9]+`)
+
+// teamPattern matches a team reviewer in org/team format.
+var teamPattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+/[a-zA-Z0-9._-]+$`)
+
+// Create reads a pull request file from the source described by opts and
+// creates the pull request.
+func Create(ctx context.Context, prFile string, opts mkissue.Options) error {
+	s := mkissue.NewSession(opts.Runner)
+
+	content, err := s.ReadFile(ctx, prFile, opts)
+	if err != nil {
+		return err
+	}
+
+	metadata, body, err := parsePRFile(string(content))
+	if err != nil {
+		return err
+	}
+	if err := validate(metadata); err != nil {
+		return err
+	}
+
+	// Fill in closes from the issue number in the head branch
+	if !metadata.closesSet {
+		head := metadata.Head
+		if head == "" {
+			head = currentBranch(ctx, s)
+		}
+		if number := IssueNumber(head); number != "" {
+			metadata.Closes = []string{number}
+		}
+	}
+	body = addCloses(body, metadata.Closes)
+
+	if err := s.EnsureLabels(ctx, metadata.Labels); err != nil {
+		return s.Fail(ctx, err, opts.KeepPartial)
+	}
+
+	if err := createPR(ctx, s, metadata, body); err != nil {
+		return s.Fail(ctx, fmt.Errorf("error creating pull request: %w", err), opts.KeepPartial)
+	}
+
+	fmt.Println("Pull request created successfully!")
+	return nil
+}
+
+func parsePRFile(content string) (*PRMetadata, string, error) {
+	fm, body, err := mkissue.ParseFrontmatter(content)
+	if err != nil {
+		return nil, "", err
+	}
+
+	draft, err := fm.Bool("draft")
+	if err != nil {
+		return nil, "", err
+	}
+
+	metadata := &PRMetadata{
+		Title:         fm.String("title"),
+		Base:          fm.String("base"),
+		Head:          fm.String("head"),
+		Draft:         draft,
+		Reviewers:     fm.List("reviewers"),
+		TeamReviewers: fm.List("team_reviewers"),
+		Labels:        fm.Labels("labels"),
+		Assignees:     fm.List("assign"),
+		Milestone:     fm.String("milestone"),
+		Projects:      fm.List("projects"),
+		closesSet:     fm.Has("closes"),
+	}
+	for _, closes := range fm.List("closes") {
+		metadata.Closes = append(metadata.Closes, strings.TrimPrefix(closes, "#"))
+	}
+	return metadata, body, nil
+}
+
+func validate(metadata *PRMetadata) error {
+	if metadata.Title == "" {
+		return &mkissue.Error{Kind: mkissue.ErrValidation, Err: errors.New("'title' is required in frontmatter")}
+	}
+	for _, team := range metadata.TeamReviewers {
+		if !teamPattern.MatchString(team) {
+			return &mkissue.Error{Kind: mkissue.ErrValidation, Err: fmt.Errorf("invalid team reviewer '%s': must be 'org/team'", team)}
+		}
+	}
+	for _, number := range metadata.Closes {
+		if number == "" || strings.Trim(number, "0123456789") != "" {
+			return &mkissue.Error{Kind: mkissue.ErrValidation, Err: fmt.Errorf("invalid issue number '%s' in 'closes'", number)}
+		}
+	}
+	return nil
+}
+
+// IssueNumber returns the issue number a branch name starts with, or "" when
+// the branch doesn't follow the '<number>-...' convention.
+func IssueNumber(branch string) string {
+	return issueNumberPattern.FindString(branch)
+}
+
+// currentBranch returns the checked out branch, or "" when it can't be determined.
+func currentBranch(ctx context.Context, s *mkissue.Session) string {
+	output, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"rev-parse", "--abbrev-ref", "HEAD"}})
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// addCloses appends a 'Closes #N' line for every issue the body doesn't
+// already close with one of GitHub's closing keywords.
+func addCloses(body string, closes []string) string {
+	var missing []string
+	for _, number := range closes {
+		keyword := regexp.MustCompile(`(?i)\b(close[sd]?|fix(e[sd])?|resolve[sd]?)\s+#` + regexp.QuoteMeta(number) + `\b`)
+		if !keyword.MatchString(body) {
+			missing = append(missing, "Closes #"+number)
+		}
+	}
+	if len(missing) == 0 {
+		return body
+	}
+	if body == "" {
+		return strings.Join(missing, "\n")
+	}
+	return body + "\n\n" + strings.Join(missing, "\n")
+}
+
+func createPR(ctx context.Context, s *mkissue.Session, metadata *PRMetadata, body string) error {
+	args := []string{"pr", "create", "--title", metadata.Title, "--body-file", "-"}
+
+	if metadata.Base != "" {
+		args = append(args, "--base", metadata.Base)
+	}
+	if metadata.Head != "" {
+		args = append(args, "--head", metadata.Head)
+	}
+	if metadata.Draft {
+		args = append(args, "--draft")
+	}
+
+	// Add reviewers; gh accepts teams as org/team
+	for _, reviewer := range append(append([]string{}, metadata.Reviewers...), metadata.TeamReviewers...) {
+		args = append(args, "--reviewer", reviewer)
+	}
+
+	// Add assignees
+	for _, assignee := range metadata.Assignees {
+		if assignee == "me" {
+			args = append(args, "--assignee", "@me")
+		} else {
+			args = append(args, "--assignee", assignee)
+		}
+	}
+
+	// Add labels
+	for _, label := range metadata.Labels {
+		args = append(args, "--label", label.Name)
+	}
+
+	// Add milestone
+	if metadata.Milestone != "" {
+		args = append(args, "--milestone", metadata.Milestone)
+	}
+
+	// Add projects
+	for _, project := range metadata.Projects {
+		args = append(args, "--project", project)
+	}
+
+	fmt.Println("Creating pull request...")
+	output, err := s.Run(ctx, runner.Command{Name: "gh", Args: args, Stdin: []byte(body), Mutating: true})
+	if err != nil {
+		return fmt.Errorf("gh command failed: %w", err)
+	}
+	fmt.Print(string(output))
+
+	url := strings.TrimSpace(string(output))
+	if i := strings.LastIndex(url, "\n"); i >= 0 {
+		url = url[i+1:]
+	}
+	s.Record(fmt.Sprintf("created pull request %s", url), runner.Command{
+		Name: "gh",
+		Args: []string{"pr", "close", url},
+	})
+	return nil
+}
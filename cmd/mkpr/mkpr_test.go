@@ -0,0 +1,197 @@
+package mkpr
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+func TestParsePRFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *PRMetadata
+		wantErr bool
+	}{
+		{
+			name: "all fields",
+			content: `---
+title: "My PR"
+base: main
+head: 42-my-feature
+draft: true
+reviewers: [alice, "@bob"]
+team_reviewers:
+  - org/team
+labels:
+  - name: bug
+assign: [me]
+milestone: v1
+projects: [Roadmap]
+closes: ["#7", 8]
+---
+Body`,
+			want: &PRMetadata{
+				Title:         "My PR",
+				Base:          "main",
+				Head:          "42-my-feature",
+				Draft:         true,
+				Reviewers:     []string{"alice", "bob"},
+				TeamReviewers: []string{"org/team"},
+				Labels:        []mkissue.Label{{Name: "bug"}},
+				Assignees:     []string{"me"},
+				Milestone:     "v1",
+				Projects:      []string{"Roadmap"},
+				Closes:        []string{"7", "8"},
+				closesSet:     true,
+			},
+		},
+		{
+			name: "minimal",
+			content: `---
+title: Minimal
+---
+`,
+			want: &PRMetadata{Title: "Minimal"},
+		},
+		{
+			name: "invalid draft",
+			content: `---
+title: Bad draft
+draft: maybe
+---
+`,
+			wantErr: true,
+		},
+		{
+			name:    "no frontmatter",
+			content: "Just a body",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := parsePRFile(tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePRFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePRFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata *PRMetadata
+		wantErr  bool
+	}{
+		{"valid", &PRMetadata{Title: "T", TeamReviewers: []string{"org/team"}, Closes: []string{"12"}}, false},
+		{"missing title", &PRMetadata{}, true},
+		{"team without org", &PRMetadata{Title: "T", TeamReviewers: []string{"team"}}, true},
+		{"closes not a number", &PRMetadata{Title: "T", Closes: []string{"abc"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(tt.metadata)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, mkissue.ErrValidation) {
+				t.Errorf("validate() error = %v, want ErrValidation", err)
+			}
+		})
+	}
+}
+
+func TestIssueNumber(t *testing.T) {
+	tests := []struct {
+		branch string
+		want   string
+	}{
+		{"42-add-feature", "42"},
+		{"7", "7"},
+		{"main", ""},
+		{"feature/42-x", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			if got := IssueNumber(tt.branch); got != tt.want {
+				t.Errorf("IssueNumber(%q) = %q, want %q", tt.branch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddCloses(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		closes []string
+		want   string
+	}{
+		{"appends", "Body", []string{"42"}, "Body\n\nCloses #42"},
+		{"empty body", "", []string{"42"}, "Closes #42"},
+		{"already closed", "Fixes #42 for real", []string{"42"}, "Fixes #42 for real"},
+		{"other issue mentioned", "See #420", []string{"42"}, "See #420\n\nCloses #42"},
+		{"nothing to close", "Body", nil, "Body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := addCloses(tt.body, tt.closes); got != tt.want {
+				t.Errorf("addCloses() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	prFile := filepath.Join("testdata", "feature.pr.md")
+	currentBranch := runner.Interaction{Name: "git", Args: []string{"rev-parse", "--abbrev-ref", "HEAD"}, Stdout: "42-add-mkpr\n"}
+	prCreate := runner.Interaction{
+		Name: "gh",
+		Args: []string{"pr", "create", "--title", "Add mkpr command", "--body-file", "-", "--base", "main", "--draft",
+			"--reviewer", "lakruzz", "--reviewer", "lakruzz/maintainers", "--assignee", "@me",
+			"--label", "enhancement", "--milestone", "v1.0"},
+		Stdin:  "## What\n\nCreate pull requests from markdown files.\n\nCloses #42",
+		Stdout: "https://github.com/lakruzz/gh-utils/pull/43\n",
+	}
+	failedCreate := prCreate
+	failedCreate.Stdout = ""
+	failedCreate.Stderr = "pull request create failed: GraphQL: No commits between main and 42-add-mkpr"
+	failedCreate.ExitCode = 1
+
+	tests := []struct {
+		name         string
+		interactions []runner.Interaction
+		wantErr      bool
+	}{
+		{"creates the pull request", []runner.Interaction{currentBranch, prCreate}, false},
+		{"reports gh failures", []runner.Interaction{currentBranch, failedCreate}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := runner.NewReplayer(tt.interactions...)
+			err := Create(context.Background(), prFile, mkissue.Options{Runner: r})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := r.Verify(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"github.com/lakruzz/gh-utils/cmd/mkrelease"
+	"github.com/spf13/cobra"
+)
+
+var (
+	releaseNotes       string
+	releasePrerelease  string
+	releaseBump        string
+	releaseRemote      string
+	releaseDryRun      bool
+	releaseKeepPartial bool
+)
+
+var mkreleaseCmd = &cobra.Command{
+	Use:   "mkrelease",
+	Short: "Tag and create a GitHub release with the next semantic version",
+	Long: `Compute the next semantic version, tag and push it and create a GitHub
+release from a markdown notes file.
+
+The version is bumped from the latest release tag using the conventional
+commit messages since that tag (feat: minor, fix: patch, '!' or
+'BREAKING CHANGE:' major) and the labels of the merged pull requests
+(e.g. breaking, enhancement, bug). --bump overrides the computed bump.
+
+The notes file may have frontmatter with these fields:
+title, draft, latest and assets (paths relative to the notes file).
+
+--prerelease alone creates an "rc" prerelease; give another identifier as
+--prerelease=<identifier> (letters, digits and hyphens), since a separate
+word would be read as an argument.
+
+Usage:
+  utils mkrelease --notes <file> [--prerelease[=<identifier>]] [--bump major|minor|patch] [--dry-run]`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		_, err := mkrelease.Create(cmd.Context(), mkrelease.Options{
+			NotesFile:   releaseNotes,
+			Prerelease:  releasePrerelease,
+			Bump:        releaseBump,
+			Remote:      releaseRemote,
+			DryRun:      releaseDryRun,
+			KeepPartial: releaseKeepPartial,
+			Runner:      commandRunner,
+		})
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mkreleaseCmd)
+
+	// Define flags for mkrelease command
+	mkreleaseCmd.Flags().StringVarP(&releaseNotes, "notes", "n", "", "Path to the markdown file containing the release notes (required)")
+	mkreleaseCmd.Flags().StringVar(&releasePrerelease, "prerelease", "", "Create a prerelease, as --prerelease=<identifier> ('rc' when given without a value)")
+	mkreleaseCmd.Flags().Lookup("prerelease").NoOptDefVal = "rc"
+	mkreleaseCmd.Flags().StringVar(&releaseBump, "bump", "", "Override the computed bump: major, minor or patch (optional)")
+	mkreleaseCmd.Flags().StringVar(&releaseRemote, "remote", "origin", "Remote to push the release tag to")
+	mkreleaseCmd.Flags().BoolVar(&releaseDryRun, "dry-run", false, "Only print the next version")
+	mkreleaseCmd.Flags().BoolVar(&releaseKeepPartial, "keep-partial", false, "Keep the pushed tag when creating the release fails, instead of rolling it back")
+	_ = mkreleaseCmd.MarkFlagRequired("notes")
+}
@@ -0,0 +1,297 @@
+// Package mkrelease creates GitHub releases. It computes the next semantic
+// version from the existing tags and the changes since the latest release,
+// then tags, pushes and publishes the release from a notes file.
+package mkrelease
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// Options control how the release is computed and created.
+type Options struct {
+	// NotesFile is the markdown file with the release notes and optional frontmatter.
+	NotesFile string
+	// Prerelease is the prerelease identifier (e.g. "rc"); empty creates a release.
+	Prerelease string
+	// Bump overrides the bump computed from commits and labels.
+	Bump string
+	// Remote is the git remote the tag is pushed to.
+	Remote string
+	// DryRun only computes and prints the next version.
+	DryRun bool
+	// KeepPartial leaves a pushed tag in place when creating the release fails.
+	KeepPartial bool
+	// Runner executes every gh and git invocation; nil uses runner.Default().
+	Runner runner.Runner
+}
+
+// Notes is the content of a release notes file.
+type Notes struct {
+	Title  string
+	Draft  bool
+	Latest *bool
+	Assets []string
+	Body   string
+}
+
+// Create computes the next version, tags and pushes it and creates the release.
+// It returns the released version.
+func Create(ctx context.Context, opts Options) (Version, error) {
+	if opts.Remote == "" {
+		opts.Remote = "origin"
+	}
+	s := mkissue.NewSession(opts.Runner)
+
+	notes, err := readNotes(opts.NotesFile)
+	if err != nil {
+		return Version{}, err
+	}
+
+	next, bump, err := plan(ctx, s, opts)
+	if err != nil {
+		return Version{}, err
+	}
+	fmt.Printf("Next version: %s (%s)\n", next, bump)
+	if opts.DryRun {
+		return next, nil
+	}
+
+	tag := next.String()
+	if err := createTag(ctx, s, tag, opts.Remote); err != nil {
+		return next, s.Fail(ctx, err, opts.KeepPartial)
+	}
+	if err := createRelease(ctx, s, tag, next, notes); err != nil {
+		return next, s.Fail(ctx, fmt.Errorf("error creating release: %w", err), opts.KeepPartial)
+	}
+
+	fmt.Printf("Release %s created successfully!\n", tag)
+	return next, nil
+}
+
+// NextVersion computes the version the next release would get.
+func NextVersion(ctx context.Context, opts Options) (Version, Bump, error) {
+	return plan(ctx, mkissue.NewSession(opts.Runner), opts)
+}
+
+// prereleasePattern matches a prerelease identifier, e.g. "rc" or "beta-2".
+var prereleasePattern = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+
+func plan(ctx context.Context, s *mkissue.Session, opts Options) (Version, Bump, error) {
+	if opts.Prerelease != "" && !prereleasePattern.MatchString(opts.Prerelease) {
+		return Version{}, BumpNone, &mkissue.Error{Kind: mkissue.ErrValidation, Err: fmt.Errorf("invalid prerelease identifier '%s': use letters, digits and hyphens", opts.Prerelease)}
+	}
+	if opts.Remote == "" {
+		opts.Remote = "origin"
+	}
+	// Tags pushed from other clones count too, or their versions are reused
+	if _, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"fetch", "--quiet", "--tags", opts.Remote}}); err != nil {
+		return Version{}, BumpNone, fmt.Errorf("failed to fetch tags from '%s': %w", opts.Remote, err)
+	}
+	output, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"tag", "--list"}})
+	if err != nil {
+		return Version{}, BumpNone, fmt.Errorf("failed to list tags: %w", err)
+	}
+	versions := Versions(strings.Split(string(output), "\n"))
+
+	bump := BumpNone
+	if opts.Bump != "" {
+		if bump, err = ParseBump(opts.Bump); err != nil {
+			return Version{}, BumpNone, &mkissue.Error{Kind: mkissue.ErrValidation, Err: err}
+		}
+	} else {
+		latest, released := LatestRelease(versions)
+		if bump, err = changesBump(ctx, s, latest, released); err != nil {
+			return Version{}, BumpNone, err
+		}
+	}
+
+	if bump == BumpNone {
+		// Releasing without a signal still needs a new version
+		bump = BumpPatch
+	}
+	next := Next(versions, bump, opts.Prerelease)
+	for _, v := range versions {
+		if v.Compare(next) == 0 {
+			return Version{}, BumpNone, &mkissue.Error{Kind: mkissue.ErrValidation, Err: fmt.Errorf("tag '%s' already exists", v)}
+		}
+	}
+	return next, bump, nil
+}
+
+// changesBump returns the most significant bump signalled by the conventional
+// commit messages and the pull request labels since the latest release.
+func changesBump(ctx context.Context, s *mkissue.Session, latest Version, released bool) (Bump, error) {
+	commits, err := commitsSince(ctx, s, latest, released)
+	if err != nil {
+		return BumpNone, err
+	}
+
+	bump := BumpNone
+	seen := map[string]bool{}
+	for _, c := range commits {
+		if b := CommitBump(c); b > bump {
+			bump = b
+		}
+		number := PullRequestNumber(c)
+		if number == "" || seen[number] {
+			continue
+		}
+		seen[number] = true
+		labels, err := s.Run(ctx, runner.Command{
+			Name: "gh",
+			Args: []string{"pr", "view", number, "--json", "labels", "--jq", ".labels[].name"},
+		})
+		if err != nil {
+			return BumpNone, fmt.Errorf("failed to read labels of pull request #%s: %w", number, err)
+		}
+		if b := LabelBump(strings.Split(string(labels), "\n")); b > bump {
+			bump = b
+		}
+	}
+	return bump, nil
+}
+
+// commitsSince lists the commits after the latest release, or all commits when
+// nothing was released yet.
+func commitsSince(ctx context.Context, s *mkissue.Session, latest Version, released bool) ([]Commit, error) {
+	revision := "HEAD"
+	if released {
+		revision = latest.String() + "..HEAD"
+	}
+	return Log(ctx, s, revision)
+}
+
+// Log lists the commits in the revision range, newest first.
+func Log(ctx context.Context, s *mkissue.Session, revision string) ([]Commit, error) {
+	output, err := s.Run(ctx, runner.Command{
+		Name: "git",
+		Args: []string{"log", "--format=%H%x1f%s%x1f%b%x1e", revision},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		commits = append(commits, Commit{SHA: fields[0], Subject: fields[1], Body: strings.TrimSpace(fields[2])})
+	}
+	return commits, nil
+}
+
+// readNotes reads the release notes file. The frontmatter is optional and may
+// set title, draft, latest and assets; asset paths are relative to the file.
+func readNotes(path string) (*Notes, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &mkissue.Error{Kind: mkissue.ErrSourceNotFound, Err: fmt.Errorf("file '%s' not found: %w", path, err)}
+	}
+	if !strings.HasPrefix(strings.TrimSpace(string(content)), "---") {
+		return &Notes{Body: strings.TrimSpace(string(content))}, nil
+	}
+
+	fm, body, err := mkissue.ParseFrontmatter(string(content))
+	if err != nil {
+		return nil, err
+	}
+	notes := &Notes{Title: fm.String("title"), Body: body}
+	if notes.Draft, err = fm.Bool("draft"); err != nil {
+		return nil, err
+	}
+	if fm.Has("latest") {
+		latest, err := fm.Bool("latest")
+		if err != nil {
+			return nil, err
+		}
+		notes.Latest = &latest
+	}
+	for _, asset := range fm.List("assets") {
+		// gh accepts 'path#display label'; only the path is resolved
+		file, label, _ := strings.Cut(asset, "#")
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(path), file)
+		}
+		if _, err := os.Stat(file); err != nil {
+			return nil, &mkissue.Error{Kind: mkissue.ErrValidation, Err: fmt.Errorf("asset '%s' not found: %w", file, err)}
+		}
+		if label != "" {
+			file += "#" + label
+		}
+		notes.Assets = append(notes.Assets, file)
+	}
+	return notes, nil
+}
+
+// createTag creates an annotated tag at HEAD, verifies it and pushes only that tag.
+func createTag(ctx context.Context, s *mkissue.Session, tag, remote string) error {
+	if _, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"tag", "-a", tag, "-m", "Release " + tag}}); err != nil {
+		return fmt.Errorf("failed to create tag '%s': %w", tag, err)
+	}
+	s.Record(fmt.Sprintf("created tag '%s'", tag), runner.Command{Name: "git", Args: []string{"tag", "-d", tag}})
+
+	tagged, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"rev-parse", tag + "^{commit}"}})
+	if err != nil {
+		return fmt.Errorf("failed to verify tag '%s': %w", tag, err)
+	}
+	head, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"rev-parse", "HEAD"}})
+	if err != nil {
+		return fmt.Errorf("failed to verify tag '%s': %w", tag, err)
+	}
+	if strings.TrimSpace(string(tagged)) != strings.TrimSpace(string(head)) {
+		return errors.New("tag '" + tag + "' does not point at HEAD")
+	}
+
+	ref := "refs/tags/" + tag
+	if _, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"push", remote, ref}, Mutating: true}); err != nil {
+		return fmt.Errorf("failed to push tag '%s': %w", tag, err)
+	}
+	s.Record(fmt.Sprintf("pushed tag '%s' to %s", tag, remote), runner.Command{
+		Name: "git",
+		Args: []string{"push", remote, ":" + ref},
+	})
+	return nil
+}
+
+func createRelease(ctx context.Context, s *mkissue.Session, tag string, version Version, notes *Notes) error {
+	title := notes.Title
+	if title == "" {
+		title = tag
+	}
+	args := []string{"release", "create", tag, "--verify-tag", "--title", title, "--notes-file", "-"}
+	if notes.Draft {
+		args = append(args, "--draft")
+	}
+	if version.IsPrerelease() {
+		args = append(args, "--prerelease")
+	}
+	switch {
+	case notes.Latest != nil:
+		args = append(args, fmt.Sprintf("--latest=%t", *notes.Latest))
+	case !version.IsPrerelease():
+		args = append(args, "--latest")
+	}
+	args = append(args, notes.Assets...)
+
+	output, err := s.Run(ctx, runner.Command{Name: "gh", Args: args, Stdin: []byte(notes.Body), Mutating: true})
+	if err != nil {
+		return fmt.Errorf("gh command failed: %w", err)
+	}
+	fmt.Print(string(output))
+	s.Record(fmt.Sprintf("created release '%s'", tag), runner.Command{
+		Name: "gh",
+		Args: []string{"release", "delete", tag, "--yes"},
+	})
+	return nil
+}
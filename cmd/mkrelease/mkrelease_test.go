@@ -0,0 +1,222 @@
+package mkrelease
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+// gitRepo creates a temporary git repository with one commit per message,
+// tagging a commit when its message has a tag in tags, pushes them to its
+// remote and changes into it.
+func gitRepo(t *testing.T, commits []string, tags map[int]string) *testutil.Repo {
+	t.Helper()
+	repo, _ := testutil.NewRepo(t, 0)
+	for i, message := range commits {
+		repo.Git("commit", "--quiet", "--allow-empty", "-m", message)
+		if tag, ok := tags[i]; ok {
+			repo.Git("tag", "-a", tag, "-m", "Release "+tag)
+		}
+	}
+	repo.Git("push", "--quiet", "--follow-tags", "origin", "main")
+	return repo
+}
+
+func notesFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "notes.md")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNextVersionFromGitRepo(t *testing.T) {
+	tests := []struct {
+		name    string
+		commits []string
+		tags    map[int]string
+		opts    Options
+		want    string
+	}{
+		{
+			name:    "feature since release",
+			commits: []string{"chore: init", "fix: old fix", "feat: new command"},
+			tags:    map[int]string{1: "v1.0.0"},
+			want:    "v1.1.0",
+		},
+		{
+			name:    "only commits before release count",
+			commits: []string{"feat!: rewrite", "fix: typo"},
+			tags:    map[int]string{0: "v1.0.0"},
+			want:    "v1.0.1",
+		},
+		{
+			name:    "breaking change footer",
+			commits: []string{"chore: init", "feat: config\n\nBREAKING CHANGE: moved the config file"},
+			tags:    map[int]string{0: "v1.4.2"},
+			want:    "v2.0.0",
+		},
+		{
+			name:    "prerelease continues counter",
+			commits: []string{"chore: init", "feat: one", "fix: two"},
+			tags:    map[int]string{0: "v0.3.0", 1: "v0.4.0-rc.1"},
+			opts:    Options{Prerelease: "rc"},
+			want:    "v0.4.0-rc.2",
+		},
+		{
+			name:    "bump override",
+			commits: []string{"chore: init", "docs: readme"},
+			tags:    map[int]string{0: "v1.0.0"},
+			opts:    Options{Bump: "major"},
+			want:    "v2.0.0",
+		},
+		{
+			name:    "no tags yet",
+			commits: []string{"feat: first feature"},
+			want:    "0.1.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitRepo(t, tt.commits, tt.tags)
+			tt.opts.Runner = runner.Exec{}
+			got, _, err := NextVersion(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatalf("NextVersion() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("NextVersion() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextVersionFetchesTags(t *testing.T) {
+	repo := gitRepo(t, []string{"chore: init", "fix: typo"}, map[int]string{0: "v1.0.0"})
+	// Another clone released v1.0.1 already
+	repo.Git("tag", "v1.0.1")
+	repo.Git("push", "--quiet", "origin", "v1.0.1")
+	repo.Git("tag", "-d", "v1.0.1")
+
+	got, _, err := NextVersion(context.Background(), Options{Runner: runner.Exec{}})
+	if err != nil {
+		t.Fatalf("NextVersion() error = %v", err)
+	}
+	if got.String() != "v1.0.2" {
+		t.Errorf("NextVersion() = %s, want v1.0.2", got)
+	}
+}
+
+func TestNextVersionRejectsInvalidPrerelease(t *testing.T) {
+	for _, pre := range []string{"rc.1", "beta+build", "rc 1"} {
+		_, _, err := NextVersion(context.Background(), Options{Prerelease: pre, Runner: testutil.Expect(t)})
+		if !errors.Is(err, mkissue.ErrValidation) {
+			t.Errorf("NextVersion(--prerelease=%s) error = %v, want validation error", pre, err)
+		}
+	}
+}
+
+func TestCreateDryRunLeavesRepoUntouched(t *testing.T) {
+	gitRepo(t, []string{"chore: init", "feat: new"}, map[int]string{0: "v1.0.0"})
+	notes := notesFile(t, "Notes without frontmatter")
+
+	got, err := Create(context.Background(), Options{NotesFile: notes, DryRun: true, Runner: runner.Exec{}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if got.String() != "v1.1.0" {
+		t.Errorf("Create() = %s, want v1.1.0", got)
+	}
+	output, err := exec.Command("git", "tag", "--list").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != "v1.0.0\n" {
+		t.Errorf("tags = %q, want only v1.0.0", output)
+	}
+}
+
+func TestCreateTagsPushesAndReleases(t *testing.T) {
+	notes := notesFile(t, "---\ntitle: Spring release\nlatest: false\nassets: [\"notes.md#Notes\"]\n---\nWhat changed")
+
+	r := runner.NewReplayer(
+		testutil.Git("", "fetch", "--quiet", "--tags", "origin"),
+		testutil.Git("v1.0.0\nv1.1.0-rc.1\n", "tag", "--list"),
+		testutil.Git("abc\x1ffeat: add mkrelease (#5)\x1f\x1e\n", "log", "--format=%H%x1f%s%x1f%b%x1e", "v1.0.0..HEAD"),
+		runner.Interaction{Name: "gh", Args: []string{"pr", "view", "5", "--json", "labels", "--jq", ".labels[].name"}, Stdout: "enhancement\n"},
+		testutil.Git("", "tag", "-a", "v1.1.0", "-m", "Release v1.1.0"),
+		testutil.Git("abc\n", "rev-parse", "v1.1.0^{commit}"),
+		testutil.Git("abc\n", "rev-parse", "HEAD"),
+		testutil.Git("", "push", "origin", "refs/tags/v1.1.0"),
+		runner.Interaction{
+			Name: "gh",
+			Args: []string{"release", "create", "v1.1.0", "--verify-tag", "--title", "Spring release",
+				"--notes-file", "-", "--latest=false", filepath.Join(filepath.Dir(notes), "notes.md") + "#Notes"},
+			Stdin:  "What changed",
+			Stdout: "https://github.com/owner/repo/releases/tag/v1.1.0\n",
+		},
+	)
+
+	got, err := Create(context.Background(), Options{NotesFile: notes, Runner: r})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if got.String() != "v1.1.0" {
+		t.Errorf("Create() = %s, want v1.1.0", got)
+	}
+	if err := r.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateRollsBackTagWhenReleaseFails(t *testing.T) {
+	notes := notesFile(t, "Notes")
+
+	r := runner.NewReplayer(
+		testutil.Git("", "fetch", "--quiet", "--tags", "origin"),
+		testutil.Git("v1.0.0\n", "tag", "--list"),
+		testutil.Git("", "log", "--format=%H%x1f%s%x1f%b%x1e", "v1.0.0..HEAD"),
+		testutil.Git("", "tag", "-a", "v1.0.1-rc.1", "-m", "Release v1.0.1-rc.1"),
+		testutil.Git("abc\n", "rev-parse", "v1.0.1-rc.1^{commit}"),
+		testutil.Git("abc\n", "rev-parse", "HEAD"),
+		testutil.Git("", "push", "origin", "refs/tags/v1.0.1-rc.1"),
+		runner.Interaction{
+			Name:     "gh",
+			Args:     []string{"release", "create", "v1.0.1-rc.1", "--verify-tag", "--title", "v1.0.1-rc.1", "--notes-file", "-", "--prerelease"},
+			Stdin:    "Notes",
+			Stderr:   "HTTP 422: Validation Failed",
+			ExitCode: 1,
+		},
+		testutil.Git("", "push", "origin", ":refs/tags/v1.0.1-rc.1"),
+		testutil.Git("", "tag", "-d", "v1.0.1-rc.1"),
+	)
+
+	_, err := Create(context.Background(), Options{NotesFile: notes, Prerelease: "rc", Runner: r})
+	if err == nil {
+		t.Fatal("Create() expected error")
+	}
+	if errors.Is(err, mkissue.ErrPartial) {
+		t.Errorf("Create() = %v, want the tag rolled back", err)
+	}
+	if err := r.Verify(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateRejectsMissingAsset(t *testing.T) {
+	notes := notesFile(t, "---\nassets: [missing.zip]\n---\nNotes")
+
+	_, err := Create(context.Background(), Options{NotesFile: notes, Runner: runner.NewReplayer()})
+	if !errors.Is(err, mkissue.ErrValidation) {
+		t.Errorf("Create() error = %v, want validation error", err)
+	}
+}
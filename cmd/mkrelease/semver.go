@@ -0,0 +1,210 @@
+package mkrelease
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version as found in a git tag, e.g. v1.2.3 or 1.3.0-rc.2.
+type Version struct {
+	// Prefix is kept from the tag, typically "v" or "".
+	Prefix string
+	Major  int
+	Minor  int
+	Patch  int
+	// Pre is the prerelease identifier without its counter, e.g. "rc".
+	Pre string
+	// PreNumber is the prerelease counter, e.g. 2 in 1.3.0-rc.2, and
+	// HasPreNumber reports whether there is one; 1.3.0-beta has none.
+	PreNumber    int
+	HasPreNumber bool
+}
+
+var versionPattern = regexp.MustCompile(`^(v?)(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(?:-([0-9A-Za-z-]+)(?:\.([0-9]+))?)?$`)
+
+// ParseVersion parses a tag name. It reports false for tags that aren't versions.
+func ParseVersion(tag string) (Version, bool) {
+	m := versionPattern.FindStringSubmatch(tag)
+	if m == nil {
+		return Version{}, false
+	}
+	v := Version{Prefix: m[1], Pre: m[5]}
+	v.Major, _ = strconv.Atoi(m[2])
+	v.Minor, _ = strconv.Atoi(m[3])
+	v.Patch, _ = strconv.Atoi(m[4])
+	if m[6] != "" {
+		v.PreNumber, _ = strconv.Atoi(m[6])
+		v.HasPreNumber = true
+	}
+	return v, true
+}
+
+// String formats the version as a tag name.
+func (v Version) String() string {
+	s := fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	if v.Pre != "" && v.HasPreNumber {
+		s += fmt.Sprintf(".%d", v.PreNumber)
+	}
+	return s
+}
+
+// IsPrerelease reports whether v has a prerelease identifier.
+func (v Version) IsPrerelease() bool {
+	return v.Pre != ""
+}
+
+// core returns v without its prerelease part.
+func (v Version) core() Version {
+	return Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+}
+
+// Compare returns -1, 0 or 1 depending on whether v sorts before, equal to or
+// after o. A prerelease sorts before the release with the same core version.
+func (v Version) Compare(o Version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	switch {
+	case v.Pre == "" && o.Pre == "":
+		return 0
+	case v.Pre == "":
+		return 1
+	case o.Pre == "":
+		return -1
+	case v.Pre != o.Pre:
+		return sign(strings.Compare(v.Pre, o.Pre))
+	case v.HasPreNumber != o.HasPreNumber:
+		// Fewer fields sort first, so 1.3.0-beta comes before 1.3.0-beta.0
+		if v.HasPreNumber {
+			return 1
+		}
+		return -1
+	}
+	return sign(v.PreNumber - o.PreNumber)
+}
+
+func sign(d int) int {
+	switch {
+	case d < 0:
+		return -1
+	case d > 0:
+		return 1
+	}
+	return 0
+}
+
+// Bump is the kind of version increment a change requires.
+type Bump int
+
+// Bumps in increasing order of significance.
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+// ParseBump parses "major", "minor" or "patch".
+func ParseBump(s string) (Bump, error) {
+	switch strings.ToLower(s) {
+	case "major":
+		return BumpMajor, nil
+	case "minor":
+		return BumpMinor, nil
+	case "patch":
+		return BumpPatch, nil
+	}
+	return BumpNone, fmt.Errorf("invalid bump '%s': must be major, minor or patch", s)
+}
+
+func (b Bump) String() string {
+	switch b {
+	case BumpMajor:
+		return "major"
+	case BumpMinor:
+		return "minor"
+	case BumpPatch:
+		return "patch"
+	}
+	return "none"
+}
+
+// apply returns the core version incremented by b.
+func (v Version) apply(b Bump) Version {
+	n := v.core()
+	switch b {
+	case BumpMajor:
+		n.Major, n.Minor, n.Patch = n.Major+1, 0, 0
+	case BumpMinor:
+		n.Minor, n.Patch = n.Minor+1, 0
+	case BumpPatch:
+		n.Patch++
+	}
+	return n
+}
+
+// Versions returns the tags that are semantic versions, sorted in ascending order.
+func Versions(tags []string) []Version {
+	var versions []Version
+	for _, tag := range tags {
+		if v, ok := ParseVersion(strings.TrimSpace(tag)); ok {
+			versions = append(versions, v)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool { return versions[i].Compare(versions[j]) < 0 })
+	return versions
+}
+
+// LatestRelease returns the highest version that is not a prerelease.
+func LatestRelease(versions []Version) (Version, bool) {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if !versions[i].IsPrerelease() {
+			return versions[i], true
+		}
+	}
+	return Version{}, false
+}
+
+// Next computes the version to release from the existing versions, the bump
+// required by the changes since the latest release and an optional prerelease
+// identifier (e.g. "rc").
+//
+// Pending prereleases are continued: with 1.2.3 released and 1.3.0-rc.1 tagged,
+// a patch bump yields 1.3.0-rc.2 as a prerelease and 1.3.0 as a release.
+func Next(versions []Version, bump Bump, pre string) Version {
+	latest, _ := LatestRelease(versions)
+	if len(versions) > 0 {
+		// Keep the tag prefix style of the repository
+		latest.Prefix = versions[len(versions)-1].Prefix
+	}
+	if bump == BumpNone {
+		bump = BumpPatch
+	}
+
+	next := latest.apply(bump)
+	for _, v := range versions {
+		if v.IsPrerelease() && v.core().Compare(next) > 0 {
+			next = v.core()
+		}
+	}
+	next.Prefix = latest.Prefix
+	if pre == "" {
+		return next
+	}
+
+	next.Pre, next.PreNumber, next.HasPreNumber = pre, 1, true
+	for _, v := range versions {
+		if v.core().Compare(next.core()) == 0 && v.Pre == pre && v.PreNumber >= next.PreNumber {
+			next.PreNumber = v.PreNumber + 1
+		}
+	}
+	return next
+}
@@ -0,0 +1,130 @@
+package mkrelease
+
+import "testing"
+
+func versions(tags ...string) []Version {
+	return Versions(tags)
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		tag    string
+		want   Version
+		wantOK bool
+	}{
+		{tag: "v1.2.3", want: Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3}, wantOK: true},
+		{tag: "0.1.0", want: Version{Minor: 1}, wantOK: true},
+		{tag: "v2.0.0-rc.3", want: Version{Prefix: "v", Major: 2, Pre: "rc", PreNumber: 3, HasPreNumber: true}, wantOK: true},
+		{tag: "v2.0.0-beta", want: Version{Prefix: "v", Major: 2, Pre: "beta"}, wantOK: true},
+		{tag: "v2.0.0-rc1", want: Version{Prefix: "v", Major: 2, Pre: "rc1"}, wantOK: true},
+		{tag: "v2.0.0-rc.0", want: Version{Prefix: "v", Major: 2, Pre: "rc", HasPreNumber: true}, wantOK: true},
+		{tag: "release-1", wantOK: false},
+		{tag: "v1.2", wantOK: false},
+		{tag: "v01.2.3", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, ok := ParseVersion(tt.tag)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseVersion(%q) ok = %v, want %v", tt.tag, ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("ParseVersion(%q) = %+v, want %+v", tt.tag, got, tt.want)
+			}
+			if ok && got.String() != tt.tag {
+				t.Errorf("ParseVersion(%q).String() = %s", tt.tag, got)
+			}
+		})
+	}
+}
+
+func TestVersionsOrder(t *testing.T) {
+	got := versions("v1.10.0", "v1.2.0", "v1.10.0-rc.2", "v1.10.0-rc.10", "not-a-version", "v1.9.9", "v1.10.0-rc")
+	want := []string{"v1.2.0", "v1.9.9", "v1.10.0-rc", "v1.10.0-rc.2", "v1.10.0-rc.10", "v1.10.0"}
+	if len(got) != len(want) {
+		t.Fatalf("Versions() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i].String() != want[i] {
+			t.Errorf("Versions()[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		bump Bump
+		pre  string
+		want string
+	}{
+		{name: "first release", bump: BumpMinor, want: "0.1.0"},
+		{name: "patch", tags: []string{"v1.2.3"}, bump: BumpPatch, want: "v1.2.4"},
+		{name: "minor", tags: []string{"v1.2.3"}, bump: BumpMinor, want: "v1.3.0"},
+		{name: "major", tags: []string{"v1.2.3"}, bump: BumpMajor, want: "v2.0.0"},
+		{name: "no signal defaults to patch", tags: []string{"v1.2.3"}, bump: BumpNone, want: "v1.2.4"},
+		{name: "keeps prefix style", tags: []string{"1.2.3"}, bump: BumpMinor, want: "1.3.0"},
+		{name: "first prerelease", tags: []string{"v1.2.3"}, bump: BumpMinor, pre: "rc", want: "v1.3.0-rc.1"},
+		{name: "continues prerelease", tags: []string{"v1.2.3", "v1.3.0-rc.1"}, bump: BumpPatch, pre: "rc", want: "v1.3.0-rc.2"},
+		{name: "new identifier restarts counter", tags: []string{"v1.2.3", "v1.3.0-rc.2"}, bump: BumpMinor, pre: "beta", want: "v1.3.0-beta.1"},
+		{name: "releases pending prerelease", tags: []string{"v1.2.3", "v1.3.0-rc.2"}, bump: BumpPatch, want: "v1.3.0"},
+		{name: "bigger bump passes prerelease", tags: []string{"v1.2.3", "v1.3.0-rc.2"}, bump: BumpMajor, pre: "rc", want: "v2.0.0-rc.1"},
+		{name: "ignores older prereleases", tags: []string{"v1.2.0-rc.1", "v1.2.0", "v1.2.3"}, bump: BumpPatch, pre: "rc", want: "v1.2.4-rc.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Next(versions(tt.tags...), tt.bump, tt.pre).String(); got != tt.want {
+				t.Errorf("Next(%v, %s, %q) = %s, want %s", tt.tags, tt.bump, tt.pre, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitBump(t *testing.T) {
+	tests := []struct {
+		commit Commit
+		want   Bump
+	}{
+		{commit: Commit{Subject: "feat: add mkrelease"}, want: BumpMinor},
+		{commit: Commit{Subject: "feat(cli): add flag"}, want: BumpMinor},
+		{commit: Commit{Subject: "fix: handle empty tags"}, want: BumpPatch},
+		{commit: Commit{Subject: "perf: cache tags"}, want: BumpPatch},
+		{commit: Commit{Subject: "refactor!: drop legacy Run"}, want: BumpMajor},
+		{commit: Commit{Subject: "feat: new config", Body: "BREAKING CHANGE: config moved"}, want: BumpMajor},
+		{commit: Commit{Subject: "docs: update README"}, want: BumpNone},
+		{commit: Commit{Subject: "Update README"}, want: BumpNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.commit.Subject, func(t *testing.T) {
+			if got := CommitBump(tt.commit); got != tt.want {
+				t.Errorf("CommitBump() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelBump(t *testing.T) {
+	if got := LabelBump([]string{"documentation", "Bug", "enhancement"}); got != BumpMinor {
+		t.Errorf("LabelBump() = %s, want minor", got)
+	}
+	if got := LabelBump([]string{"question"}); got != BumpNone {
+		t.Errorf("LabelBump() = %s, want none", got)
+	}
+}
+
+func TestPullRequestNumber(t *testing.T) {
+	tests := map[string]string{
+		"Merge pull request #12 from lakruzz/12-feature": "12",
+		"feat: add mkrelease (#34)":                      "34",
+		"fix: see #56 for details":                       "",
+	}
+	for subject, want := range tests {
+		if got := PullRequestNumber(Commit{Subject: subject}); got != want {
+			t.Errorf("PullRequestNumber(%q) = %q, want %q", subject, got, want)
+		}
+	}
+}
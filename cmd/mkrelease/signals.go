@@ -0,0 +1,80 @@
+package mkrelease
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Commit is a commit since the latest release.
+type Commit struct {
+	SHA     string
+	Subject string
+	Body    string
+}
+
+var (
+	conventionalPattern = regexp.MustCompile(`^([a-zA-Z]+)(\([^)]*\))?(!)?:\s`)
+	breakingPattern     = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:`)
+	// pullRequestPattern finds the pull request number of merge and squash commits.
+	pullRequestPattern = regexp.MustCompile(`(?:^Merge pull request #([0-9]+)|\(#([0-9]+)\)$)`)
+)
+
+// CommitBump returns the bump a conventional commit message requires:
+// breaking changes are major, feat is minor, fix and perf are patch.
+func CommitBump(c Commit) Bump {
+	if breakingPattern.MatchString(c.Body) {
+		return BumpMajor
+	}
+	m := conventionalPattern.FindStringSubmatch(c.Subject)
+	if m == nil {
+		return BumpNone
+	}
+	if m[3] == "!" {
+		return BumpMajor
+	}
+	switch strings.ToLower(m[1]) {
+	case "feat":
+		return BumpMinor
+	case "fix", "perf":
+		return BumpPatch
+	}
+	return BumpNone
+}
+
+// labelBumps maps pull request labels to the bump they signal.
+var labelBumps = map[string]Bump{
+	"breaking":     BumpMajor,
+	"major":        BumpMajor,
+	"semver:major": BumpMajor,
+	"feature":      BumpMinor,
+	"enhancement":  BumpMinor,
+	"minor":        BumpMinor,
+	"semver:minor": BumpMinor,
+	"bug":          BumpPatch,
+	"fix":          BumpPatch,
+	"patch":        BumpPatch,
+	"semver:patch": BumpPatch,
+}
+
+// LabelBump returns the most significant bump signalled by the labels.
+func LabelBump(labels []string) Bump {
+	bump := BumpNone
+	for _, label := range labels {
+		if b := labelBumps[strings.ToLower(strings.TrimSpace(label))]; b > bump {
+			bump = b
+		}
+	}
+	return bump
+}
+
+// PullRequestNumber returns the pull request a merge or squash commit belongs to, or "".
+func PullRequestNumber(c Commit) string {
+	m := pullRequestPattern.FindStringSubmatch(c.Subject)
+	if m == nil {
+		return ""
+	}
+	if m[1] != "" {
+		return m[1]
+	}
+	return m[2]
+}
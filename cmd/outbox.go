@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/spf13/cobra"
+)
+
+var (
+	outboxKeepPartial bool
+	outboxDropAll     bool
+)
+
+var outboxCmd = &cobra.Command{
+	Use:   "outbox",
+	Short: "Manage issues queued with 'mkissue --queue'",
+	Long: `Manage the issues queued with 'mkissue --queue' or '--queue-offline'.
+
+The outbox is kept in the git directory of the current repository (or in
+the user cache directory outside a repository, or in $UTILS_OUTBOX). Each
+queued issue carries an invisible marker in its body, so flushing again
+after a partial failure never creates an issue twice.`,
+}
+
+var outboxListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the queued issues",
+	Args:  cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		outbox, err := mkissue.OpenOutbox()
+		if err != nil {
+			return err
+		}
+		requests, err := outbox.List()
+		if err != nil {
+			return err
+		}
+		if len(requests) == 0 {
+			fmt.Println("The outbox is empty")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, r := range requests {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.ID, r.QueuedAt.Local().Format("2006-01-02 15:04"), r.Metadata.Title, r.File)
+		}
+		return w.Flush()
+	},
+}
+
+var outboxFlushCmd = &cobra.Command{
+	Use:   "flush",
+	Short: "Create the queued issues on GitHub",
+	Long: `Create the queued issues on GitHub, oldest first, in the repository each
+was queued for, and remove them from the outbox. Issues that an earlier
+flush already created are only removed; their comments, pin, lock and state
+are reported for you to check.
+Flushing stops at the first network error; run it again when GitHub can
+be reached.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		outbox, err := mkissue.OpenOutbox()
+		if err != nil {
+			return err
+		}
+		created, err := outbox.Flush(cmd.Context(), mkissue.Options{Runner: commandRunner, KeepPartial: outboxKeepPartial, Host: host})
+		fmt.Printf("Flushed %d issue(s)\n", len(created))
+		return err
+	},
+}
+
+var outboxDropCmd = &cobra.Command{
+	Use:   "drop [<id>...]",
+	Short: "Remove queued issues without creating them",
+	RunE: func(_ *cobra.Command, args []string) error {
+		if outboxDropAll == (len(args) > 0) {
+			return validationError("give the IDs to drop or --all")
+		}
+		outbox, err := mkissue.OpenOutbox()
+		if err != nil {
+			return err
+		}
+		if outboxDropAll {
+			requests, err := outbox.List()
+			if err != nil {
+				return err
+			}
+			for _, r := range requests {
+				args = append(args, r.ID)
+			}
+		}
+		for _, id := range args {
+			if err := outbox.Drop(id); err != nil {
+				return err
+			}
+			fmt.Printf("Dropped %s\n", id)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(outboxCmd)
+	outboxCmd.AddCommand(outboxListCmd, outboxFlushCmd, outboxDropCmd)
+
+	// Define flags for outbox commands
+	outboxFlushCmd.Flags().BoolVar(&outboxKeepPartial, "keep-partial", false, "Keep labels created for an issue that fails, instead of rolling them back")
+	outboxDropCmd.Flags().BoolVar(&outboxDropAll, "all", false, "Drop every queued issue")
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/cmd/recur"
+	"github.com/lakruzz/gh-utils/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recurState       string
+	recurDryRun      bool
+	recurKeepPartial bool
+)
+
+var recurCmd = &cobra.Command{
+	Use:   "recur <dir>",
+	Short: "Create the recurring issues that are due",
+	Long: `Create the issues of the issue files in <dir>, and its subdirectories, that
+have a schedule, for every occurrence that came due since the last run.
+
+The schedule is a cron expression (in UTC, like GitHub Actions) or an
+iCalendar recurrence rule, and the title may hold placeholders for the
+occurrence:
+
+  title: Sprint {{iteration}} checklist ({{date}})
+  schedule: FREQ=WEEKLY;INTERVAL=2;BYDAY=MO
+  schedule_start: 2026-01-05T09:00
+
+The runs are recorded in <dir>/.utils-recur.json (or --state), so no
+occurrence gets two issues; commit it, or keep it between the runs of a
+scheduled workflow. The first run of a file only creates its latest
+occurrence. Issues are created like 'utils mkissue' creates them, with the
+secret scan and mention policy of .utils.yml.
+
+Usage:
+  utils recur <dir> [--state <file>] [--dry-run] [--keep-partial]`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scanner, err := secrets.New(secretRules)
+		if err != nil {
+			return validationError("%v", err)
+		}
+		occurrences, err := recur.Run(cmd.Context(), args[0], recur.Options{
+			Issue: mkissue.Options{
+				Runner:      commandRunner,
+				KeepPartial: recurKeepPartial,
+				Secrets:     scanner,
+				Mentions:    mentionPolicy(mentions.Policy),
+			},
+			State:  recurState,
+			DryRun: recurDryRun,
+		})
+		if recurDryRun {
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			for _, o := range occurrences {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", o.At.Format(time.RFC3339), o.Title, o.File)
+			}
+			if flushErr := w.Flush(); err == nil {
+				err = flushErr
+			}
+			fmt.Printf("%d issue(s) due\n", len(occurrences))
+			return err
+		}
+		fmt.Printf("Created %d issue(s)\n", len(occurrences))
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(recurCmd)
+
+	// Define flags for recur command
+	recurCmd.Flags().StringVar(&recurState, "state", "", "File recording the runs (defaults to <dir>/"+recur.StateFile+")")
+	recurCmd.Flags().BoolVar(&recurDryRun, "dry-run", false, "List the issues that are due without creating them")
+	recurCmd.Flags().BoolVar(&recurKeepPartial, "keep-partial", false, "Keep labels and other changes made on GitHub for an issue that fails, instead of rolling them back")
+}
@@ -0,0 +1,302 @@
+// Package recur creates recurring issues: issue files with a schedule get an
+// issue for every occurrence that came due since the last run, created
+// through the same pipeline as utils mkissue.
+package recur
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/schedule"
+)
+
+// StateFile is the name of the state file kept next to the issue files.
+const StateFile = ".utils-recur.json"
+
+// Options control which occurrences are due and how their issues are created.
+type Options struct {
+	// Issue are the options every issue is created with; Occurrence is set
+	// for each one.
+	Issue mkissue.Options
+	// State is the file recording the runs; "" uses StateFile in the directory.
+	State string
+	// Now is the time of the run; zero uses the current time.
+	Now time.Time
+	// DryRun returns the due occurrences without creating their issues or
+	// recording the run.
+	DryRun bool
+}
+
+// State records, for each issue file, up to when its occurrences are handled,
+// so a run never creates an issue an earlier run created.
+type State struct {
+	// Files are keyed by their slash-separated path within the directory.
+	Files map[string]*FileState `json:"files"`
+}
+
+// FileState is the state of one issue file.
+type FileState struct {
+	// LastRun is the time up to which the occurrences of the file are handled.
+	LastRun time.Time `json:"last_run"`
+	// LastOccurrence is the occurrence the last issue was created for.
+	LastOccurrence *time.Time `json:"last_occurrence,omitempty"`
+}
+
+// Occurrence is an issue due for an occurrence of the schedule of a file.
+type Occurrence struct {
+	File  string
+	Title string
+	At    time.Time
+}
+
+// Run creates the issues of the scheduled issue files in dir, and its
+// subdirectories, that came due since the last run recorded in the state. On
+// the first run of a file only its latest occurrence is due, so adding a
+// schedule doesn't create a backlog of past issues. It returns the
+// occurrences it created issues for.
+//
+// The state is saved after every issue, so a run that fails halfway is
+// resumed by the next one. A file that fails is retried from the occurrence
+// that failed, unless its issue was kept on GitHub (see mkissue.PartialError);
+// the other files still run, except after a network error. A file with a
+// schedule whose frontmatter can't be parsed fails, but keeps its state, and
+// so does a file that lost its schedule; only the state of removed files is
+// forgotten.
+func Run(ctx context.Context, dir string, opts Options) ([]Occurrence, error) {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.UTC()
+	statePath := opts.State
+	if statePath == "" {
+		statePath = filepath.Join(dir, StateFile)
+	}
+
+	found, err := scan(dir)
+	if err != nil {
+		return nil, err
+	}
+	state, err := loadState(statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Occurrence
+	errs := found.broken
+	for _, f := range found.files {
+		last := state.Files[f.key]
+		var due []time.Time
+		if last == nil {
+			if latest := f.schedule.Latest(now); !latest.IsZero() {
+				due = append(due, latest)
+			}
+		} else {
+			due = f.schedule.Between(last.LastRun, now)
+		}
+
+		failed := false
+		for _, at := range due {
+			title, err := mkissue.RenderTitle(f.title, f.schedule, at)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.key, err))
+				failed = true
+				break
+			}
+			occurrence := Occurrence{File: f.path, Title: title, At: at}
+			if opts.DryRun {
+				done = append(done, occurrence)
+				continue
+			}
+
+			fmt.Printf("Creating '%s' for %s from %s\n", title, at.Format(time.RFC3339), f.key)
+			issueOpts := opts.Issue
+			issueOpts.Occurrence = at
+			err = mkissue.Create(ctx, f.path, issueOpts)
+			var partial *mkissue.PartialError
+			if err != nil && !(errors.As(err, &partial) && partial.Issue != "") {
+				errs = append(errs, fmt.Errorf("%s (%s): %w", f.key, at.Format(time.RFC3339), err))
+				failed = true
+				break
+			}
+			// An issue left on GitHub by a failed run counts as created, so the
+			// next run doesn't create the occurrence again
+			done = append(done, occurrence)
+			created := at
+			state.Files[f.key] = &FileState{LastRun: at, LastOccurrence: &created}
+			if saveErr := state.save(statePath); saveErr != nil {
+				return done, saveErr
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s (%s): issue %s created, but: %w", f.key, at.Format(time.RFC3339), partial.Issue, err))
+				failed = true
+				break
+			}
+		}
+		if opts.DryRun {
+			continue
+		}
+		if failed {
+			if errors.Is(errs[len(errs)-1], mkissue.ErrNetwork) || ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		if state.Files[f.key] == nil {
+			state.Files[f.key] = &FileState{}
+		}
+		state.Files[f.key].LastRun = now
+	}
+	if opts.DryRun {
+		return done, errors.Join(errs...)
+	}
+
+	for key, last := range state.Files {
+		switch {
+		case found.unscheduled[key]:
+			// Nothing is due while the schedule is gone, so adding it back
+			// continues from here instead of creating the latest occurrence again
+			last.LastRun = now
+		case !found.present[key]:
+			delete(state.Files, key)
+		}
+	}
+	if err := state.save(statePath); err != nil {
+		errs = append(errs, err)
+	}
+	return done, errors.Join(errs...)
+}
+
+// scheduledFile is an issue file with a schedule.
+type scheduledFile struct {
+	path     string
+	key      string
+	title    string
+	schedule *schedule.Schedule
+}
+
+// scanned are the markdown files of a directory, keyed by their
+// slash-separated path within it.
+type scanned struct {
+	files []scheduledFile
+	// present are all markdown files, and unscheduled those without a schedule.
+	present     map[string]bool
+	unscheduled map[string]bool
+	// broken are the errors of the files with a schedule that can't be parsed.
+	broken []error
+}
+
+// scheduleLine matches the schedule field of a frontmatter that can't be parsed.
+var scheduleLine = regexp.MustCompile(`(?m)^schedule:`)
+
+// scan reads the markdown files in dir with a schedule in their frontmatter,
+// in order of their paths. Hidden directories are skipped.
+func scan(dir string) (*scanned, error) {
+	s := &scanned{present: map[string]bool{}, unscheduled: map[string]bool{}}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".md") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		s.present[key] = true
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		frontmatter, _, err := mkissue.ParseFrontmatter(string(content))
+		if err != nil {
+			if scheduleLine.Match(content) {
+				s.broken = append(s.broken, fmt.Errorf("%s: %w", key, err))
+			} else {
+				s.unscheduled[key] = true
+			}
+			return nil
+		}
+		if !frontmatter.Has("schedule") {
+			s.unscheduled[key] = true
+			return nil
+		}
+		sched, err := frontmatter.Schedule()
+		if err != nil {
+			s.broken = append(s.broken, fmt.Errorf("%s: %w", key, err))
+			return nil
+		}
+		s.files = append(s.files, scheduledFile{path: path, key: key, title: frontmatter.String("title"), schedule: sched})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadState reads the state file; a missing file is an empty state.
+func loadState(path string) (*State, error) {
+	state := &State{Files: map[string]*FileState{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the recur state: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to read the recur state '%s': %w", path, err)
+	}
+	if state.Files == nil {
+		state.Files = map[string]*FileState{}
+	}
+	return state, nil
+}
+
+// save writes the state through a temporary file, so a crash never leaves
+// half a state.
+func (s *State) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".recur-*")
+	if err != nil {
+		return fmt.Errorf("failed to save the recur state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	// The state is meant to be committed next to the issue files
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save the recur state: %w", err)
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save the recur state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save the recur state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save the recur state: %w", err)
+	}
+	return nil
+}
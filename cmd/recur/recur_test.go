@@ -0,0 +1,237 @@
+package recur
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+func at(value string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func create(title string) runner.Interaction {
+	return runner.Interaction{
+		Name:   "gh",
+		Args:   []string{"issue", "create", "--title", title, "--body-file", "-"},
+		Stdin:  "Checklist",
+		Stdout: "https://github.com/owner/repo/issues/1",
+	}
+}
+
+// run runs recur in dir at now, expecting exactly the interactions, and
+// returns the titles of the created issues.
+func run(t *testing.T, dir, now string, interactions ...runner.Interaction) ([]string, error) {
+	t.Helper()
+	r := runner.NewReplayer(interactions...)
+	occurrences, err := Run(context.Background(), dir, Options{Issue: mkissue.Options{Runner: r}, Now: at(now)})
+	if verr := r.Verify(); verr != nil {
+		t.Error(verr)
+	}
+	var titles []string
+	for _, o := range occurrences {
+		titles = append(titles, o.Title)
+	}
+	return titles, err
+}
+
+func setup(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"sprint.issue.md":      "---\ntitle: Sprint {{iteration}} checklist ({{date}})\nschedule: FREQ=WEEKLY;INTERVAL=2;BYDAY=MO\nschedule_start: 2026-01-05T09:00\n---\nChecklist",
+		"ops/monthly.issue.md": "---\ntitle: Release review {{month}} {{year}}\nschedule: \"0 9 1 * *\"\n---\nChecklist",
+		"once.issue.md":        "---\ntitle: Not scheduled\n---\nChecklist",
+		"README.md":            "# Recurring issues\n",
+		".drafts/x.issue.md":   "---\ntitle: Draft\nschedule: \"@daily\"\n---\nChecklist",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestRun(t *testing.T) {
+	dir := setup(t)
+
+	// The first run only creates the latest occurrence of each file
+	titles, err := run(t, dir, "2026-10-18 12:00",
+		create("Release review October 2026"),
+		create("Sprint 21 checklist (2026-10-12)"),
+	)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if want := []string{"Release review October 2026", "Sprint 21 checklist (2026-10-12)"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("Run() = %v, want %v", titles, want)
+	}
+
+	// Later runs create every occurrence since the last run, once
+	if _, err := run(t, dir, "2026-11-10 12:00",
+		create("Release review November 2026"),
+		create("Sprint 22 checklist (2026-10-26)"),
+		create("Sprint 23 checklist (2026-11-09)"),
+	); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if titles, err := run(t, dir, "2026-11-10 12:00"); err != nil || len(titles) != 0 {
+		t.Errorf("Run() again = %v, %v, want nothing", titles, err)
+	}
+
+	state, err := loadState(filepath.Join(dir, StateFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Files) != 2 || !state.Files["sprint.issue.md"].LastOccurrence.Equal(at("2026-11-09 09:00")) ||
+		!state.Files["ops/monthly.issue.md"].LastRun.Equal(at("2026-11-10 12:00")) {
+		t.Errorf("state = %+v", state.Files)
+	}
+}
+
+func TestRunRetriesFailedOccurrences(t *testing.T) {
+	dir := setup(t)
+	if _, err := run(t, dir, "2026-11-10 12:00",
+		create("Release review November 2026"),
+		create("Sprint 23 checklist (2026-11-09)"),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := create("Sprint 24 checklist (2026-11-23)")
+	failed.Stdout, failed.Stderr, failed.ExitCode = "", "GraphQL: Could not resolve to a Repository", 1
+	titles, err := run(t, dir, "2026-12-10 12:00", create("Release review December 2026"), failed)
+	if err == nil || !strings.Contains(err.Error(), "sprint.issue.md (2026-11-23T09:00:00Z)") {
+		t.Errorf("Run() error = %v, want the failed occurrence", err)
+	}
+	if want := []string{"Release review December 2026"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("Run() = %v, want %v", titles, want)
+	}
+
+	if _, err := run(t, dir, "2026-12-10 12:00",
+		create("Sprint 24 checklist (2026-11-23)"),
+		create("Sprint 25 checklist (2026-12-07)"),
+	); err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+}
+
+func TestRunKeepsStateOfChangedFiles(t *testing.T) {
+	dir := setup(t)
+	path := filepath.Join(dir, "sprint.issue.md")
+	good, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := run(t, dir, "2026-10-18 12:00",
+		create("Release review October 2026"),
+		create("Sprint 21 checklist (2026-10-12)"),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// A broken frontmatter is reported, and the other files still run
+	write("---\ntitle: Sprint {{iteration}}\nschedule: [FREQ=WEEKLY\n---\nChecklist")
+	if _, err := run(t, dir, "2026-11-02 12:00", create("Release review November 2026")); err == nil || !strings.Contains(err.Error(), "sprint.issue.md") {
+		t.Errorf("Run() error = %v, want the broken file", err)
+	}
+
+	// Once fixed, only the occurrences since the last one are created
+	write(string(good))
+	if _, err := run(t, dir, "2026-11-02 12:00", create("Sprint 22 checklist (2026-10-26)")); err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+
+	// Nothing is due while the schedule is removed, nor when it's added back
+	write("---\ntitle: Sprint checklist\n---\nChecklist")
+	if titles, err := run(t, dir, "2026-11-10 12:00"); err != nil || len(titles) != 0 {
+		t.Errorf("Run() without schedule = %v, %v, want nothing", titles, err)
+	}
+	write(string(good))
+	if titles, err := run(t, dir, "2026-11-12 12:00"); err != nil || len(titles) != 0 {
+		t.Errorf("Run() with the schedule back = %v, %v, want nothing", titles, err)
+	}
+	if _, err := run(t, dir, "2026-11-23 12:00", create("Sprint 24 checklist (2026-11-23)")); err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+}
+
+func TestRunRecordsKeptIssues(t *testing.T) {
+	url := "https://github.com/owner/repo/issues/1"
+	comment := runner.Interaction{
+		Name: "gh", Args: []string{"issue", "comment", url, "--body-file", "-"}, Stdin: "Agenda",
+		Stderr: "HTTP 422: Validation Failed", ExitCode: 1,
+	}
+	deleteFails := runner.Interaction{Name: "gh", Args: []string{"issue", "delete", url, "--yes"}, Stderr: "HTTP 403: Must have admin rights to Repository.", ExitCode: 1}
+
+	tests := []struct {
+		name        string
+		keepPartial bool
+		undo        []runner.Interaction
+	}{
+		{"keep partial", true, nil},
+		{"failed rollback", false, []runner.Interaction{deleteFails}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			content := "---\ntitle: Retro {{date}}\nschedule: \"0 9 * * 5\"\ncomments: [Agenda]\n---\nChecklist"
+			if err := os.WriteFile(filepath.Join(dir, "retro.issue.md"), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			r := runner.NewReplayer(append([]runner.Interaction{create("Retro 2026-10-16"), comment}, tt.undo...)...)
+			occurrences, err := Run(context.Background(), dir, Options{Issue: mkissue.Options{Runner: r, KeepPartial: tt.keepPartial}, Now: at("2026-10-18 12:00")})
+			if verr := r.Verify(); verr != nil {
+				t.Error(verr)
+			}
+			if err == nil || !strings.Contains(err.Error(), "issue "+url+" created") || len(occurrences) != 1 {
+				t.Errorf("Run() = %v, %v, want the kept issue reported", occurrences, err)
+			}
+
+			// The kept issue isn't created again
+			if titles, err := run(t, dir, "2026-10-18 12:30"); err != nil || len(titles) != 0 {
+				t.Errorf("Run() again = %v, %v, want nothing", titles, err)
+			}
+		})
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	dir := setup(t)
+	occurrences, err := Run(context.Background(), dir, Options{Issue: mkissue.Options{Runner: runner.NewReplayer()}, Now: at("2026-10-18 12:00"), DryRun: true})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := []Occurrence{
+		{File: filepath.Join(dir, "ops", "monthly.issue.md"), Title: "Release review October 2026", At: at("2026-10-01 09:00")},
+		{File: filepath.Join(dir, "sprint.issue.md"), Title: "Sprint 21 checklist (2026-10-12)", At: at("2026-10-12 09:00")},
+	}
+	if !reflect.DeepEqual(occurrences, want) {
+		t.Errorf("Run() = %+v, want %+v", occurrences, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, StateFile)); !os.IsNotExist(err) {
+		t.Errorf("dry run saved the state: %v", err)
+	}
+}
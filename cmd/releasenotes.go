@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/lakruzz/gh-utils/cmd/releasenotes"
+	"github.com/spf13/cobra"
+)
+
+var (
+	notesFrom   string
+	notesTo     string
+	notesOutput string
+)
+
+var releasenotesCmd = &cobra.Command{
+	Use:   "releasenotes",
+	Short: "Generate release notes from the issues and pull requests merged between two revisions",
+	Long: `Generate markdown release notes from the local git history.
+
+Issues are found from merged branches named after them (e.g. 42-add-feature)
+and pull requests from merge and squash commits. Their titles and labels are
+looked up on GitHub and grouped by the label categories in the 'releasenotes'
+section of .utils.yml.
+
+Usage:
+  utils releasenotes [--from <tag>] [--to <ref>] [--output <file>]
+
+The output can be passed directly to 'gh release create --notes-file' or
+'utils mkrelease --notes'.`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return releasenotes.Generate(cmd.Context(), releasenotes.Options{
+			From:   notesFrom,
+			To:     notesTo,
+			Output: notesOutput,
+			Config: &releaseNotesConfig,
+			Runner: commandRunner,
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(releasenotesCmd)
+
+	// Define flags for releasenotes command
+	releasenotesCmd.Flags().StringVar(&notesFrom, "from", "", "Tag to start from, exclusive (defaults to the latest release tag)")
+	releasenotesCmd.Flags().StringVar(&notesTo, "to", "HEAD", "Revision to end at, inclusive")
+	releasenotesCmd.Flags().StringVarP(&notesOutput, "output", "o", "", "File to write the notes to (defaults to stdout)")
+}
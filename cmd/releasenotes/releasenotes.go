@@ -0,0 +1,252 @@
+// Package releasenotes renders release notes from the issues and pull requests
+// merged between two git revisions.
+package releasenotes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/cmd/mkpr"
+	"github.com/lakruzz/gh-utils/cmd/mkrelease"
+	"github.com/lakruzz/gh-utils/internal/config"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// Options select the changes to include and where the notes are written.
+type Options struct {
+	// From is the tag after which changes are included; "" uses the latest release tag.
+	From string
+	// To is the revision up to which changes are included; "" is HEAD.
+	To string
+	// Output is the notes file to write; "" writes to stdout.
+	Output string
+	// Config groups the changes; nil uses the default categories.
+	Config *config.ReleaseNotes
+	// Runner executes every gh and git invocation; nil uses runner.Default().
+	Runner runner.Runner
+}
+
+// Kind tells whether a reference is an issue or a pull request.
+type Kind string
+
+// Reference kinds.
+const (
+	Issue       Kind = "issue"
+	PullRequest Kind = "pr"
+)
+
+// Reference is an issue or pull request mentioned by a commit.
+type Reference struct {
+	Kind   Kind
+	Number int
+}
+
+// Change is a referenced issue or pull request as shown in the notes.
+type Change struct {
+	Reference
+	Title  string
+	Labels []string
+	URL    string
+}
+
+var (
+	// mergeBranchPattern finds the branch of 'Merge branch' and 'Merge pull request' commits.
+	mergeBranchPattern = regexp.MustCompile(`^Merge (?:remote-tracking )?branch '(?:[^/']+/)?([^']+)'|^Merge pull request #[0-9]+ from [^/ ]+/(\S+)`)
+)
+
+// Generate renders the release notes and writes them to opts.Output or stdout.
+func Generate(ctx context.Context, opts Options) error {
+	notes, err := Render(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if opts.Output == "" {
+		fmt.Print(notes)
+		return nil
+	}
+	if err := os.WriteFile(opts.Output, []byte(notes), 0o644); err != nil {
+		return fmt.Errorf("failed to write release notes: %w", err)
+	}
+	return nil
+}
+
+// Render looks up the changes between opts.From and opts.To and renders them as markdown.
+func Render(ctx context.Context, opts Options) (string, error) {
+	cfg := opts.Config
+	if cfg == nil {
+		cfg = &config.Default().ReleaseNotes
+	}
+	s := mkissue.NewSession(opts.Runner)
+
+	revision, err := revisionRange(ctx, s, opts.From, opts.To)
+	if err != nil {
+		return "", err
+	}
+	commits, err := mkrelease.Log(ctx, s, revision)
+	if err != nil {
+		return "", err
+	}
+
+	var changes []Change
+	seen := map[Reference]bool{}
+	for _, ref := range References(commits) {
+		change, err := lookup(ctx, s, ref)
+		if err != nil {
+			return "", err
+		}
+		if seen[change.Reference] {
+			continue
+		}
+		seen[change.Reference] = true
+		changes = append(changes, change)
+	}
+	return Markdown(changes, *cfg), nil
+}
+
+// revisionRange returns the git revision range from..to, defaulting from to
+// the latest release tag and to to HEAD.
+func revisionRange(ctx context.Context, s *mkissue.Session, from, to string) (string, error) {
+	if to == "" {
+		to = "HEAD"
+	}
+	if from == "" {
+		output, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"tag", "--list"}})
+		if err != nil {
+			return "", fmt.Errorf("failed to list tags: %w", err)
+		}
+		latest, ok := mkrelease.LatestRelease(mkrelease.Versions(strings.Split(string(output), "\n")))
+		if !ok {
+			return to, nil
+		}
+		from = latest.String()
+	}
+	return from + ".." + to, nil
+}
+
+// References returns the issues and pull requests the commits refer to, in
+// order of first appearance. Merged branches named after an issue (e.g.
+// 42-add-feature) refer to that issue; other pull requests refer to themselves.
+func References(commits []mkrelease.Commit) []Reference {
+	var refs []Reference
+	seen := map[Reference]bool{}
+	add := func(ref Reference) {
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+
+	for _, c := range commits {
+		if m := mergeBranchPattern.FindStringSubmatch(c.Subject); m != nil {
+			if number, err := strconv.Atoi(mkpr.IssueNumber(m[1] + m[2])); err == nil {
+				add(Reference{Kind: Issue, Number: number})
+				continue
+			}
+		}
+		if number, err := strconv.Atoi(mkrelease.PullRequestNumber(c)); err == nil {
+			add(Reference{Kind: PullRequest, Number: number})
+		}
+	}
+	return refs
+}
+
+// lookup fetches the title and labels of ref. A pull request from a branch
+// named after an issue is replaced by that issue.
+func lookup(ctx context.Context, s *mkissue.Session, ref Reference) (Change, error) {
+	var view struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		URL    string `json:"url"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+		HeadRefName string `json:"headRefName"`
+	}
+
+	fields := "number,title,labels,url"
+	if ref.Kind == PullRequest {
+		fields += ",headRefName"
+	}
+	number := strconv.Itoa(ref.Number)
+	output, err := s.Run(ctx, runner.Command{Name: "gh", Args: []string{string(ref.Kind), "view", number, "--json", fields}})
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to look up %s #%s: %w", ref.Kind, number, err)
+	}
+	if err := json.Unmarshal(output, &view); err != nil {
+		return Change{}, fmt.Errorf("failed to parse %s #%s: %w", ref.Kind, number, err)
+	}
+
+	if issue, err := strconv.Atoi(mkpr.IssueNumber(view.HeadRefName)); err == nil {
+		return lookup(ctx, s, Reference{Kind: Issue, Number: issue})
+	}
+	change := Change{Reference: ref, Title: view.Title, URL: view.URL}
+	for _, label := range view.Labels {
+		change.Labels = append(change.Labels, label.Name)
+	}
+	return change, nil
+}
+
+// Markdown renders the changes grouped by the configured categories, ready
+// for 'gh release create --notes-file'.
+func Markdown(changes []Change, cfg config.ReleaseNotes) string {
+	groups := make([][]Change, len(cfg.Categories))
+	var other []Change
+
+next:
+	for _, change := range changes {
+		if hasLabel(change.Labels, cfg.Exclude) {
+			continue
+		}
+		for i, category := range cfg.Categories {
+			if hasLabel(change.Labels, category.Labels) {
+				groups[i] = append(groups[i], change)
+				continue next
+			}
+		}
+		if cfg.Other != "" {
+			other = append(other, change)
+		}
+	}
+
+	var b strings.Builder
+	section := func(title string, changes []Change) {
+		if len(changes) == 0 {
+			return
+		}
+		sort.SliceStable(changes, func(i, j int) bool { return changes[i].Number < changes[j].Number })
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "## %s\n\n", title)
+		for _, change := range changes {
+			fmt.Fprintf(&b, "- %s (#%d)\n", change.Title, change.Number)
+		}
+	}
+	for i, category := range cfg.Categories {
+		section(category.Title, groups[i])
+	}
+	section(cfg.Other, other)
+
+	if b.Len() == 0 {
+		return "No notable changes.\n"
+	}
+	return b.String()
+}
+
+func hasLabel(labels, wanted []string) bool {
+	for _, label := range labels {
+		for _, w := range wanted {
+			if strings.EqualFold(label, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
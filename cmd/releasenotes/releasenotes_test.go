@@ -0,0 +1,144 @@
+package releasenotes
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/cmd/mkrelease"
+	"github.com/lakruzz/gh-utils/internal/config"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+func TestReferences(t *testing.T) {
+	commits := []mkrelease.Commit{
+		{Subject: "Merge pull request #51 from lakruzz/42-add-releasenotes"},
+		{Subject: "feat: add flag (#50)"},
+		{Subject: "Merge branch '43-fix-typo'"},
+		{Subject: "Merge remote-tracking branch 'origin/44-docs' into main"},
+		{Subject: "Merge pull request #49 from lakruzz/dependabot-go"},
+		{Subject: "Merge branch 'main' into 45-feature"},
+		{Subject: "Update README"},
+		{Subject: "Merge branch '42-add-releasenotes'"},
+	}
+	want := []Reference{
+		{Kind: Issue, Number: 42},
+		{Kind: PullRequest, Number: 50},
+		{Kind: Issue, Number: 43},
+		{Kind: Issue, Number: 44},
+		{Kind: PullRequest, Number: 49},
+	}
+	if got := References(commits); !reflect.DeepEqual(got, want) {
+		t.Errorf("References() = %v, want %v", got, want)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	cfg := config.ReleaseNotes{
+		Categories: []config.Category{
+			{Title: "Features", Labels: []string{"enhancement"}},
+			{Title: "Fixes", Labels: []string{"bug"}},
+			{Title: "Empty", Labels: []string{"nothing"}},
+		},
+		Other:   "Other",
+		Exclude: []string{"skip-changelog"},
+	}
+	changes := []Change{
+		{Reference: Reference{Kind: Issue, Number: 7}, Title: "Fix crash", Labels: []string{"Bug"}},
+		{Reference: Reference{Kind: Issue, Number: 3}, Title: "Add command", Labels: []string{"enhancement", "bug"}},
+		{Reference: Reference{Kind: Issue, Number: 5}, Title: "Another feature", Labels: []string{"enhancement"}},
+		{Reference: Reference{Kind: PullRequest, Number: 9}, Title: "Bump deps", Labels: []string{"skip-changelog"}},
+		{Reference: Reference{Kind: PullRequest, Number: 8}, Title: "Tidy up"},
+	}
+
+	want := `## Features
+
+- Add command (#3)
+- Another feature (#5)
+
+## Fixes
+
+- Fix crash (#7)
+
+## Other
+
+- Tidy up (#8)
+`
+	if got := Markdown(changes, cfg); got != want {
+		t.Errorf("Markdown() =\n%s\nwant\n%s", got, want)
+	}
+
+	cfg.Other = ""
+	if got := Markdown(changes[3:], cfg); got != "No notable changes.\n" {
+		t.Errorf("Markdown() = %q, want no notable changes", got)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	r := runner.NewReplayer(
+		runner.Interaction{Name: "git", Args: []string{"tag", "--list"}, Stdout: "v1.0.0\nv1.1.0\nv1.2.0-rc.1\n"},
+		runner.Interaction{
+			Name:   "git",
+			Args:   []string{"log", "--format=%H%x1f%s%x1f%b%x1e", "v1.1.0..HEAD"},
+			Stdout: "a\x1fMerge pull request #12 from lakruzz/10-add-widget\x1f\x1e\nb\x1ffix: handle nil (#11)\x1f\x1e\n",
+		},
+		runner.Interaction{
+			Name:   "gh",
+			Args:   []string{"issue", "view", "10", "--json", "number,title,labels,url"},
+			Stdout: `{"number":10,"title":"Add widget","labels":[{"name":"enhancement"}],"url":"https://github.com/o/r/issues/10"}`,
+		},
+		runner.Interaction{
+			Name:   "gh",
+			Args:   []string{"pr", "view", "11", "--json", "number,title,labels,url,headRefName"},
+			Stdout: `{"number":11,"title":"Handle nil","labels":[{"name":"bug"}],"url":"https://github.com/o/r/pull/11","headRefName":"fix-nil"}`,
+		},
+	)
+
+	output := filepath.Join(t.TempDir(), "notes.md")
+	err := Generate(context.Background(), Options{Output: output, Config: &config.Default().ReleaseNotes, Runner: r})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if err := r.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "## 🚀 Features\n\n- Add widget (#10)\n\n## 🐛 Bug Fixes\n\n- Handle nil (#11)\n"
+	if string(got) != want {
+		t.Errorf("notes =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGeneratePullRequestFromIssueBranch(t *testing.T) {
+	r := runner.NewReplayer(
+		runner.Interaction{
+			Name:   "git",
+			Args:   []string{"log", "--format=%H%x1f%s%x1f%b%x1e", "v1.0.0..v1.1.0"},
+			Stdout: "a\x1ffeat: squashed (#21)\x1f\x1e\n",
+		},
+		runner.Interaction{
+			Name:   "gh",
+			Args:   []string{"pr", "view", "21", "--json", "number,title,labels,url,headRefName"},
+			Stdout: `{"number":21,"title":"Squashed","labels":[],"headRefName":"20-the-issue"}`,
+		},
+		runner.Interaction{
+			Name:   "gh",
+			Args:   []string{"issue", "view", "20", "--json", "number,title,labels,url"},
+			Stdout: `{"number":20,"title":"The issue","labels":[]}`,
+		},
+	)
+
+	got, err := Render(context.Background(), Options{From: "v1.0.0", To: "v1.1.0", Config: &config.Default().ReleaseNotes, Runner: r})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "## Other Changes\n\n- The issue (#20)\n"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
@@ -1,28 +1,195 @@
 package cmd
 
 import (
-	"fmt"
+	"context"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/lakruzz/gh-utils/internal/audit"
+	"github.com/lakruzz/gh-utils/internal/config"
+	"github.com/lakruzz/gh-utils/internal/ghhost"
+	"github.com/lakruzz/gh-utils/internal/runner"
 	"github.com/spf13/cobra"
 )
 
+var (
+	timeout       time.Duration
+	cancelTimeout context.CancelFunc = func() {}
+
+	recordFile  string
+	replayFile  string
+	errorFormat string
+	hostname    string
+	// host is the host gh runs against, from --hostname or the configuration;
+	// "" leaves it to gh.
+	host string
+
+	// hosts is the host configuration of .utils.yml, loaded with the runner.
+	hosts config.Hosts
+	// secretRules are the custom secret scanner rules of .utils.yml.
+	secretRules config.Secrets
+	// mentions is the mention policy of .utils.yml.
+	mentions config.Mentions
+	// releaseNotesConfig and trunkWorthyConfig are the sections of .utils.yml
+	// for the commands of the same name.
+	releaseNotesConfig config.ReleaseNotes
+	trunkWorthyConfig  config.TrunkWorthy
+
+	// started is set once a subcommand is about to run; errors before that
+	// point come from parsing the command line.
+	started bool
+
+	// commandRunner executes every gh and git invocation made by the subcommands.
+	// It is set up by the root command before any subcommand runs.
+	commandRunner runner.Runner
+	recorder      *runner.Recorder
+	replayer      *runner.Replayer
+	// auditor collects the changes made on GitHub for the audit log; replayed
+	// sessions change nothing and aren't audited.
+	auditor *audit.Recorder
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "utils",
 	Short: "GitHub utilities extension",
 	Long: `A collection of utilities for GitHub workflows and automation.
 This is a GitHub CLI extension that provides additional commands
 to enhance your GitHub workflow.`,
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		started = true
+		// From here on errors are reported by Execute; the usage is not repeated
+		cmd.SilenceUsage = true
+		if errorFormat != "text" && errorFormat != "json" {
+			return validationError("invalid --error-format '%s': must be 'text' or 'json'", errorFormat)
+		}
+		if timeout < 0 {
+			return validationError("--timeout must not be negative")
+		}
+		if timeout > 0 {
+			ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+			cancelTimeout = cancel
+			cmd.SetContext(ctx)
+		}
+		return setupRunner()
+	},
+}
+
+// setupRunner selects the runner for this invocation: recorded sessions are
+// replayed with --replay, and real sessions are saved with --record.
+func setupRunner() error {
+	if recordFile != "" && replayFile != "" {
+		return validationError("cannot use both --record and --replay flags together")
+	}
+
+	commandRunner, recorder, replayer, auditor = runner.Default(), nil, nil, nil
+	if replayFile != "" {
+		r, err := runner.LoadReplayer(replayFile)
+		if err != nil {
+			return err
+		}
+		replayer = r
+		commandRunner = r
+	} else {
+		auditor = audit.NewRecorder(commandRunner)
+		commandRunner = auditor
+	}
+	if recordFile != "" {
+		recorder = runner.NewRecorder(commandRunner)
+		commandRunner = recorder
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return validationError("%v", err)
+	}
+	hosts, secretRules, mentions = cfg.Hosts, cfg.Secrets, cfg.Mentions
+	releaseNotesConfig, trunkWorthyConfig = cfg.ReleaseNotes, cfg.TrunkWorthy
+	host = hosts.Default
+	if hostname != "" {
+		host = hosts.Resolve(hostname)
+		if !ghhost.ValidHost(host) {
+			return validationError("invalid --hostname '%s'", hostname)
+		}
+	}
+	// Outermost, so recordings show the host each command ran against
+	if host != "" {
+		commandRunner = ghhost.WithHost(commandRunner, host)
+	}
+	return nil
+}
+
+// hostRepo checks a --repo value in OWNER/REPO or HOST/OWNER/REPO format and
+// resolves a host alias. The host must agree with --hostname.
+func hostRepo(repo string) (string, error) {
+	if repo == "" {
+		return "", nil
+	}
+	r, err := ghhost.ParseRepo(repo)
+	if err != nil {
+		return "", validationError("invalid --repo '%s': %v", repo, err)
+	}
+	if r.Host == "" {
+		return repo, nil
+	}
+	r.Host = hosts.Resolve(r.Host)
+	if hostname != "" && hosts.Resolve(hostname) != r.Host {
+		return "", validationError("--repo '%s' is not on --hostname '%s'", repo, hostname)
+	}
+	return r.String(), nil
+}
+
+// finishRunner saves the recording or checks that the whole replay was used.
+func finishRunner() error {
+	if recorder != nil {
+		if err := recorder.Save(recordFile); err != nil {
+			return err
+		}
+	}
+	if replayer != nil {
+		return replayer.Verify()
+	}
+	return nil
+}
+
+// execute runs the root command with args and returns the first error.
+func execute(ctx context.Context, args []string) error {
+	started = false
+	auditor = nil
+	rootCmd.SetArgs(args)
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	cancelTimeout()
+	if err != nil && !started {
+		err = usageError(err)
+	}
+	if finishErr := finishRunner(); err == nil {
+		err = finishErr
+	}
+	recordAudit(cmd, args, err)
+	return err
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
+// Interrupt and termination signals cancel the command context, so running gh and
+// git processes are killed and temporary files are cleaned up before exiting.
+// The exit code tells the kind of failure, see mkissue.ExitCode.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := execute(ctx, os.Args[1:])
+	stop()
+	if err != nil {
+		os.Exit(reportError(os.Stderr, err, errorFormat))
 	}
 }
 
 func init() {
-	// Global flags can be added here
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "Abort the run when it takes longer than this duration, e.g. 30s or 5m (default no timeout)")
+	rootCmd.PersistentFlags().StringVar(&recordFile, "record", "", "Record all gh and git interactions to a fixture file")
+	rootCmd.PersistentFlags().StringVar(&replayFile, "replay", "", "Replay gh and git interactions from a fixture file instead of running them")
+	rootCmd.PersistentFlags().StringVar(&errorFormat, "error-format", "text", "Format of error output on stderr: text or json")
+	rootCmd.PersistentFlags().StringVar(&hostname, "hostname", "", "GitHub host to run against, e.g. a GitHub Enterprise Server host or an alias from "+config.FileName)
+	_ = rootCmd.PersistentFlags().MarkHidden("record")
+	_ = rootCmd.PersistentFlags().MarkHidden("replay")
 }
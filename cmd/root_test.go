@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// run executes the root command like Execute does, starting from default flag values.
+func run(t *testing.T, args ...string) error {
+	t.Helper()
+	resetFlags(rootCmd)
+	return execute(context.Background(), args)
+}
+
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		_ = f.Value.Set(f.DefValue)
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+func TestMkissueReplay(t *testing.T) {
+	testdata := filepath.Join("mkissue", "testdata")
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name: "recorded session",
+			args: []string{"mkissue", "--file", filepath.Join(testdata, "labels.issue.md"),
+				"--replay", filepath.Join(testdata, "labels.json")},
+		},
+		{
+			name: "unexpected commands fail the run",
+			args: []string{"mkissue", "--file", filepath.Join(testdata, "labels.issue.md"),
+				"--replay", filepath.Join(testdata, "repo-not-found.json")},
+			wantErr: "unexpected command",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := run(t, tt.args...)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("execute() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("execute() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReportError(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		format   string
+		wantCode int
+		wantOut  string
+	}{
+		{
+			name:     "unknown flag is a usage error",
+			args:     []string{"mkissue", "--no-such-flag"},
+			format:   "text",
+			wantCode: mkissue.ExitValidation,
+			wantOut:  "unknown flag",
+		},
+		{
+			name:     "conflicting flags",
+			args:     []string{"mkissue", "--file", "x.md", "--branch", "b", "--gist", "g"},
+			format:   "json",
+			wantCode: mkissue.ExitValidation,
+			wantOut:  `"kind":"validation","exit_code":2`,
+		},
+		{
+			name:     "missing file",
+			args:     []string{"mkissue", "--file", "does-not-exist.md", "--replay", filepath.Join("mkissue", "testdata", "empty.json")},
+			format:   "json",
+			wantCode: mkissue.ExitSourceNotFound,
+			wantOut:  `"kind":"source_not_found"`,
+		},
+		{
+			name:     "partial failure lists applied changes",
+			args:     []string{"mkissue", "--file", filepath.Join("mkissue", "testdata", "labels.issue.md"), "--replay", filepath.Join("mkissue", "testdata", "labels-issue-fails.json"), "--keep-partial"},
+			format:   "json",
+			wantCode: mkissue.ExitPartial,
+			wantOut:  `"completed":["created label 'needs-triage'"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := run(t, tt.args...)
+			if err == nil {
+				t.Fatalf("execute() expected error")
+			}
+			var out bytes.Buffer
+			code := reportError(&out, err, tt.format)
+			if code != tt.wantCode {
+				t.Errorf("reportError() = %d, want %d (error: %v)", code, tt.wantCode, err)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("reportError() wrote %q, want it to contain %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
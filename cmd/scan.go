@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/lakruzz/gh-utils/internal/secrets"
+	"github.com/spf13/cobra"
+)
+
+var scanCmd = &cobra.Command{
+	Use:   "scan <file>...",
+	Short: "Scan files for tokens, keys and other secrets",
+	Long: `Scan files, such as issue files, for possible secrets before they are
+published, and report each finding with its line. Use - to scan stdin.
+
+The built-in rules find GitHub tokens (ghp_, gho_, ghu_, ghs_, ghr_ and
+github_pat_), AWS access and secret keys, private keys, JSON Web Tokens and
+high-entropy strings. Add rules and allow known false positives in the
+secrets section of .utils.yml:
+
+  secrets:
+    patterns:
+      - name: Acme token
+        regex: "acme_[a-z0-9]{32}"
+    allow:
+      - "EXAMPLE"
+
+The command fails when it finds anything. 'utils mkissue' runs the same
+scan on the title, body and comments of every issue it creates.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scanner, err := secrets.New(secretRules)
+		if err != nil {
+			return validationError("%v", err)
+		}
+		found := 0
+		for _, file := range args {
+			var data []byte
+			if file == "-" {
+				data, err = io.ReadAll(cmd.InOrStdin())
+			} else {
+				data, err = os.ReadFile(file)
+			}
+			if err != nil {
+				return validationError("failed to read '%s': %v", file, err)
+			}
+			for _, f := range scanner.Scan(file, string(data)) {
+				fmt.Fprintln(cmd.OutOrStdout(), f)
+				found++
+			}
+		}
+		if found > 0 {
+			return validationError("found %d possible secret(s)", found)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "No secrets found")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(scanCmd)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/config"
+)
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "utils.yml")
+	clean := filepath.Join(dir, "clean.md")
+	leaky := filepath.Join(dir, "leaky.md")
+	for file, content := range map[string]string{
+		cfg:   "secrets:\n  patterns:\n    - name: Acme token\n      regex: \"acme_[a-z0-9]{8}\"\n",
+		clean: "Nothing to see\n",
+		leaky: "Output:\n\n    token acme_0a1b2c3d\n",
+	} {
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv(config.EnvFile, cfg)
+	var out bytes.Buffer
+	scanCmd.SetOut(&out)
+	t.Cleanup(func() { scanCmd.SetOut(nil) })
+
+	if err := run(t, "scan", clean); err != nil {
+		t.Errorf("scan clean error = %v", err)
+	}
+
+	out.Reset()
+	err := run(t, "scan", clean, leaky)
+	if !errors.Is(err, mkissue.ErrValidation) {
+		t.Errorf("scan error = %v, want a validation error", err)
+	}
+	if want := leaky + ":3: Acme token (acme********)\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	if err := run(t, "scan", filepath.Join(dir, "missing.md")); err == nil || !strings.Contains(err.Error(), "failed to read") {
+		t.Errorf("scan missing error = %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/lakruzz/gh-utils/cmd/stable"
+	"github.com/spf13/cobra"
+)
+
+var (
+	stableTag    string
+	stableRemote string
+	stableRepo   string
+)
+
+var stableCmd = &cobra.Command{
+	Use:   "stable",
+	Short: "Manage the moving 'stable' tag",
+	Long: `Manage the moving 'stable' tag.
+
+The tag is annotated and its message lists the previous positions, so the
+history survives moving the tag. Only the tag itself is pushed.`,
+}
+
+var stableMarkCmd = &cobra.Command{
+	Use:   "mark <ref>",
+	Short: "Move the stable tag to a commit on the default branch with passing statuses",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := stableOptions()
+		repo, err := hostRepo(opts.Repo)
+		if err != nil {
+			return err
+		}
+		opts.Repo = repo
+		return stable.Mark(cmd.Context(), args[0], opts)
+	},
+}
+
+var stableHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List the current and previous positions of the stable tag",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		history, err := stable.History(cmd.Context(), stableOptions())
+		if err != nil {
+			return err
+		}
+		if len(history) == 0 {
+			fmt.Printf("'%s' has not been marked yet\n", stableTag)
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for i, p := range history {
+			marked := "unknown"
+			if !p.MarkedAt.IsZero() {
+				marked = p.MarkedAt.Local().Format("2006-01-02 15:04")
+			}
+			current := ""
+			if i == 0 {
+				current = "(current)"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i, p.SHA, marked, current)
+		}
+		return w.Flush()
+	},
+}
+
+var stableRollbackCmd = &cobra.Command{
+	Use:   "rollback [<steps>|<sha>]",
+	Short: "Move the stable tag back to a previous position",
+	Long: `Move the stable tag back to a previous position: the number of positions
+to go back as listed by 'utils stable history' (default 1), or a commit
+from the history. Rolling back is recorded in the history like any move.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		to := ""
+		if len(args) > 0 {
+			to = args[0]
+		}
+		return stable.Rollback(cmd.Context(), to, stableOptions())
+	},
+}
+
+func stableOptions() stable.Options {
+	return stable.Options{Tag: stableTag, Remote: stableRemote, Repo: stableRepo, Runner: commandRunner}
+}
+
+func init() {
+	rootCmd.AddCommand(stableCmd)
+	stableCmd.AddCommand(stableMarkCmd, stableHistoryCmd, stableRollbackCmd)
+
+	// Define flags for stable commands
+	stableCmd.PersistentFlags().StringVar(&stableTag, "tag", stable.DefaultTag, "Name of the moving tag")
+	stableCmd.PersistentFlags().StringVar(&stableRemote, "remote", "origin", "Remote to fetch the tag from and push it to")
+	stableMarkCmd.Flags().StringVarP(&stableRepo, "repo", "r", "", "Repository to check statuses in, in owner/repo or host/owner/repo format (defaults to the current repository)")
+}
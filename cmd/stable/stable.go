@@ -0,0 +1,301 @@
+// Package stable manages the moving 'stable' tag. The tag is annotated, and
+// its message records the previous positions, so the history survives the
+// tag being moved and can be rolled back.
+package stable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/cmd/status"
+	"github.com/lakruzz/gh-utils/cmd/workon"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// DefaultTag is the name of the moving tag.
+const DefaultTag = "stable"
+
+// maxHistory bounds the number of previous positions kept in the tag message.
+const maxHistory = 50
+
+// Options control which tag is managed and where it is pushed.
+type Options struct {
+	// Tag is the moving tag; "" is DefaultTag.
+	Tag string
+	// Remote is the remote the tag is fetched from and pushed to.
+	Remote string
+	// Repo is the GitHub repository in owner/repo or host/owner/repo format, for checking
+	// statuses; "" uses $GITHUB_REPOSITORY or the current repository.
+	Repo string
+	// Runner executes every gh and git invocation; nil uses runner.Default().
+	Runner runner.Runner
+}
+
+// Position is a commit the tag pointed at.
+type Position struct {
+	SHA      string
+	MarkedAt time.Time
+}
+
+// state is the current tag and its history.
+type state struct {
+	// object is the tag object, to restore the tag exactly.
+	object  string
+	history []Position
+}
+
+func (o *Options) defaults() {
+	if o.Tag == "" {
+		o.Tag = DefaultTag
+	}
+	if o.Remote == "" {
+		o.Remote = "origin"
+	}
+}
+
+// Mark moves the tag to ref after checking that ref is on the default branch
+// and its commit statuses passed, and pushes only the tag.
+func Mark(ctx context.Context, ref string, opts Options) error {
+	opts.defaults()
+	s := mkissue.NewSession(opts.Runner)
+
+	current, err := fetch(ctx, s, opts)
+	if err != nil {
+		return err
+	}
+	sha, err := resolve(ctx, s, ref)
+	if err != nil {
+		return err
+	}
+	if len(current.history) > 0 && current.history[0].SHA == sha {
+		fmt.Printf("'%s' already points at %s\n", opts.Tag, short(sha))
+		return nil
+	}
+
+	defaultBranch, err := workon.DefaultBranch(ctx, s, opts.Remote)
+	if err != nil {
+		return err
+	}
+	// Check against the default branch as it is on the remote, not as it
+	// was last fetched
+	base := opts.Remote + "/" + defaultBranch
+	refspec := "+refs/heads/" + defaultBranch + ":refs/remotes/" + base
+	if _, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"fetch", "--quiet", opts.Remote, refspec}}); err != nil {
+		return fmt.Errorf("failed to fetch '%s': %w", base, err)
+	}
+	if _, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"merge-base", "--is-ancestor", sha, base}}); err != nil {
+		var exitErr *runner.ExitError
+		if errors.As(err, &exitErr) && exitErr.Result.ExitCode == 1 {
+			return &mkissue.Error{Kind: mkissue.ErrValidation, Err: fmt.Errorf("'%s' is not on the default branch '%s'", ref, defaultBranch)}
+		}
+		return fmt.Errorf("failed to check '%s' against '%s': %w", ref, base, err)
+	}
+
+	target, err := status.FindTarget(ctx, s, opts.Repo, sha)
+	if err != nil {
+		return err
+	}
+	combined, err := status.List(ctx, s, target)
+	if err != nil {
+		return err
+	}
+	if combined.State != status.Success || len(combined.Statuses) == 0 {
+		return &mkissue.Error{Kind: mkissue.ErrValidation, Err: fmt.Errorf("'%s' has no passing commit statuses (%s)", ref, describe(combined))}
+	}
+
+	return move(ctx, s, opts, current, sha)
+}
+
+// Rollback moves the tag back to a previous position: to is the number of
+// positions to go back (1 is the previous one) or a commit in the history.
+func Rollback(ctx context.Context, to string, opts Options) error {
+	opts.defaults()
+	s := mkissue.NewSession(opts.Runner)
+
+	current, err := fetch(ctx, s, opts)
+	if err != nil {
+		return err
+	}
+	if to == "" {
+		to = "1"
+	}
+	position, err := pick(current.history, to)
+	if err != nil {
+		return &mkissue.Error{Kind: mkissue.ErrValidation, Err: err}
+	}
+	if position.SHA == current.history[0].SHA {
+		fmt.Printf("'%s' already points at %s\n", opts.Tag, short(position.SHA))
+		return nil
+	}
+	return move(ctx, s, opts, current, position.SHA)
+}
+
+// History returns the positions of the tag, the current one first.
+func History(ctx context.Context, opts Options) ([]Position, error) {
+	opts.defaults()
+	current, err := fetch(ctx, mkissue.NewSession(opts.Runner), opts)
+	if err != nil {
+		return nil, err
+	}
+	return current.history, nil
+}
+
+// pick finds a position by how many steps back it is, or by commit SHA prefix.
+func pick(history []Position, to string) (Position, error) {
+	if len(history) < 2 {
+		return Position{}, errors.New("there is no previous position to roll back to")
+	}
+	if n, err := strconv.Atoi(to); err == nil && len(to) < 4 {
+		if n < 1 || n >= len(history) {
+			return Position{}, fmt.Errorf("can only roll back 1 to %d positions", len(history)-1)
+		}
+		return history[n], nil
+	}
+	for _, p := range history[1:] {
+		if strings.HasPrefix(p.SHA, to) {
+			return p, nil
+		}
+	}
+	return Position{}, fmt.Errorf("'%s' is not a previous position", to)
+}
+
+// fetch updates the local tag from the remote and reads its history.
+func fetch(ctx context.Context, s *mkissue.Session, opts Options) (state, error) {
+	ref := "refs/tags/" + opts.Tag
+	output, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"ls-remote", opts.Remote, ref}})
+	if err != nil {
+		return state{}, fmt.Errorf("failed to read '%s' from '%s': %w", opts.Tag, opts.Remote, err)
+	}
+	if strings.TrimSpace(string(output)) == "" {
+		return state{}, nil
+	}
+	if _, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"fetch", "--quiet", opts.Remote, "+" + ref + ":" + ref}}); err != nil {
+		return state{}, fmt.Errorf("failed to fetch '%s': %w", opts.Tag, err)
+	}
+
+	output, err = s.Run(ctx, runner.Command{
+		Name: "git",
+		Args: []string{"for-each-ref", "--format=%(objectname)%00%(objecttype)%00%(*objectname)%00%(taggerdate:iso-strict)%00%(contents)", ref},
+	})
+	if err != nil {
+		return state{}, fmt.Errorf("failed to read '%s': %w", opts.Tag, err)
+	}
+	fields := strings.SplitN(strings.TrimSuffix(string(output), "\n"), "\x00", 5)
+	if len(fields) < 5 {
+		return state{}, nil
+	}
+	if fields[1] != "tag" {
+		// A lightweight tag, e.g. made by the old mark-stable alias, has no history
+		return state{object: fields[0], history: []Position{{SHA: fields[0]}}}, nil
+	}
+	markedAt, _ := time.Parse(time.RFC3339, fields[3])
+	history := append([]Position{{SHA: fields[2], MarkedAt: markedAt}}, parseMessage(fields[4])...)
+	return state{object: fields[0], history: history}, nil
+}
+
+// move points the tag at sha, records the previous positions in its message
+// and pushes it, unless the tag on the remote changed since it was fetched.
+// The local tag is restored when the push fails.
+func move(ctx context.Context, s *mkissue.Session, opts Options, current state, sha string) error {
+	message := formatMessage(sha, current.history)
+	if _, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"tag", "--force", "--annotate", opts.Tag, sha, "--message", message}}); err != nil {
+		return fmt.Errorf("failed to move '%s': %w", opts.Tag, err)
+	}
+	undo := runner.Command{Name: "git", Args: []string{"tag", "--delete", opts.Tag}}
+	if current.object != "" {
+		undo = runner.Command{Name: "git", Args: []string{"update-ref", "refs/tags/" + opts.Tag, current.object}}
+	}
+	s.Record(fmt.Sprintf("moved local tag '%s'", opts.Tag), undo)
+
+	ref := "refs/tags/" + opts.Tag
+	// An empty lease requires that the tag still doesn't exist
+	lease := "--force-with-lease=" + ref + ":" + current.object
+	if _, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"push", lease, opts.Remote, ref}, Mutating: true}); err != nil {
+		if strings.Contains(runner.Stderr(err), "stale info") {
+			err = &mkissue.Error{Kind: mkissue.ErrValidation, Err: fmt.Errorf("'%s' was moved on '%s' since it was fetched; run again to move it from its new position", opts.Tag, opts.Remote)}
+		} else {
+			err = fmt.Errorf("failed to push '%s': %w", opts.Tag, err)
+		}
+		return s.Fail(ctx, err, false)
+	}
+	fmt.Printf("Moved '%s' to %s\n", opts.Tag, short(sha))
+	return nil
+}
+
+const historyHeader = "Previous positions:"
+
+// formatMessage writes the tag message: the new position followed by the
+// previous ones with the time they were marked.
+func formatMessage(sha string, history []Position) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Stable: %s\n", sha)
+	if len(history) > maxHistory {
+		history = history[:maxHistory]
+	}
+	if len(history) > 0 {
+		b.WriteString("\n" + historyHeader + "\n")
+		for _, p := range history {
+			b.WriteString(p.SHA)
+			if !p.MarkedAt.IsZero() {
+				b.WriteString(" " + p.MarkedAt.UTC().Format(time.RFC3339))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// parseMessage reads the previous positions from a tag message.
+func parseMessage(message string) []Position {
+	_, list, ok := strings.Cut(message, historyHeader)
+	if !ok {
+		return nil
+	}
+	var history []Position
+	for _, line := range strings.Split(list, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		p := Position{SHA: fields[0]}
+		if len(fields) > 1 {
+			p.MarkedAt, _ = time.Parse(time.RFC3339, fields[1])
+		}
+		history = append(history, p)
+	}
+	return history
+}
+
+func resolve(ctx context.Context, s *mkissue.Session, ref string) (string, error) {
+	output, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"rev-parse", "--verify", "--quiet", ref + "^{commit}"}})
+	if err != nil {
+		return "", &mkissue.Error{Kind: mkissue.ErrValidation, Err: fmt.Errorf("'%s' is not a commit", ref)}
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// describe summarizes the statuses that keep a commit from being stable.
+func describe(c status.Combined) string {
+	if len(c.Statuses) == 0 {
+		return "no statuses"
+	}
+	var parts []string
+	for _, st := range c.Statuses {
+		if st.State != status.Success {
+			parts = append(parts, st.Context+": "+st.State)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func short(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
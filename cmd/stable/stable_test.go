@@ -0,0 +1,252 @@
+package stable
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+// repo is a clone of a bare remote, see testutil.NewRepo.
+type repo struct {
+	*testutil.Repo
+}
+
+// newRepo creates a repository with commits on main, checked against the
+// statuses of o/r, and returns their SHAs.
+func newRepo(t *testing.T, commits int) (*repo, []string) {
+	r, shas := testutil.NewRepo(t, commits)
+	t.Setenv("GITHUB_REPOSITORY", "o/r")
+	return &repo{r}, shas
+}
+
+// remoteTag returns the commit the tag points at on the remote.
+func (r *repo) remoteTag() string {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", "stable^{commit}")
+	cmd.Dir = r.Remote
+	output, _ := cmd.Output()
+	return strings.TrimSpace(string(output))
+}
+
+// withStatuses runs git for real and answers the combined status API with
+// success for the passing commits and failure for any other commit.
+func withStatuses(passing ...string) runner.Runner {
+	return runner.Func(func(ctx context.Context, cmd runner.Command) (runner.Result, error) {
+		if cmd.Name != "gh" {
+			return runner.Exec{}.Run(ctx, cmd)
+		}
+		for _, sha := range passing {
+			if cmd.Args[1] == "repos/o/r/commits/"+sha+"/status" {
+				return runner.Result{Stdout: []byte(`{"state":"success","statuses":[{"state":"success","context":"build"}]}`)}, nil
+			}
+		}
+		return runner.Result{Stdout: []byte(`{"state":"failure","statuses":[{"state":"failure","context":"build"},{"state":"success","context":"lint"}]}`)}, nil
+	})
+}
+
+func shas(history []Position) []string {
+	var list []string
+	for _, p := range history {
+		list = append(list, p.SHA)
+	}
+	return list
+}
+
+func TestMarkHistoryAndRollback(t *testing.T) {
+	r, commits := newRepo(t, 3)
+	opts := Options{Runner: withStatuses(commits...)}
+	ctx := context.Background()
+
+	for _, sha := range commits {
+		if err := Mark(ctx, sha, opts); err != nil {
+			t.Fatalf("Mark(%s) error = %v", sha, err)
+		}
+	}
+	if got := r.remoteTag(); got != commits[2] {
+		t.Fatalf("remote stable = %s, want %s", got, commits[2])
+	}
+
+	history, err := History(ctx, opts)
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	want := []string{commits[2], commits[1], commits[0]}
+	if !reflect.DeepEqual(shas(history), want) {
+		t.Errorf("History() = %v, want %v", shas(history), want)
+	}
+	for _, p := range history {
+		if time.Since(p.MarkedAt) > time.Hour {
+			t.Errorf("position %s has no marked time: %v", p.SHA, p.MarkedAt)
+		}
+	}
+
+	// Only the stable tag is pushed
+	if tags := strings.Fields(r.Git("ls-remote", "--tags", "--refs", "origin")); len(tags) != 2 {
+		t.Errorf("remote tags = %v, want only stable", tags)
+	}
+
+	if err := Rollback(ctx, "", opts); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if got := r.remoteTag(); got != commits[1] {
+		t.Errorf("remote stable after rollback = %s, want %s", got, commits[1])
+	}
+	if err := Rollback(ctx, commits[0][:8], opts); err != nil {
+		t.Fatalf("Rollback(sha) error = %v", err)
+	}
+	if got := r.remoteTag(); got != commits[0] {
+		t.Errorf("remote stable after rollback = %s, want %s", got, commits[0])
+	}
+
+	history, err = History(ctx, opts)
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	want = []string{commits[0], commits[1], commits[2], commits[1], commits[0]}
+	if !reflect.DeepEqual(shas(history), want) {
+		t.Errorf("History() = %v, want %v", shas(history), want)
+	}
+}
+
+func TestMarkRefusals(t *testing.T) {
+	r, commits := newRepo(t, 2)
+	r.Git("switch", "--quiet", "-c", "feature")
+	r.Git("commit", "--quiet", "--allow-empty", "-m", "not on main")
+	feature := r.Git("rev-parse", "HEAD")
+
+	tests := []struct {
+		name string
+		ref  string
+	}{
+		{name: "not on the default branch", ref: feature},
+		{name: "failing statuses", ref: commits[0]},
+		{name: "not a commit", ref: "no-such-ref"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Mark(context.Background(), tt.ref, Options{Runner: withStatuses(commits[1], feature)})
+			if !errors.Is(err, mkissue.ErrValidation) {
+				t.Errorf("Mark() error = %v, want validation error", err)
+			}
+			if got := r.remoteTag(); got != "" {
+				t.Errorf("remote stable = %s, want no tag", got)
+			}
+		})
+	}
+}
+
+func TestMarkFetchesDefaultBranch(t *testing.T) {
+	r, commits := newRepo(t, 1)
+	r.Git("switch", "--quiet", "-c", "feature")
+	r.Git("commit", "--quiet", "--allow-empty", "-m", "merged on GitHub")
+	merged := r.Git("rev-parse", "HEAD")
+	r.Git("push", "--quiet", "origin", "feature")
+	// Merged on the remote since the clone last fetched main
+	r.Git("--git-dir", r.Remote, "update-ref", "refs/heads/main", merged, commits[0])
+
+	if err := Mark(context.Background(), merged, Options{Runner: withStatuses(merged)}); err != nil {
+		t.Fatalf("Mark() error = %v", err)
+	}
+	if got := r.remoteTag(); got != merged {
+		t.Errorf("remote stable = %s, want %s", got, merged)
+	}
+}
+
+func TestMarkRestoresLocalTagWhenPushFails(t *testing.T) {
+	r, commits := newRepo(t, 2)
+	ctx := context.Background()
+	if err := Mark(ctx, commits[0], Options{Runner: withStatuses(commits...)}); err != nil {
+		t.Fatalf("Mark() error = %v", err)
+	}
+	before := r.Git("rev-parse", "refs/tags/stable")
+
+	rejectPush := runner.Func(func(ctx context.Context, cmd runner.Command) (runner.Result, error) {
+		if cmd.Name == "git" && cmd.Args[0] == "push" {
+			res := runner.Result{Stderr: []byte("remote rejected"), ExitCode: 1}
+			return res, &runner.ExitError{Command: cmd, Result: res}
+		}
+		return withStatuses(commits...).Run(ctx, cmd)
+	})
+	if err := Mark(ctx, commits[1], Options{Runner: rejectPush}); err == nil {
+		t.Fatal("Mark() expected error")
+	}
+	if got := r.Git("rev-parse", "refs/tags/stable"); got != before {
+		t.Errorf("local stable = %s, want it restored to %s", got, before)
+	}
+}
+
+func TestMarkKeepsTagMovedMeanwhile(t *testing.T) {
+	for _, marked := range []bool{false, true} {
+		r, commits := newRepo(t, 3)
+		ctx := context.Background()
+		var before string
+		if marked {
+			if err := Mark(ctx, commits[0], Options{Runner: withStatuses(commits...)}); err != nil {
+				t.Fatalf("Mark() error = %v", err)
+			}
+			before = r.Git("rev-parse", "refs/tags/stable")
+		}
+
+		// Another run moves the tag after this one fetched it
+		race := runner.Func(func(ctx context.Context, cmd runner.Command) (runner.Result, error) {
+			if cmd.Name == "git" && cmd.Args[0] == "push" {
+				r.Git("--git-dir", r.Remote, "tag", "--force", "stable", commits[2])
+			}
+			return withStatuses(commits...).Run(ctx, cmd)
+		})
+		err := Mark(ctx, commits[1], Options{Runner: race})
+		if !errors.Is(err, mkissue.ErrValidation) || !strings.Contains(err.Error(), "was moved on 'origin'") {
+			t.Errorf("Mark() error = %v, want the lost lease reported", err)
+		}
+		if got := r.remoteTag(); got != commits[2] {
+			t.Errorf("remote stable = %s, want the other run's %s", got, commits[2])
+		}
+		if marked {
+			if got := r.Git("rev-parse", "refs/tags/stable"); got != before {
+				t.Errorf("local stable = %s, want it restored to %s", got, before)
+			}
+		}
+	}
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	marked := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	history := []Position{{SHA: "bbb", MarkedAt: marked}, {SHA: "aaa"}}
+
+	message := formatMessage("ccc", history)
+	if !strings.HasPrefix(message, "Stable: ccc\n") {
+		t.Errorf("formatMessage() = %q", message)
+	}
+	if got := parseMessage(message); !reflect.DeepEqual(got, history) {
+		t.Errorf("parseMessage() = %+v, want %+v", got, history)
+	}
+}
+
+func TestPick(t *testing.T) {
+	history := []Position{{SHA: "c1"}, {SHA: "b2"}, {SHA: "a3"}}
+	tests := []struct {
+		to      string
+		want    string
+		wantErr bool
+	}{
+		{to: "1", want: "b2"},
+		{to: "2", want: "a3"},
+		{to: "3", wantErr: true},
+		{to: "0", wantErr: true},
+		{to: "a3", want: "a3"},
+		{to: "c1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := pick(history, tt.to)
+		if (err != nil) != tt.wantErr || got.SHA != tt.want {
+			t.Errorf("pick(%q) = %v, %v, want %q, error %v", tt.to, got.SHA, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/cmd/status"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusRepo      string
+	statusSHA       string
+	statusTargetURL string
+	statusJSON      bool
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Set and list commit statuses",
+	Long: `Set and list commit statuses through the GitHub statuses API.
+
+The commit is taken from $GITHUB_SHA and $GITHUB_REPOSITORY in GitHub
+Actions, and otherwise from HEAD of the local checkout and its GitHub
+repository. Use --sha and --repo to pick another commit.`,
+}
+
+var statusSetCmd = &cobra.Command{
+	Use:   "set <state> <description> <context>",
+	Short: "Set a commit status",
+	Long: `Set a commit status. State is one of pending, success, failure or error.
+
+--target-url defaults to the URL of the GitHub Actions run, if any.
+
+Usage:
+  utils status set success "Build check passed" build`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st := status.Status{State: args[0], Description: args[1], Context: args[2], TargetURL: statusTargetURL}
+		if err := st.Validate(); err != nil {
+			return validationError("%v", err)
+		}
+		s := mkissue.NewSession(commandRunner)
+		repo, err := hostRepo(statusRepo)
+		if err != nil {
+			return err
+		}
+		target, err := status.FindTarget(cmd.Context(), s, repo, statusSHA)
+		if err != nil {
+			return err
+		}
+		if err := status.Set(cmd.Context(), s, target, st); err != nil {
+			return err
+		}
+		fmt.Printf("Set status '%s' to %s on %s\n", st.Context, st.State, target.SHA)
+		return nil
+	},
+}
+
+var statusListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the latest status per context of a commit",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		s := mkissue.NewSession(commandRunner)
+		repo, err := hostRepo(statusRepo)
+		if err != nil {
+			return err
+		}
+		target, err := status.FindTarget(cmd.Context(), s, repo, statusSHA)
+		if err != nil {
+			return err
+		}
+		combined, err := status.List(cmd.Context(), s, target)
+		if err != nil {
+			return err
+		}
+		if statusJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(combined)
+		}
+
+		fmt.Printf("%s %s on %s\n", stateIcon(combined.State), combined.State, target.SHA)
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, st := range combined.Statuses {
+			fmt.Fprintf(w, "  %s %s\t%s\t%s\n", stateIcon(st.State), st.Context, st.Description, st.TargetURL)
+		}
+		return w.Flush()
+	},
+}
+
+func stateIcon(state string) string {
+	switch state {
+	case status.Success:
+		return "✅"
+	case status.Pending:
+		return "⏳"
+	}
+	return "❌"
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.AddCommand(statusSetCmd, statusListCmd)
+
+	// Define flags for status commands
+	statusCmd.PersistentFlags().StringVarP(&statusRepo, "repo", "r", "", "Repository in owner/repo or host/owner/repo format (defaults to $GITHUB_REPOSITORY or the current repository)")
+	statusCmd.PersistentFlags().StringVar(&statusSHA, "sha", "", "Commit to use (defaults to $GITHUB_SHA or HEAD)")
+	statusSetCmd.Flags().StringVar(&statusTargetURL, "target-url", status.RunURL(), "URL to link from the status (defaults to the GitHub Actions run)")
+	statusListCmd.Flags().BoolVar(&statusJSON, "json", false, "Print the statuses as JSON")
+}
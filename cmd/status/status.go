@@ -0,0 +1,195 @@
+// Package status sets and reads commit statuses through the GitHub statuses API.
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/ghhost"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// Commit status states accepted by GitHub.
+const (
+	Pending = "pending"
+	Success = "success"
+	Failure = "failure"
+	Error   = "error"
+)
+
+// maxDescription is the longest description GitHub accepts.
+const maxDescription = 140
+
+// Target is the commit statuses are set on.
+type Target struct {
+	// Repo is the repository in owner/repo format.
+	Repo string
+	SHA  string
+	// Host is the GitHub host of the repository; "" leaves it to gh.
+	Host string
+}
+
+// Status is a commit status.
+type Status struct {
+	State       string `json:"state"`
+	Description string `json:"description"`
+	// Context identifies the status, e.g. the name of a check.
+	Context   string `json:"context"`
+	TargetURL string `json:"target_url"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+// Combined is the overall state of a commit and its latest status per context.
+type Combined struct {
+	// State is failure if any status failed or errored, pending if any is
+	// pending or there are none, and success otherwise.
+	State    string   `json:"state"`
+	Statuses []Status `json:"statuses"`
+}
+
+// InActions reports whether utils runs in a GitHub Actions workflow run, as
+// the runner sets $GITHUB_ACTIONS. The run may still lack the other variables.
+func InActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// TargetFromEnv returns the commit of the GitHub Actions run from
+// $GITHUB_REPOSITORY and $GITHUB_SHA, on the host of $GITHUB_SERVER_URL.
+func TargetFromEnv() (Target, bool) {
+	t := Target{Repo: os.Getenv("GITHUB_REPOSITORY"), SHA: os.Getenv("GITHUB_SHA")}
+	if t.Repo != "" {
+		t.Host = ghhost.FromServerURL(os.Getenv("GITHUB_SERVER_URL"))
+	}
+	return t, t.Repo != "" && t.SHA != ""
+}
+
+// RunURL returns the URL of the GitHub Actions run, or "" outside Actions.
+func RunURL() string {
+	server, repo, id := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID")
+	if server == "" || repo == "" || id == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/actions/runs/%s", strings.TrimSuffix(server, "/"), repo, id)
+}
+
+// FindTarget returns the commit to set statuses on. repo (OWNER/REPO or
+// HOST/OWNER/REPO) and sha override the defaults: $GITHUB_REPOSITORY and
+// $GITHUB_SHA in GitHub Actions, otherwise the repository of the local
+// checkout and its HEAD.
+func FindTarget(ctx context.Context, s *mkissue.Session, repo, sha string) (Target, error) {
+	env, _ := TargetFromEnv()
+	host := ""
+	if repo != "" {
+		r, err := ghhost.ParseRepo(repo)
+		if err != nil {
+			return Target{}, &mkissue.Error{Kind: mkissue.ErrValidation, Err: err}
+		}
+		repo, host = r.FullName(), r.Host
+	} else {
+		repo, host = env.Repo, env.Host
+	}
+	if sha == "" {
+		sha = env.SHA
+	}
+
+	if sha == "" {
+		output, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"rev-parse", "HEAD"}})
+		if err != nil {
+			return Target{}, fmt.Errorf("failed to find the commit: %w", err)
+		}
+		sha = strings.TrimSpace(string(output))
+	}
+	if repo == "" {
+		output, err := s.Run(ctx, runner.Command{
+			Name: "gh",
+			Args: []string{"repo", "view", "--json", "nameWithOwner", "--jq", ".nameWithOwner"},
+		})
+		if err != nil {
+			return Target{}, fmt.Errorf("failed to find the repository: %w", err)
+		}
+		repo = strings.TrimSpace(string(output))
+	}
+	return Target{Repo: repo, SHA: sha, Host: host}, nil
+}
+
+// api returns the arguments of a gh api call on the host of the target.
+func (t Target) api(args ...string) []string {
+	return append(append([]string{"api"}, ghhost.Repo{Host: t.Host}.APIArgs()...), args...)
+}
+
+// Validate checks the state and context of st.
+func (st Status) Validate() error {
+	switch st.State {
+	case Pending, Success, Failure, Error:
+	default:
+		return fmt.Errorf("invalid state '%s': must be pending, success, failure or error", st.State)
+	}
+	if st.Context == "" {
+		return fmt.Errorf("status context is required")
+	}
+	return nil
+}
+
+// Set creates st on the target commit.
+func Set(ctx context.Context, s *mkissue.Session, target Target, st Status) error {
+	if err := st.Validate(); err != nil {
+		return &mkissue.Error{Kind: mkissue.ErrValidation, Err: err}
+	}
+	description := st.Description
+	if len([]rune(description)) > maxDescription {
+		description = string([]rune(description)[:maxDescription-1]) + "…"
+	}
+
+	args := target.api(
+		"--method", "POST", fmt.Sprintf("repos/%s/statuses/%s", target.Repo, target.SHA),
+		"-f", "state="+st.State,
+		"-f", "context="+st.Context,
+		"-f", "description="+description,
+	)
+	if st.TargetURL != "" {
+		args = append(args, "-f", "target_url="+st.TargetURL)
+	}
+	// Setting the same status twice is harmless, so failures may be retried;
+	// it's still a change to audit
+	if _, err := s.Run(ctx, runner.Command{Name: "gh", Args: args, Audited: true}); err != nil {
+		return fmt.Errorf("failed to set status '%s' on %s: %w", st.Context, shortSHA(target.SHA), err)
+	}
+	return nil
+}
+
+// List returns the combined state and the latest status per context of the target commit.
+func List(ctx context.Context, s *mkissue.Session, target Target) (Combined, error) {
+	output, err := s.Run(ctx, runner.Command{
+		Name: "gh",
+		Args: target.api(fmt.Sprintf("repos/%s/commits/%s/status", target.Repo, target.SHA)),
+	})
+	if err != nil {
+		return Combined{}, fmt.Errorf("failed to list statuses of %s: %w", shortSHA(target.SHA), err)
+	}
+	var combined Combined
+	if err := json.Unmarshal(output, &combined); err != nil {
+		return Combined{}, fmt.Errorf("failed to parse statuses of %s: %w", shortSHA(target.SHA), err)
+	}
+	return combined, nil
+}
+
+// Find returns the latest status with the given context, if any.
+func (c Combined) Find(context string) (Status, bool) {
+	for _, st := range c.Statuses {
+		if st.Context == context {
+			return st, true
+		}
+	}
+	return Status{}, false
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
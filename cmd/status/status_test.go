@@ -0,0 +1,199 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/audit"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+func clearActionsEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"GITHUB_ACTIONS", "GITHUB_REPOSITORY", "GITHUB_SHA", "GITHUB_ACTION", "GITHUB_RUN_ID", "GITHUB_SERVER_URL"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  Status
+		args    []string
+		wantErr error
+	}{
+		{
+			name:   "with target url",
+			status: Status{State: Success, Description: "Build check passed", Context: "build", TargetURL: "https://example.com/run"},
+			args: []string{"api", "--method", "POST", "repos/o/r/statuses/abc",
+				"-f", "state=success", "-f", "context=build", "-f", "description=Build check passed",
+				"-f", "target_url=https://example.com/run"},
+		},
+		{
+			name:   "long description is truncated",
+			status: Status{State: Pending, Description: strings.Repeat("é", 200), Context: "lint"},
+			args: []string{"api", "--method", "POST", "repos/o/r/statuses/abc",
+				"-f", "state=pending", "-f", "context=lint", "-f", "description=" + strings.Repeat("é", 139) + "…"},
+		},
+		{
+			name:    "invalid state",
+			status:  Status{State: "passed", Context: "build"},
+			wantErr: mkissue.ErrValidation,
+		},
+		{
+			name:    "missing context",
+			status:  Status{State: Success},
+			wantErr: mkissue.ErrValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var interactions []runner.Interaction
+			if tt.args != nil {
+				interactions = append(interactions, runner.Interaction{Name: "gh", Args: tt.args})
+			}
+			r := runner.NewReplayer(interactions...)
+
+			err := Set(context.Background(), mkissue.NewSession(r), Target{Repo: "o/r", SHA: "abc"}, tt.status)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Set() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if err := r.Verify(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestSetIsAudited(t *testing.T) {
+	recorder := audit.NewRecorder(runner.Func(func(context.Context, runner.Command) (runner.Result, error) {
+		return runner.Result{}, nil
+	}))
+	if err := Set(context.Background(), mkissue.NewSession(recorder), Target{Repo: "o/r", SHA: "abc"}, Status{State: Success, Context: "build"}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if got := recorder.Operations(); len(got) != 1 || got[0].Action != "api POST repos/o/r/statuses/abc" {
+		t.Errorf("Operations() = %+v, want the status", got)
+	}
+}
+
+func TestFindTarget(t *testing.T) {
+	t.Run("from actions environment", func(t *testing.T) {
+		t.Setenv("GITHUB_REPOSITORY", "lakruzz/gh-utils")
+		t.Setenv("GITHUB_SHA", "abc")
+		got, err := FindTarget(context.Background(), mkissue.NewSession(runner.NewReplayer()), "", "")
+		if err != nil {
+			t.Fatalf("FindTarget() error = %v", err)
+		}
+		if got != (Target{Repo: "lakruzz/gh-utils", SHA: "abc"}) {
+			t.Errorf("FindTarget() = %+v", got)
+		}
+	})
+
+	t.Run("from local checkout", func(t *testing.T) {
+		clearActionsEnv(t)
+		r := runner.NewReplayer(
+			runner.Interaction{Name: "git", Args: []string{"rev-parse", "HEAD"}, Stdout: "def\n"},
+			runner.Interaction{Name: "gh", Args: []string{"repo", "view", "--json", "nameWithOwner", "--jq", ".nameWithOwner"}, Stdout: "o/r\n"},
+		)
+		got, err := FindTarget(context.Background(), mkissue.NewSession(r), "", "")
+		if err != nil {
+			t.Fatalf("FindTarget() error = %v", err)
+		}
+		if got != (Target{Repo: "o/r", SHA: "def"}) {
+			t.Errorf("FindTarget() = %+v", got)
+		}
+	})
+
+	t.Run("explicit", func(t *testing.T) {
+		t.Setenv("GITHUB_REPOSITORY", "lakruzz/gh-utils")
+		t.Setenv("GITHUB_SHA", "abc")
+		got, err := FindTarget(context.Background(), mkissue.NewSession(runner.NewReplayer()), "o/other", "123")
+		if err != nil {
+			t.Fatalf("FindTarget() error = %v", err)
+		}
+		if got != (Target{Repo: "o/other", SHA: "123"}) {
+			t.Errorf("FindTarget() = %+v", got)
+		}
+	})
+
+	t.Run("explicit on host", func(t *testing.T) {
+		clearActionsEnv(t)
+		got, err := FindTarget(context.Background(), mkissue.NewSession(runner.NewReplayer()), "ghes.example.com/o/r", "123")
+		if err != nil {
+			t.Fatalf("FindTarget() error = %v", err)
+		}
+		if got != (Target{Repo: "o/r", SHA: "123", Host: "ghes.example.com"}) {
+			t.Errorf("FindTarget() = %+v", got)
+		}
+	})
+
+	t.Run("server of actions run", func(t *testing.T) {
+		t.Setenv("GITHUB_REPOSITORY", "o/r")
+		t.Setenv("GITHUB_SHA", "abc")
+		t.Setenv("GITHUB_SERVER_URL", "https://ghes.example.com")
+		got, err := FindTarget(context.Background(), mkissue.NewSession(runner.NewReplayer()), "", "")
+		if err != nil {
+			t.Fatalf("FindTarget() error = %v", err)
+		}
+		if got != (Target{Repo: "o/r", SHA: "abc", Host: "ghes.example.com"}) {
+			t.Errorf("FindTarget() = %+v", got)
+		}
+	})
+
+	t.Run("invalid repo", func(t *testing.T) {
+		_, err := FindTarget(context.Background(), mkissue.NewSession(runner.NewReplayer()), "https://ghes.example.com/o/r", "123")
+		if mkissue.ExitCode(err) != mkissue.ExitCode(&mkissue.Error{Kind: mkissue.ErrValidation}) {
+			t.Errorf("FindTarget() error = %v, want a validation error", err)
+		}
+	})
+}
+
+func TestList(t *testing.T) {
+	r := runner.NewReplayer(runner.Interaction{
+		Name: "gh",
+		Args: []string{"api", "repos/o/r/commits/abc/status"},
+		Stdout: `{"state":"failure","sha":"abc","statuses":[
+			{"state":"success","description":"Build check passed","context":"build","target_url":"https://x/1","updated_at":"2026-01-02T03:04:05Z"},
+			{"state":"failure","description":"Lint check failed","context":"lint","target_url":null}
+		]}`,
+	})
+
+	got, err := List(context.Background(), mkissue.NewSession(r), Target{Repo: "o/r", SHA: "abc"})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if got.State != Failure || len(got.Statuses) != 2 {
+		t.Fatalf("List() = %+v", got)
+	}
+	build, ok := got.Find("build")
+	if !ok || build.State != Success || build.TargetURL != "https://x/1" {
+		t.Errorf("Find(build) = %+v, %v", build, ok)
+	}
+	if _, ok := got.Find("coverage"); ok {
+		t.Error("Find(coverage) found a status that wasn't set")
+	}
+}
+
+func TestRunURL(t *testing.T) {
+	clearActionsEnv(t)
+	if got := RunURL(); got != "" {
+		t.Errorf("RunURL() = %q outside actions, want empty", got)
+	}
+	t.Setenv("GITHUB_SERVER_URL", "https://github.example.com/")
+	t.Setenv("GITHUB_REPOSITORY", "o/r")
+	t.Setenv("GITHUB_RUN_ID", "7")
+	if got, want := RunURL(), "https://github.example.com/o/r/actions/runs/7"; got != want {
+		t.Errorf("RunURL() = %q, want %q", got, want)
+	}
+}
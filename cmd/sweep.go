@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"github.com/lakruzz/gh-utils/cmd/sweep"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sweepRemote string
+	sweepForce  bool
+	sweepDryRun bool
+)
+
+var sweepCmd = &cobra.Command{
+	Use:   "sweep",
+	Short: "Delete local branches whose work is merged, closed or gone",
+	Long: `Fetch and prune the remote, then delete the local branches that are done:
+
+  - their upstream branch is gone
+  - they are merged into the default branch
+  - their pull request was merged or closed (and none is open)
+
+Branches with commits that are on no remote are kept unless --force is
+given; commits of a merged pull request count as pushed, even when it was
+squash merged. If the checked out branch is deleted, the default branch
+is checked out and fast-forwarded first.
+
+Usage:
+  utils sweep [--dry-run] [--force] [--remote <name>]`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		_, err := sweep.Sweep(cmd.Context(), sweep.Options{
+			Remote: sweepRemote,
+			Force:  sweepForce,
+			DryRun: sweepDryRun,
+			Runner: commandRunner,
+		})
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sweepCmd)
+
+	// Define flags for sweep command
+	sweepCmd.Flags().StringVar(&sweepRemote, "remote", "origin", "Remote to fetch, prune and take the default branch from")
+	sweepCmd.Flags().BoolVar(&sweepForce, "force", false, "Also delete branches with commits that are on no remote")
+	sweepCmd.Flags().BoolVar(&sweepDryRun, "dry-run", false, "Only list the branches that would be deleted")
+}
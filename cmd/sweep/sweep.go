@@ -0,0 +1,265 @@
+// Package sweep deletes local branches whose work is done: their upstream is
+// gone, they are merged into the default branch, or their pull request was
+// merged or closed. Branches with commits that exist on no remote are kept
+// unless forced.
+package sweep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/cmd/workon"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// Options control which branches are deleted.
+type Options struct {
+	// Remote is fetched and pruned, and holds the default branch.
+	Remote string
+	// Force deletes branches with commits that are on no remote.
+	Force bool
+	// DryRun only lists the branches that would be deleted.
+	DryRun bool
+	// Runner executes every gh and git invocation; nil uses runner.Default().
+	Runner runner.Runner
+}
+
+// Candidate is a local branch that can be swept.
+type Candidate struct {
+	Branch string
+	// Reasons tell why the branch is done, e.g. "upstream gone" or "PR #12 merged".
+	Reasons []string
+	// Unpushed counts the commits of the branch that are on no remote.
+	Unpushed int
+	// Current is set for the checked out branch.
+	Current bool
+}
+
+// Safe reports whether the branch can be deleted without losing work.
+func (c Candidate) Safe() bool {
+	return c.Unpushed == 0
+}
+
+// branch is a local branch as listed by git for-each-ref.
+type branch struct {
+	name   string
+	sha    string
+	gone   bool
+	merged bool
+}
+
+// pullRequest is a pull request for a branch, as listed by gh pr list.
+type pullRequest struct {
+	Number      int    `json:"number"`
+	State       string `json:"state"`
+	HeadRefName string `json:"headRefName"`
+	HeadRefOid  string `json:"headRefOid"`
+}
+
+// Sweep deletes the candidates that are safe to delete, or all of them with
+// Force. With DryRun nothing is deleted. It returns the candidates.
+func Sweep(ctx context.Context, opts Options) ([]Candidate, error) {
+	if opts.Remote == "" {
+		opts.Remote = "origin"
+	}
+	s := mkissue.NewSession(opts.Runner)
+
+	if _, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"fetch", "--prune", opts.Remote}}); err != nil {
+		return nil, fmt.Errorf("failed to fetch '%s': %w", opts.Remote, err)
+	}
+	defaultBranch, err := workon.DefaultBranch(ctx, s, opts.Remote)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := Plan(ctx, s, opts.Remote, defaultBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	var kept []string
+	for _, c := range candidates {
+		reasons := strings.Join(c.Reasons, ", ")
+		if !c.Safe() && !opts.Force {
+			fmt.Printf("Keeping %s (%s): %d commit(s) not on any remote, use --force to delete\n", c.Branch, reasons, c.Unpushed)
+			kept = append(kept, c.Branch)
+			continue
+		}
+		if opts.DryRun {
+			fmt.Printf("Would delete %s (%s)\n", c.Branch, reasons)
+			continue
+		}
+		if c.Current {
+			if _, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"switch", defaultBranch}}); err != nil {
+				return candidates, fmt.Errorf("failed to switch to '%s': %w", defaultBranch, err)
+			}
+			updateDefault(ctx, s, opts.Remote, defaultBranch)
+		}
+		// -D because squash merged branches aren't merged as far as git knows
+		if _, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"branch", "-D", c.Branch}}); err != nil {
+			return candidates, fmt.Errorf("failed to delete branch '%s': %w", c.Branch, err)
+		}
+		fmt.Printf("Deleted %s (%s)\n", c.Branch, reasons)
+	}
+	if len(candidates) == 0 {
+		fmt.Println("Nothing to sweep")
+	}
+	return candidates, nil
+}
+
+// Plan lists the local branches that are done, with the reasons and the
+// number of commits that would be lost by deleting them.
+func Plan(ctx context.Context, s *mkissue.Session, remote, defaultBranch string) ([]Candidate, error) {
+	output, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"rev-parse", "--abbrev-ref", "HEAD"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the current branch: %w", err)
+	}
+	current := strings.TrimSpace(string(output))
+
+	branches, err := localBranches(ctx, s, remote+"/"+defaultBranch)
+	if err != nil {
+		return nil, err
+	}
+	prs := pullRequests(ctx, s)
+
+	var candidates []Candidate
+	for _, b := range branches {
+		if b.name == defaultBranch {
+			continue
+		}
+		c := Candidate{Branch: b.name, Current: b.name == current}
+		if b.gone {
+			c.Reasons = append(c.Reasons, "upstream gone")
+		}
+		if b.merged {
+			c.Reasons = append(c.Reasons, "merged into "+defaultBranch)
+		}
+		pr, done := donePullRequest(prs[b.name])
+		if done {
+			c.Reasons = append(c.Reasons, fmt.Sprintf("PR #%d %s", pr.Number, strings.ToLower(pr.State)))
+		}
+		if len(c.Reasons) == 0 {
+			continue
+		}
+
+		// Commits of a merged pull request are kept by GitHub, even when squashed
+		if !(done && pr.HeadRefOid == b.sha) {
+			if c.Unpushed, err = unpushed(ctx, s, b.name); err != nil {
+				return nil, err
+			}
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
+
+// localBranches lists the local branches with their upstream state and whether
+// they are merged into base.
+func localBranches(ctx context.Context, s *mkissue.Session, base string) ([]branch, error) {
+	output, err := s.Run(ctx, runner.Command{
+		Name: "git",
+		Args: []string{"for-each-ref", "--format=%(refname:short)%09%(objectname)%09%(upstream:track)", "refs/heads"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	merged, err := s.Run(ctx, runner.Command{
+		Name: "git",
+		Args: []string{"for-each-ref", "--format=%(refname:short)", "--merged", base, "refs/heads"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches merged into '%s': %w", base, err)
+	}
+	baseSHA, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"rev-parse", base}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve '%s': %w", base, err)
+	}
+
+	isMerged := map[string]bool{}
+	for _, name := range strings.Split(strings.TrimSpace(string(merged)), "\n") {
+		isMerged[name] = true
+	}
+	var branches []branch
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+		b := branch{name: fields[0], sha: fields[1], gone: fields[2] == "[gone]"}
+		// A branch still at the tip of the default branch has just been started
+		b.merged = isMerged[b.name] && b.sha != strings.TrimSpace(string(baseSHA))
+		branches = append(branches, b)
+	}
+	return branches, nil
+}
+
+// pullRequests returns the pull requests by head branch. Without access to
+// GitHub it warns and returns none, so sweeping works offline.
+func pullRequests(ctx context.Context, s *mkissue.Session) map[string][]pullRequest {
+	output, err := s.Run(ctx, runner.Command{
+		Name: "gh",
+		Args: []string{"pr", "list", "--state", "all", "--limit", "1000", "--json", "number,state,headRefName,headRefOid"},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not checking pull requests: %v\n", err)
+		return nil
+	}
+	var list []pullRequest
+	if err := json.Unmarshal(output, &list); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not checking pull requests: %v\n", err)
+		return nil
+	}
+	prs := map[string][]pullRequest{}
+	for _, pr := range list {
+		prs[pr.HeadRefName] = append(prs[pr.HeadRefName], pr)
+	}
+	return prs
+}
+
+// donePullRequest returns the merged or closed pull request of a branch. A
+// branch with an open pull request isn't done.
+func donePullRequest(prs []pullRequest) (pullRequest, bool) {
+	var done pullRequest
+	for _, pr := range prs {
+		switch pr.State {
+		case "OPEN":
+			return pullRequest{}, false
+		case "MERGED":
+			done = pr
+		case "CLOSED":
+			if done.State != "MERGED" {
+				done = pr
+			}
+		}
+	}
+	return done, done.Number != 0
+}
+
+// unpushed counts the commits of the branch that are on no remote.
+func unpushed(ctx context.Context, s *mkissue.Session, name string) (int, error) {
+	output, err := s.Run(ctx, runner.Command{
+		Name: "git",
+		Args: []string{"rev-list", "--count", "refs/heads/" + name, "--not", "--remotes"},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to check '%s' for unpushed commits: %w", name, err)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to check '%s' for unpushed commits: %w", name, err)
+	}
+	return count, nil
+}
+
+// updateDefault fast-forwards the default branch after switching to it, like
+// the pull in the old sweep alias.
+func updateDefault(ctx context.Context, s *mkissue.Session, remote, defaultBranch string) {
+	_, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"merge", "--ff-only", remote + "/" + defaultBranch}})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: '%s' not updated: %v\n", defaultBranch, err)
+	}
+}
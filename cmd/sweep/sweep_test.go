@@ -0,0 +1,189 @@
+package sweep
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+// repo is a clone of a bare remote with a main branch, see testutil.NewRepo.
+type repo struct {
+	*testutil.Repo
+}
+
+func newRepo(t *testing.T) *repo {
+	r, _ := testutil.NewRepo(t, 1)
+	return &repo{r}
+}
+
+// branch creates a branch from main with one commit and pushes it.
+func (r *repo) branch(name string) string {
+	r.Git("switch", "--quiet", "-c", name, "main")
+	r.Git("commit", "--quiet", "--allow-empty", "-m", "work on "+name)
+	r.Git("push", "--quiet", "-u", "origin", name)
+	r.Git("switch", "--quiet", "main")
+	return r.Git("rev-parse", name)
+}
+
+func (r *repo) branches() []string {
+	list := strings.Fields(r.Git("for-each-ref", "--format=%(refname:short)", "refs/heads"))
+	sort.Strings(list)
+	return list
+}
+
+// gitWithPRs runs git for real and answers 'gh pr list' with prs.
+func gitWithPRs(prs string) runner.Runner {
+	return runner.Func(func(ctx context.Context, cmd runner.Command) (runner.Result, error) {
+		if cmd.Name == "gh" {
+			if prs == "" {
+				res := runner.Result{Stderr: []byte("gh: not logged in"), ExitCode: 4}
+				return res, &runner.ExitError{Command: cmd, Result: res}
+			}
+			return runner.Result{Stdout: []byte(prs)}, nil
+		}
+		return runner.Exec{}.Run(ctx, cmd)
+	})
+}
+
+// setup creates branches in every state sweep distinguishes.
+func setup(t *testing.T) (*repo, string) {
+	r := newRepo(t)
+
+	// Merged and deleted on the remote
+	r.branch("1-merged")
+	r.Git("merge", "--quiet", "--no-ff", "-m", "Merge branch '1-merged'", "1-merged")
+	r.Git("push", "--quiet", "origin", "main")
+	r.Git("push", "--quiet", "origin", "--delete", "1-merged")
+
+	// Deleted on the remote, but with a local commit that was never pushed
+	r.branch("2-unpushed")
+	r.Git("switch", "--quiet", "2-unpushed")
+	r.Git("commit", "--quiet", "--allow-empty", "-m", "local only")
+	r.Git("switch", "--quiet", "main")
+	r.Git("push", "--quiet", "origin", "--delete", "2-unpushed")
+
+	// Work in progress
+	r.branch("3-active")
+
+	// Just started, at the tip of main
+	r.Git("branch", "4-fresh", "main")
+
+	// Squash merged on GitHub and deleted
+	squashed := r.branch("5-squashed")
+	r.Git("push", "--quiet", "origin", "--delete", "5-squashed")
+
+	// Pull request closed, branch still on the remote
+	r.branch("6-closed")
+
+	prs := `[
+		{"number":11,"state":"MERGED","headRefName":"5-squashed","headRefOid":"` + squashed + `"},
+		{"number":12,"state":"CLOSED","headRefName":"6-closed","headRefOid":"x"},
+		{"number":13,"state":"OPEN","headRefName":"3-active","headRefOid":"y"},
+		{"number":10,"state":"CLOSED","headRefName":"3-active","headRefOid":"z"}
+	]`
+	return r, prs
+}
+
+func TestPlan(t *testing.T) {
+	_, prs := setup(t)
+
+	candidates, err := Sweep(context.Background(), Options{DryRun: true, Runner: gitWithPRs(prs)})
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+
+	got := map[string]Candidate{}
+	for _, c := range candidates {
+		got[c.Branch] = c
+	}
+	want := map[string]Candidate{
+		"1-merged": {Branch: "1-merged", Reasons: []string{"upstream gone", "merged into main"}},
+		// Its pushed commit went with the remote branch
+		"2-unpushed": {Branch: "2-unpushed", Reasons: []string{"upstream gone"}, Unpushed: 2},
+		"5-squashed": {Branch: "5-squashed", Reasons: []string{"upstream gone", "PR #11 merged"}},
+		"6-closed":   {Branch: "6-closed", Reasons: []string{"PR #12 closed"}, Unpushed: 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  Options
+		noPRs bool
+		want  []string
+	}{
+		{
+			name: "dry run deletes nothing",
+			opts: Options{DryRun: true},
+			want: []string{"1-merged", "2-unpushed", "3-active", "4-fresh", "5-squashed", "6-closed", "main"},
+		},
+		{
+			name: "keeps unpushed work",
+			want: []string{"2-unpushed", "3-active", "4-fresh", "main"},
+		},
+		{
+			name: "force deletes unpushed work",
+			opts: Options{Force: true},
+			want: []string{"3-active", "4-fresh", "main"},
+		},
+		{
+			name:  "without GitHub only git signals count",
+			noPRs: true,
+			// 5-squashed has commits that are on no remote as far as git can tell
+			want: []string{"2-unpushed", "3-active", "4-fresh", "5-squashed", "6-closed", "main"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, prs := setup(t)
+			if tt.noPRs {
+				prs = ""
+			}
+			tt.opts.Runner = gitWithPRs(prs)
+			if _, err := Sweep(context.Background(), tt.opts); err != nil {
+				t.Fatalf("Sweep() error = %v", err)
+			}
+			if got := r.branches(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("branches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSweepCurrentBranchAndDefaultBranch(t *testing.T) {
+	r := newRepo(t)
+	// The default branch isn't assumed to be main
+	r.Git("switch", "--quiet", "-c", "trunk")
+	r.Git("push", "--quiet", "-u", "origin", "trunk")
+	r.Git("symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/trunk")
+	r.Git("branch", "--quiet", "-D", "main")
+
+	r.Git("switch", "--quiet", "-c", "7-done")
+	r.Git("commit", "--quiet", "--allow-empty", "-m", "done")
+	r.Git("push", "--quiet", "-u", "origin", "7-done")
+	// Merged on the remote by someone else
+	r.Git("push", "--quiet", "origin", "7-done:trunk")
+	r.Git("push", "--quiet", "origin", "--delete", "7-done")
+
+	if _, err := Sweep(context.Background(), Options{Runner: gitWithPRs("[]")}); err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if got := r.branches(); !reflect.DeepEqual(got, []string{"trunk"}) {
+		t.Errorf("branches = %v, want [trunk]", got)
+	}
+	if got := r.Git("rev-parse", "--abbrev-ref", "HEAD"); got != "trunk" {
+		t.Errorf("checked out %s, want trunk", got)
+	}
+	if r.Git("rev-parse", "HEAD") != r.Git("rev-parse", "origin/trunk") {
+		t.Error("trunk wasn't fast-forwarded to origin/trunk")
+	}
+}
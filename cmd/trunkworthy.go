@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"github.com/lakruzz/gh-utils/cmd/trunkworthy"
+	"github.com/spf13/cobra"
+)
+
+var trunkWorthyCmd = &cobra.Command{
+	Use:   "trunk-worthy",
+	Short: "Run the checks a commit must pass before it goes to trunk",
+	Long: `Run the checks configured in the 'trunk-worthy' section of .utils.yml.
+
+Checks run in waves: all checks of a wave run in parallel (bounded by
+'parallelism'), and the next wave only starts when every check of the
+previous wave passed. The output of failed checks is shown at the end.
+
+In GitHub Actions each check also sets a commit status, with the check
+name as context, and the results are written to $GITHUB_STEP_SUMMARY.
+
+Usage:
+  utils trunk-worthy
+  utils trunk-worthy mark-pending`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		_, err := trunkworthy.Run(cmd.Context(), trunkworthy.Options{Config: &trunkWorthyConfig, Runner: commandRunner})
+		return err
+	},
+}
+
+var markPendingCmd = &cobra.Command{
+	Use:   "mark-pending",
+	Short: "Set the commit status of every configured check to pending",
+	Long: `Set the commit status of every configured check to pending.
+
+Only valid in GitHub Actions runs. Run it as early as possible after the
+checkout, so the planned checks show up as pending before they run.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return trunkworthy.MarkPending(cmd.Context(), trunkworthy.Options{Config: &trunkWorthyConfig, Runner: commandRunner})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(trunkWorthyCmd)
+	trunkWorthyCmd.AddCommand(markPendingCmd)
+}
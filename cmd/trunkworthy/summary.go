@@ -0,0 +1,69 @@
+package trunkworthy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// writeSummary appends the results to the job summary of a GitHub Actions run,
+// if $GITHUB_STEP_SUMMARY is set.
+func writeSummary(results []Result) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write step summary: %w", err)
+	}
+	if _, err := f.WriteString(Summary(results)); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write step summary: %w", err)
+	}
+	return f.Close()
+}
+
+// Summary renders the results as markdown: a table of all checks followed by
+// the output of the failed ones.
+func Summary(results []Result) string {
+	var b strings.Builder
+	b.WriteString("## Trunk-worthy checks\n\n")
+	b.WriteString("| Check | Result | Duration |\n")
+	b.WriteString("|-------|--------|----------|\n")
+	for _, r := range results {
+		outcome, duration := "✅ passed", r.Duration.Round(100*time.Millisecond).String()
+		switch {
+		case r.Skipped:
+			outcome, duration = "⏭️ skipped", ""
+		case !r.Passed:
+			outcome = "❌ failed"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", DisplayName(r.Check), outcome, duration)
+	}
+
+	for _, r := range results {
+		if r.Passed || r.Skipped {
+			continue
+		}
+		output := strings.TrimRight(string(r.Output), "\n")
+		fence := codeFence(output)
+		fmt.Fprintf(&b, "\n<details open><summary>%s output</summary>\n\n%stext\n%s\n%s\n\n</details>\n", DisplayName(r.Check), fence, output, fence)
+	}
+	return b.String()
+}
+
+// codeFence returns a fence longer than any run of backticks in s.
+func codeFence(s string) string {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
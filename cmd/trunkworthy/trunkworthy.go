@@ -0,0 +1,257 @@
+// Package trunkworthy runs the checks a commit must pass before it goes to
+// trunk. Checks run in waves: all checks of a wave run in parallel, and the
+// next wave only starts when every check of the previous wave passed.
+package trunkworthy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/cmd/status"
+	"github.com/lakruzz/gh-utils/internal/config"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// Options control which checks run and where results are reported.
+type Options struct {
+	// Config defines the checks and waves, from the 'trunk-worthy' section of
+	// .utils.yml; nil has none.
+	Config *config.TrunkWorthy
+	// Shell runs the check commands; nil runs them locally without retries.
+	Shell runner.Runner
+	// Runner executes the gh invocations that set commit statuses; nil uses runner.Default().
+	Runner runner.Runner
+	// Out receives the progress and check output; nil is stdout.
+	Out io.Writer
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Check    config.Check
+	Passed   bool
+	Skipped  bool
+	Output   []byte
+	Duration time.Duration
+}
+
+// Run runs the configured checks wave by wave. In GitHub Actions it sets a
+// commit status per check and writes a summary to $GITHUB_STEP_SUMMARY.
+func Run(ctx context.Context, opts Options) ([]Result, error) {
+	cfg, err := load(opts)
+	if err != nil {
+		return nil, err
+	}
+	t, err := newTrunk(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintln(t.out, "🔍 Running checks in parallel...")
+	var results []Result
+	passed := true
+	for i, wave := range cfg.Waves {
+		if !passed || ctx.Err() != nil {
+			for _, name := range wave {
+				results = append(results, t.skip(ctx, cfg.Checks[name]))
+			}
+			continue
+		}
+		if i > 0 {
+			fmt.Fprintf(t.out, "\n🔗 Running wave %d - also in parallel...\n", i+1)
+		}
+		for _, r := range t.wave(ctx, cfg, wave) {
+			passed = passed && r.Passed
+			results = append(results, r)
+		}
+	}
+
+	if err := writeSummary(results); err != nil {
+		t.warn(err)
+	}
+
+	var failed []string
+	for _, r := range results {
+		if !r.Passed && !r.Skipped {
+			failed = append(failed, DisplayName(r.Check))
+			fmt.Fprintf(t.out, "\n%s issues:\n%s\n", DisplayName(r.Check), strings.TrimRight(string(r.Output), "\n"))
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return results, fmt.Errorf("checks interrupted: %w", err)
+	}
+	if len(failed) > 0 {
+		fmt.Fprintln(t.out, "\n👉 Fix the issues above before committing.")
+		return results, errors.Join(fmt.Errorf("checks failed: %s", strings.Join(failed, ", ")), t.statusErr())
+	}
+	fmt.Fprintln(t.out, "\n✅ All checks passed")
+	return results, t.statusErr()
+}
+
+// MarkPending sets the commit status of every configured check to pending.
+// It is meant to run as an early step of a GitHub Actions job.
+func MarkPending(ctx context.Context, opts Options) error {
+	cfg, err := load(opts)
+	if err != nil {
+		return err
+	}
+	t, err := newTrunk(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if t.target == nil {
+		return &mkissue.Error{Kind: mkissue.ErrValidation, Err: errors.New("mark-pending is only valid in GitHub Actions runs")}
+	}
+	for _, wave := range cfg.Waves {
+		for _, name := range wave {
+			check := cfg.Checks[name]
+			t.setStatus(ctx, check, status.Pending, DisplayName(check))
+		}
+	}
+	return t.statusErr()
+}
+
+// DisplayName returns the name a check is shown with, e.g. "Build check".
+func DisplayName(c config.Check) string {
+	name := c.Display
+	if name == "" && c.Name != "" {
+		name = strings.ToUpper(c.Name[:1]) + c.Name[1:]
+	}
+	return name + " check"
+}
+
+func load(opts Options) (*config.TrunkWorthy, error) {
+	cfg := opts.Config
+	if cfg == nil || len(cfg.Waves) == 0 {
+		return nil, &mkissue.Error{Kind: mkissue.ErrValidation, Err: errors.New("no checks configured: add a 'trunk-worthy' section to " + config.FileName)}
+	}
+	return cfg, nil
+}
+
+// trunk holds the state shared by the checks of a run.
+type trunk struct {
+	shell runner.Runner
+	// root is the directory the checks run in.
+	root    string
+	session *mkissue.Session
+	target  *status.Target
+	out     io.Writer
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// newTrunk prepares a run in the root of the current repository. In GitHub Actions the commit statuses are set on
+// the commit of the run, which must be known.
+func newTrunk(ctx context.Context, opts Options) (*trunk, error) {
+	t := &trunk{shell: opts.Shell, out: opts.Out}
+	if t.shell == nil {
+		t.shell = runner.Exec{}
+	}
+	if t.out == nil {
+		t.out = os.Stdout
+	}
+	// Run the checks from the repository root, whatever directory this runs in
+	if res, err := (runner.Exec{}).Run(ctx, runner.Command{Name: "git", Args: []string{"rev-parse", "--show-toplevel"}}); err == nil {
+		t.root = strings.TrimSpace(string(res.Stdout))
+	}
+	if status.InActions() {
+		target, ok := status.TargetFromEnv()
+		if !ok {
+			return nil, &mkissue.Error{Kind: mkissue.ErrValidation, Err: errors.New("can't set commit statuses: $GITHUB_REPOSITORY and $GITHUB_SHA must be set in GitHub Actions runs")}
+		}
+		t.target = &target
+		t.session = mkissue.NewSession(opts.Runner)
+	}
+	return t, nil
+}
+
+// statusErr returns the errors of setting commit statuses.
+func (t *trunk) statusErr() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return errors.Join(t.errs...)
+}
+
+// wave runs the checks of a wave with at most cfg.Parallelism running at once.
+func (t *trunk) wave(ctx context.Context, cfg *config.TrunkWorthy, names []string) []Result {
+	limit := cfg.Parallelism
+	if limit <= 0 {
+		limit = runtime.NumCPU()
+	}
+	slots := make(chan struct{}, limit)
+	results := make([]Result, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, check config.Check) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results[i] = t.check(ctx, check)
+		}(i, cfg.Checks[name])
+	}
+	wg.Wait()
+	return results
+}
+
+// check runs a single check and reports its result.
+func (t *trunk) check(ctx context.Context, check config.Check) Result {
+	started := time.Now()
+	// Send stderr to stdout so the output keeps its order
+	res, err := t.shell.Run(ctx, runner.Command{Name: "sh", Args: []string{"-c", "exec 2>&1\n" + check.Command}, Dir: t.root})
+	r := Result{Check: check, Passed: err == nil, Output: res.Stdout, Duration: time.Since(started)}
+	var exitErr *runner.ExitError
+	if errors.As(err, &exitErr) {
+		r.Output = exitErr.Result.Stdout
+	} else if err != nil {
+		r.Output = append(r.Output, []byte(err.Error()+"\n")...)
+	}
+
+	icon, state, outcome := "✅", status.Success, "passed"
+	if !r.Passed {
+		icon, state, outcome = "❌", status.Failure, "failed"
+	}
+	t.mu.Lock()
+	fmt.Fprintf(t.out, "   %s %s (%s)\n", icon, DisplayName(check), r.Duration.Round(100*time.Millisecond))
+	t.mu.Unlock()
+	t.setStatus(ctx, check, state, DisplayName(check)+" "+outcome)
+	return r
+}
+
+// skip reports a check that didn't run because an earlier wave failed.
+func (t *trunk) skip(ctx context.Context, check config.Check) Result {
+	t.setStatus(ctx, check, status.Error, DisplayName(check)+" skipped, an earlier check failed")
+	return Result{Check: check, Skipped: true}
+}
+
+// setStatus sets the commit status of the check when running in GitHub Actions.
+func (t *trunk) setStatus(ctx context.Context, check config.Check, state, description string) {
+	if t.target == nil {
+		return
+	}
+	err := status.Set(context.WithoutCancel(ctx), t.session, *t.target, status.Status{
+		State:       state,
+		Description: description,
+		Context:     check.Name,
+		TargetURL:   status.RunURL(),
+	})
+	if err != nil {
+		t.warn(err)
+		t.mu.Lock()
+		t.errs = append(t.errs, err)
+		t.mu.Unlock()
+	}
+}
+
+func (t *trunk) warn(err error) {
+	fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+}
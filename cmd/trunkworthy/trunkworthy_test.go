@@ -0,0 +1,302 @@
+package trunkworthy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/config"
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+// outsideActions clears the environment GitHub Actions sets, so tests behave
+// the same locally and in CI.
+func outsideActions(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"GITHUB_ACTIONS", "GITHUB_REPOSITORY", "GITHUB_SHA", "GITHUB_ACTION", "GITHUB_RUN_ID", "GITHUB_SERVER_URL", "GITHUB_STEP_SUMMARY"} {
+		t.Setenv(name, "")
+	}
+}
+
+// inActions sets up the environment of a GitHub Actions run.
+func inActions(t *testing.T) string {
+	t.Helper()
+	summary := filepath.Join(t.TempDir(), "summary.md")
+	for name, value := range map[string]string{
+		"GITHUB_ACTIONS":      "true",
+		"GITHUB_REPOSITORY":   "lakruzz/gh-utils",
+		"GITHUB_SHA":          "abc1234",
+		"GITHUB_ACTION":       "run",
+		"GITHUB_RUN_ID":       "42",
+		"GITHUB_SERVER_URL":   "https://github.com",
+		"GITHUB_STEP_SUMMARY": summary,
+	} {
+		t.Setenv(name, value)
+	}
+	return summary
+}
+
+func testConfig(waves ...[]string) *config.TrunkWorthy {
+	cfg := &config.TrunkWorthy{Checks: map[string]config.Check{}, Waves: waves}
+	for _, wave := range waves {
+		for _, name := range wave {
+			cfg.Checks[name] = config.Check{Name: name, Command: name}
+		}
+	}
+	return cfg
+}
+
+// shell fakes check commands: a command containing "fail" exits with 1.
+func shell(ran *[]string, mu *sync.Mutex) runner.Runner {
+	return runner.Func(func(_ context.Context, cmd runner.Command) (runner.Result, error) {
+		command := strings.TrimPrefix(cmd.Args[1], "exec 2>&1\n")
+		mu.Lock()
+		*ran = append(*ran, command)
+		mu.Unlock()
+		res := runner.Result{Stdout: []byte(command + " output\n")}
+		if strings.Contains(command, "fail") {
+			res.ExitCode = 1
+			return res, &runner.ExitError{Command: cmd, Result: res}
+		}
+		return res, nil
+	})
+}
+
+func TestRunWaves(t *testing.T) {
+	tests := []struct {
+		name       string
+		waves      [][]string
+		wantRan    []string
+		wantFailed []string
+	}{
+		{
+			name:    "all pass",
+			waves:   [][]string{{"lint", "build"}, {"test"}},
+			wantRan: []string{"build", "lint", "test"},
+		},
+		{
+			name:       "failure stops later waves",
+			waves:      [][]string{{"lint", "failbuild"}, {"test"}, {"e2e"}},
+			wantRan:    []string{"failbuild", "lint"},
+			wantFailed: []string{"Failbuild check"},
+		},
+		{
+			name:       "failure in last wave",
+			waves:      [][]string{{"lint"}, {"failtest", "failcover"}},
+			wantRan:    []string{"failcover", "failtest", "lint"},
+			wantFailed: []string{"Failtest check", "Failcover check"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outsideActions(t)
+			var ran []string
+			var mu sync.Mutex
+			var out bytes.Buffer
+
+			results, err := Run(context.Background(), Options{Config: testConfig(tt.waves...), Shell: shell(&ran, &mu), Out: &out})
+
+			sort.Strings(ran)
+			if strings.Join(ran, ",") != strings.Join(tt.wantRan, ",") {
+				t.Errorf("ran %v, want %v", ran, tt.wantRan)
+			}
+			if (err != nil) != (len(tt.wantFailed) > 0) {
+				t.Fatalf("Run() error = %v, want failures %v", err, tt.wantFailed)
+			}
+			if err != nil && !strings.Contains(err.Error(), strings.Join(tt.wantFailed, ", ")) {
+				t.Errorf("Run() error = %v, want failures %v", err, tt.wantFailed)
+			}
+			for _, failed := range tt.wantFailed {
+				if !strings.Contains(out.String(), failed+" issues:\n") {
+					t.Errorf("output doesn't show the issues of %s:\n%s", failed, out.String())
+				}
+			}
+
+			var total int
+			for _, wave := range tt.waves {
+				total += len(wave)
+			}
+			if len(results) != total {
+				t.Errorf("got %d results, want one per check (%d)", len(results), total)
+			}
+		})
+	}
+}
+
+func TestRunBoundedParallelism(t *testing.T) {
+	outsideActions(t)
+	cfg := testConfig([]string{"a", "b", "c", "d", "e"})
+	cfg.Parallelism = 2
+
+	var running, peak atomic.Int32
+	slow := runner.Func(func(context.Context, runner.Command) (runner.Result, error) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+		return runner.Result{}, nil
+	})
+
+	if _, err := Run(context.Background(), Options{Config: cfg, Shell: slow, Out: &bytes.Buffer{}}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := peak.Load(); got != 2 {
+		t.Errorf("at most %d checks ran at once, want 2", got)
+	}
+}
+
+func TestRunRealShellCapturesOutput(t *testing.T) {
+	outsideActions(t)
+	cfg := &config.TrunkWorthy{
+		Checks: map[string]config.Check{
+			"noisy": {Name: "noisy", Command: "echo out; echo err >&2; exit 3"},
+		},
+		Waves: [][]string{{"noisy"}},
+	}
+
+	results, err := Run(context.Background(), Options{Config: cfg, Out: &bytes.Buffer{}})
+	if err == nil {
+		t.Fatal("Run() expected error")
+	}
+	if got := string(results[0].Output); got != "out\nerr\n" {
+		t.Errorf("Output = %q, want stdout and stderr in order", got)
+	}
+}
+
+func TestRunFromRepositoryRoot(t *testing.T) {
+	outsideActions(t)
+	repo, _ := testutil.NewRepo(t, 1)
+	sub := filepath.Join(repo.Dir, "cmd", "sub")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(sub); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.TrunkWorthy{
+		Checks: map[string]config.Check{"where": {Name: "where", Command: "pwd -P"}},
+		Waves:  [][]string{{"where"}},
+	}
+
+	results, err := Run(context.Background(), Options{Config: cfg, Out: &bytes.Buffer{}})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	root, err := filepath.EvalSymlinks(repo.Dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(results[0].Output)); got != root {
+		t.Errorf("check ran in %s, want the repository root %s", got, root)
+	}
+}
+
+func TestRunInActions(t *testing.T) {
+	summary := inActions(t)
+	cfg := testConfig([]string{"failbuild"}, []string{"test"})
+	cfg.Checks["failbuild"] = config.Check{Name: "failbuild", Command: "failbuild", Display: "Build"}
+
+	var ran []string
+	var mu sync.Mutex
+	r := runner.NewReplayer(
+		statusCall("failbuild", "failure", "Build check failed"),
+		statusCall("test", "error", "Test check skipped, an earlier check failed"),
+	)
+
+	_, err := Run(context.Background(), Options{Config: cfg, Shell: shell(&ran, &mu), Runner: r, Out: &bytes.Buffer{}})
+	if err == nil {
+		t.Fatal("Run() expected error")
+	}
+	if err := r.Verify(); err != nil {
+		t.Error(err)
+	}
+
+	got, err := os.ReadFile(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"| Build check | ❌ failed |", "| Test check | ⏭️ skipped |  |", "<summary>Build check output</summary>", "failbuild output"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("summary doesn't contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestRunInActionsWithoutTarget(t *testing.T) {
+	inActions(t)
+	t.Setenv("GITHUB_SHA", "")
+	var ran []string
+	var mu sync.Mutex
+	_, err := Run(context.Background(), Options{Config: testConfig([]string{"lint"}), Shell: shell(&ran, &mu), Runner: runner.NewReplayer(), Out: &bytes.Buffer{}})
+	if !errors.Is(err, mkissue.ErrValidation) || len(ran) != 0 {
+		t.Errorf("Run() error = %v after running %v, want a validation error before any check", err, ran)
+	}
+}
+
+func statusCall(name, state, description string) runner.Interaction {
+	return runner.Interaction{Name: "gh", Args: []string{
+		"api", "--method", "POST", "repos/lakruzz/gh-utils/statuses/abc1234",
+		"-f", "state=" + state,
+		"-f", "context=" + name,
+		"-f", "description=" + description,
+		"-f", "target_url=https://github.com/lakruzz/gh-utils/actions/runs/42",
+	}}
+}
+
+func TestMarkPending(t *testing.T) {
+	cfg := testConfig([]string{"lint", "build"}, []string{"coverage"})
+	cfg.Checks["coverage"] = config.Check{Name: "coverage", Command: "make coverage", Display: "Unit Test with coverage"}
+
+	t.Run("outside actions", func(t *testing.T) {
+		outsideActions(t)
+		err := MarkPending(context.Background(), Options{Config: cfg, Runner: runner.NewReplayer()})
+		if !errors.Is(err, mkissue.ErrValidation) {
+			t.Errorf("MarkPending() error = %v, want validation error", err)
+		}
+	})
+
+	t.Run("in actions", func(t *testing.T) {
+		inActions(t)
+		r := runner.NewReplayer(
+			statusCall("lint", "pending", "Lint check"),
+			statusCall("build", "pending", "Build check"),
+			statusCall("coverage", "pending", "Unit Test with coverage check"),
+		)
+		if err := MarkPending(context.Background(), Options{Config: cfg, Runner: r}); err != nil {
+			t.Fatalf("MarkPending() error = %v", err)
+		}
+		if err := r.Verify(); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestRunWithoutChecks(t *testing.T) {
+	_, err := Run(context.Background(), Options{Config: &config.TrunkWorthy{}})
+	if !errors.Is(err, mkissue.ErrValidation) {
+		t.Errorf("Run() error = %v, want validation error", err)
+	}
+}
+
+func TestSummaryCodeFence(t *testing.T) {
+	got := Summary([]Result{{Check: config.Check{Name: "docs"}, Output: []byte("```go\nx\n```\n")}})
+	if !strings.Contains(got, "````text\n```go\nx\n```\n````") {
+		t.Errorf("Summary() doesn't fence output containing a fence:\n%s", got)
+	}
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"github.com/lakruzz/gh-utils/cmd/workon"
+	"github.com/spf13/cobra"
+)
+
+var (
+	workonBase    string
+	workonRemote  string
+	workonAssign  bool
+	workonLabel   string
+	workonProject string
+	workonStatus  string
+)
+
+var workonCmd = &cobra.Command{
+	Use:   "workon <issue>",
+	Short: "Start work on an issue in a branch named after it",
+	Long: `Start work on an issue: create the branch <number>-<slugified-title> from
+the default branch (or --base) and check it out. If a branch for the issue
+already exists, locally or on the remote, it is checked out instead.
+
+Optionally assign the issue to yourself, add an "in progress" label, and
+add the issue to a project with its Status field set to "In Progress". A
+status that the project doesn't have fails before the branch is created; if
+the issue can't be updated, the new branch is deleted again.
+
+Usage:
+  utils workon <issue> [--base <ref>] [--assign] [--label [<name>]] [--project <title> [--status [<status>]]]`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, err := workon.Start(cmd.Context(), args[0], workon.Options{
+			Base:    workonBase,
+			Remote:  workonRemote,
+			Assign:  workonAssign,
+			Label:   workonLabel,
+			Project: workonProject,
+			Status:  workonStatus,
+			Runner:  commandRunner,
+		})
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(workonCmd)
+
+	// Define flags for workon command
+	workonCmd.Flags().StringVar(&workonBase, "base", "", "Branch or ref to start from (defaults to the remote's default branch)")
+	workonCmd.Flags().StringVar(&workonRemote, "remote", "origin", "Remote to fetch the default branch from")
+	workonCmd.Flags().BoolVar(&workonAssign, "assign", false, "Assign the issue to yourself")
+	workonCmd.Flags().StringVar(&workonLabel, "label", "", "Label to add to the issue (defaults to 'in progress' when given without a value)")
+	workonCmd.Flags().Lookup("label").NoOptDefVal = "in progress"
+	workonCmd.Flags().StringVar(&workonProject, "project", "", "Project to add the issue to, by title")
+	workonCmd.Flags().StringVar(&workonStatus, "status", "", "Status to set in the project (defaults to 'In Progress' when given without a value)")
+	workonCmd.Flags().Lookup("status").NoOptDefVal = "In Progress"
+}
@@ -0,0 +1,96 @@
+package workon
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxSlugLength keeps branch names readable in prompts and PR lists.
+const maxSlugLength = 50
+
+// transliterations spell out the letters that commonly appear in our issue
+// titles but have no ASCII form.
+var transliterations = map[rune]string{
+	'æ': "ae", 'ø': "oe", 'å': "aa",
+	'ä': "a", 'ö': "o", 'ü': "u", 'ß': "ss",
+	'á': "a", 'à': "a", 'â': "a", 'é': "e", 'è': "e", 'ê': "e", 'ë': "e",
+	'í': "i", 'ì': "i", 'î': "i", 'ï': "i", 'ó': "o", 'ò': "o", 'ô': "o",
+	'ú': "u", 'ù': "u", 'û': "u", 'ñ': "n", 'ç': "c",
+}
+
+// Slug turns an issue title into the part of a branch name after the issue
+// number: lowercase ASCII letters and digits separated by single dashes, cut at
+// a word boundary after at most 50 characters.
+func Slug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			dash = false
+		case transliterations[r] != "":
+			b.WriteString(transliterations[r])
+			dash = false
+		case r == '\'' || r == '’':
+			// Keep contractions together: "don't" becomes "dont"
+		default:
+			if !dash && b.Len() > 0 {
+				b.WriteByte('-')
+				dash = true
+			}
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if len(slug) <= maxSlugLength {
+		return slug
+	}
+	cut := slug[:maxSlugLength]
+	if i := strings.LastIndexByte(cut, '-'); i > 0 && slug[maxSlugLength] != '-' {
+		// Don't end in the middle of a word
+		cut = cut[:i]
+	}
+	return strings.TrimSuffix(cut, "-")
+}
+
+// BranchName returns the branch name for an issue, e.g. 42-add-workon.
+func BranchName(number int, title string) string {
+	name := strconv.Itoa(number)
+	if slug := Slug(title); slug != "" {
+		name += "-" + slug
+	}
+	return name
+}
+
+// ExistingBranch finds a branch that was already created for the issue, so
+// work is resumed instead of starting a second branch after the title changed.
+// branches are short ref names as listed by 'git branch --all', e.g.
+// 42-old-title or origin/42-old-title. It prefers the exact name, then local
+// branches, and returns the local name to switch to.
+func ExistingBranch(number int, name string, branches []string, remote string) (string, bool) {
+	prefix := strconv.Itoa(number)
+	var local, remoteOnly []string
+	for _, branch := range branches {
+		branch = strings.TrimSpace(branch)
+		short, isRemote := strings.CutPrefix(branch, remote+"/")
+		if short != prefix && !strings.HasPrefix(short, prefix+"-") {
+			continue
+		}
+		if short == name {
+			return name, true
+		}
+		if isRemote {
+			remoteOnly = append(remoteOnly, short)
+		} else {
+			local = append(local, short)
+		}
+	}
+	if len(local) > 0 {
+		return local[0], true
+	}
+	if len(remoteOnly) > 0 {
+		return remoteOnly[0], true
+	}
+	return "", false
+}
@@ -0,0 +1,110 @@
+package workon
+
+import "testing"
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{title: "Add workon command", want: "add-workon-command"},
+		{title: "`utils workon`: start work on an issue", want: "utils-workon-start-work-on-an-issue"},
+		{title: "  Fix   double   spaces  ", want: "fix-double-spaces"},
+		{title: "Don't break on apostrophes", want: "dont-break-on-apostrophes"},
+		{title: "Støtte for æøå i titler", want: "stoette-for-aeoeaa-i-titler"},
+		{title: "Über café", want: "uber-cafe"},
+		{title: "v2.0 release (#12)", want: "v2-0-release-12"},
+		{title: "日本語", want: ""},
+		{title: "!!!", want: ""},
+		{
+			title: "A very long title that goes on and on well past the fifty character limit",
+			want:  "a-very-long-title-that-goes-on-and-on-well-past",
+		},
+		{
+			title: "Exactly fifty characters long title for testing xx",
+			want:  "exactly-fifty-characters-long-title-for-testing-xx",
+		},
+		{
+			title: "Supercalifragilisticexpialidocious-is-a-long-word-indeed",
+			want:  "supercalifragilisticexpialidocious-is-a-long-word",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			got := Slug(tt.title)
+			if got != tt.want {
+				t.Errorf("Slug(%q) = %q, want %q", tt.title, got, tt.want)
+			}
+			if len(got) > maxSlugLength {
+				t.Errorf("Slug(%q) is %d characters, want at most %d", tt.title, len(got), maxSlugLength)
+			}
+		})
+	}
+}
+
+func TestBranchName(t *testing.T) {
+	if got := BranchName(42, "Add workon"); got != "42-add-workon" {
+		t.Errorf("BranchName() = %q, want 42-add-workon", got)
+	}
+	if got := BranchName(7, "日本語"); got != "7" {
+		t.Errorf("BranchName() = %q, want 7", got)
+	}
+}
+
+func TestExistingBranch(t *testing.T) {
+	tests := []struct {
+		name     string
+		branches []string
+		want     string
+		wantOK   bool
+	}{
+		{
+			name:     "no branch for the issue",
+			branches: []string{"main", "origin/main", "420-other", "4-other", "origin/42x"},
+		},
+		{
+			name:     "exact local branch",
+			branches: []string{"main", "42-add-workon"},
+			want:     "42-add-workon",
+			wantOK:   true,
+		},
+		{
+			name:     "exact remote branch",
+			branches: []string{"main", "origin/42-add-workon"},
+			want:     "42-add-workon",
+			wantOK:   true,
+		},
+		{
+			name:     "renamed issue resumes local branch",
+			branches: []string{"origin/42-old-title", "42-old-title-local"},
+			want:     "42-old-title-local",
+			wantOK:   true,
+		},
+		{
+			name:     "renamed issue resumes remote branch",
+			branches: []string{"main", "origin/42-old-title"},
+			want:     "42-old-title",
+			wantOK:   true,
+		},
+		{
+			name:     "bare number branch",
+			branches: []string{"42"},
+			want:     "42",
+			wantOK:   true,
+		},
+		{
+			name:     "other remotes are not ours",
+			branches: []string{"upstream/42-add-workon"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ExistingBranch(42, "42-add-workon", tt.branches, "origin")
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ExistingBranch() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
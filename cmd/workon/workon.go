@@ -0,0 +1,207 @@
+// Package workon starts work on an issue: it creates the issue branch, named
+// <number>-<slug>, from the default branch and marks the issue as in progress,
+// with a label, an assignee or a status in a project.
+package workon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// Options control how the branch is created and the issue is updated.
+type Options struct {
+	// Base is the branch or ref to start from; "" uses the default branch of Remote.
+	Base string
+	// Remote is the remote the default branch is fetched from.
+	Remote string
+	// Assign assigns the issue to the authenticated user.
+	Assign bool
+	// Label is added to the issue, e.g. "in progress"; "" adds no label.
+	Label string
+	// Project is a project the issue is added to, by title; "" adds it to none.
+	Project string
+	// Status is set in the Status field of the issue in Project, e.g.
+	// "In Progress"; "" leaves it as it is.
+	Status string
+	// Runner executes every gh and git invocation; nil uses runner.Default().
+	Runner runner.Runner
+}
+
+// Issue is the part of an issue workon needs.
+type Issue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	URL    string `json:"url"`
+}
+
+// inProgressColor is used when the label has to be created.
+const inProgressColor = "fbca04"
+
+// Start checks out the branch for the issue, creating it when there's none
+// yet, and assigns, labels and sets the project status of the issue. A branch
+// it created is deleted again when the issue can't be updated. It returns the
+// branch name.
+func Start(ctx context.Context, issue string, opts Options) (string, error) {
+	number, err := strconv.Atoi(strings.TrimPrefix(issue, "#"))
+	if err != nil || number <= 0 {
+		return "", &mkissue.Error{Kind: mkissue.ErrValidation, Err: fmt.Errorf("invalid issue number '%s'", issue)}
+	}
+	if opts.Status != "" && opts.Project == "" {
+		return "", &mkissue.Error{Kind: mkissue.ErrValidation, Err: fmt.Errorf("a status needs the project to set it in")}
+	}
+	if opts.Remote == "" {
+		opts.Remote = "origin"
+	}
+	s := mkissue.NewSession(opts.Runner)
+
+	output, err := s.Run(ctx, runner.Command{
+		Name: "gh",
+		Args: []string{"issue", "view", strconv.Itoa(number), "--json", "number,title,state,url"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read issue #%d: %w", number, err)
+	}
+	var view Issue
+	if err := json.Unmarshal(output, &view); err != nil {
+		return "", fmt.Errorf("failed to parse issue #%d: %w", number, err)
+	}
+	if strings.EqualFold(view.State, "closed") {
+		fmt.Fprintf(os.Stderr, "Warning: issue #%d is closed\n", number)
+	}
+
+	// A status that doesn't exist fails before the branch is created
+	var fields *mkissue.ProjectFields
+	if opts.Status != "" {
+		fields, err = s.ResolveProjectFields(ctx, []mkissue.Project{{Title: opts.Project, Fields: map[string]string{"Status": opts.Status}}})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	branch, created, err := checkout(ctx, s, BranchName(number, view.Title), number, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if err := markInProgress(ctx, s, view, fields, opts); err != nil {
+		err = s.Fail(ctx, err, false)
+		if !created {
+			return branch, err
+		}
+		// Leave the repository as it was, so running workon again starts over
+		if removeErr := removeBranch(ctx, s, branch); removeErr != nil {
+			return branch, fmt.Errorf("%w; branch '%s' was kept: %v", err, branch, removeErr)
+		}
+		return "", fmt.Errorf("%w; branch '%s' was deleted", err, branch)
+	}
+	return branch, nil
+}
+
+// removeBranch switches back to where workon started and deletes the branch it created.
+func removeBranch(ctx context.Context, s *mkissue.Session, branch string) error {
+	if _, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"checkout", "--quiet", "-"}}); err != nil {
+		return err
+	}
+	_, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"branch", "-D", branch}})
+	return err
+}
+
+// checkout switches to the existing branch for the issue or creates name from
+// the base. It reports whether the branch was created.
+func checkout(ctx context.Context, s *mkissue.Session, name string, number int, opts Options) (string, bool, error) {
+	output, err := s.Run(ctx, runner.Command{
+		Name: "git",
+		Args: []string{"branch", "--list", "--all", "--format=%(refname:short)"},
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	if existing, ok := ExistingBranch(number, name, strings.Split(string(output), "\n"), opts.Remote); ok {
+		if _, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"switch", existing}}); err != nil {
+			return "", false, fmt.Errorf("failed to switch to branch '%s': %w", existing, err)
+		}
+		fmt.Printf("Resuming work on #%d in existing branch '%s'\n", number, existing)
+		return existing, false, nil
+	}
+
+	base := opts.Base
+	if base == "" {
+		branch, err := DefaultBranch(ctx, s, opts.Remote)
+		if err != nil {
+			return "", false, err
+		}
+		if _, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"fetch", opts.Remote, branch}}); err != nil {
+			return "", false, fmt.Errorf("failed to fetch '%s': %w", branch, err)
+		}
+		base = opts.Remote + "/" + branch
+	}
+	if _, err := s.Run(ctx, runner.Command{Name: "git", Args: []string{"switch", "--no-track", "-c", name, base}}); err != nil {
+		return "", false, fmt.Errorf("failed to create branch '%s': %w", name, err)
+	}
+	fmt.Printf("Created branch '%s' from '%s'\n", name, base)
+	return name, true, nil
+}
+
+// DefaultBranch returns the default branch of the remote: from the remote HEAD
+// git knows about, or else from GitHub.
+func DefaultBranch(ctx context.Context, s *mkissue.Session, remote string) (string, error) {
+	output, err := s.Run(ctx, runner.Command{
+		Name: "git",
+		Args: []string{"symbolic-ref", "--quiet", "--short", "refs/remotes/" + remote + "/HEAD"},
+	})
+	if err == nil {
+		if branch := strings.TrimPrefix(strings.TrimSpace(string(output)), remote+"/"); branch != "" {
+			return branch, nil
+		}
+	}
+
+	output, err = s.Run(ctx, runner.Command{
+		Name: "gh",
+		Args: []string{"repo", "view", "--json", "defaultBranchRef", "--jq", ".defaultBranchRef.name"},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to determine the default branch: %w", err)
+	}
+	branch := strings.TrimSpace(string(output))
+	if branch == "" {
+		return "", fmt.Errorf("failed to determine the default branch: repository has none")
+	}
+	return branch, nil
+}
+
+func markInProgress(ctx context.Context, s *mkissue.Session, issue Issue, fields *mkissue.ProjectFields, opts Options) error {
+	number := issue.Number
+	args := []string{"issue", "edit", strconv.Itoa(number)}
+	if opts.Assign {
+		args = append(args, "--add-assignee", "@me")
+	}
+	if opts.Label != "" {
+		if err := s.EnsureLabels(ctx, []mkissue.Label{{Name: opts.Label, Color: inProgressColor}}); err != nil {
+			return err
+		}
+		args = append(args, "--add-label", opts.Label)
+	}
+	if opts.Project != "" {
+		args = append(args, "--add-project", opts.Project)
+	}
+	if len(args) == 3 {
+		return nil
+	}
+	if _, err := s.Run(ctx, runner.Command{Name: "gh", Args: args, Mutating: true}); err != nil {
+		return fmt.Errorf("failed to update issue #%d: %w", number, err)
+	}
+	if err := s.SetProjectFields(ctx, issue.URL, fields); err != nil {
+		return err
+	}
+	fmt.Printf("Marked issue #%d as in progress\n", number)
+	return nil
+}
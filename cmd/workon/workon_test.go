@@ -0,0 +1,178 @@
+package workon
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/cmd/mkissue"
+	"github.com/lakruzz/gh-utils/internal/runner"
+	"github.com/lakruzz/gh-utils/internal/testutil"
+)
+
+func issueView(number, json string) runner.Interaction {
+	return runner.Interaction{Name: "gh", Args: []string{"issue", "view", number, "--json", "number,title,state,url"}, Stdout: json}
+}
+
+func branches(list string) runner.Interaction {
+	return runner.Interaction{Name: "git", Args: []string{"branch", "--list", "--all", "--format=%(refname:short)"}, Stdout: list}
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name         string
+		issue        string
+		opts         Options
+		interactions []runner.Interaction
+		want         string
+	}{
+		{
+			name:  "new branch from default branch",
+			issue: "#42",
+			opts:  Options{Assign: true, Label: "in progress"},
+			interactions: []runner.Interaction{
+				issueView("42", `{"number":42,"title":"Add workon","state":"OPEN"}`),
+				branches("main\norigin/HEAD\norigin/main\n"),
+				testutil.Git("origin/trunk\n", "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"),
+				testutil.Git("", "fetch", "origin", "trunk"),
+				testutil.Git("", "switch", "--no-track", "-c", "42-add-workon", "origin/trunk"),
+				{Name: "gh", Args: []string{"label", "list", "--json", "name", "--jq", ".[].name"}, Stdout: "bug\nin progress\n"},
+				{Name: "gh", Args: []string{"issue", "edit", "42", "--add-assignee", "@me", "--add-label", "in progress"}},
+			},
+			want: "42-add-workon",
+		},
+		{
+			name:  "default branch from GitHub",
+			issue: "7",
+			interactions: []runner.Interaction{
+				issueView("7", `{"number":7,"title":"Fix it","state":"OPEN"}`),
+				branches("main\n"),
+				{Name: "git", Args: []string{"symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"}, ExitCode: 1},
+				{Name: "gh", Args: []string{"repo", "view", "--json", "defaultBranchRef", "--jq", ".defaultBranchRef.name"}, Stdout: "develop\n"},
+				testutil.Git("", "fetch", "origin", "develop"),
+				testutil.Git("", "switch", "--no-track", "-c", "7-fix-it", "origin/develop"),
+			},
+			want: "7-fix-it",
+		},
+		{
+			name:  "explicit base",
+			issue: "7",
+			opts:  Options{Base: "release/1.x"},
+			interactions: []runner.Interaction{
+				issueView("7", `{"number":7,"title":"Fix it","state":"OPEN"}`),
+				branches("main\n"),
+				testutil.Git("", "switch", "--no-track", "-c", "7-fix-it", "release/1.x"),
+			},
+			want: "7-fix-it",
+		},
+		{
+			name:  "resumes existing branch after title change",
+			issue: "42",
+			interactions: []runner.Interaction{
+				issueView("42", `{"number":42,"title":"New title","state":"OPEN"}`),
+				branches("main\norigin/42-old-title\n"),
+				testutil.Git("", "switch", "42-old-title"),
+			},
+			want: "42-old-title",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := runner.NewReplayer(tt.interactions...)
+			tt.opts.Runner = r
+			got, err := Start(context.Background(), tt.issue, tt.opts)
+			if err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Start() = %q, want %q", got, tt.want)
+			}
+			if err := r.Verify(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestStartInvalidIssue(t *testing.T) {
+	for _, issue := range []string{"abc", "0", "-3", ""} {
+		_, err := Start(context.Background(), issue, Options{Runner: runner.NewReplayer()})
+		if !errors.Is(err, mkissue.ErrValidation) {
+			t.Errorf("Start(%q) error = %v, want validation error", issue, err)
+		}
+	}
+}
+
+func TestStartSetsProjectStatus(t *testing.T) {
+	var edits, updates []string
+	r := runner.Func(func(_ context.Context, cmd runner.Command) (runner.Result, error) {
+		args := strings.Join(cmd.Args, " ")
+		switch {
+		case strings.HasPrefix(args, "issue view"):
+			return runner.Result{Stdout: []byte(`{"number":42,"title":"Add workon","state":"OPEN","url":"https://github.com/o/r/issues/42"}`)}, nil
+		case strings.HasPrefix(args, "issue edit"):
+			edits = append(edits, args)
+		case strings.Contains(args, "repository(owner"):
+			return runner.Result{Stdout: []byte(`{"data":{"repository":{"projectsV2":{"nodes":[{"id":"P1","title":"Kanban","fields":{"nodes":[` +
+				`{"id":"F1","name":"Status","dataType":"SINGLE_SELECT","options":[{"id":"O1","name":"Todo"},{"id":"O2","name":"In Progress"}]}]}}]}}}}`)}, nil
+		case strings.Contains(args, "resource(url"):
+			return runner.Result{Stdout: []byte(`{"data":{"resource":{"projectItems":{"nodes":[{"id":"I1","project":{"id":"P1"}}]}}}}`)}, nil
+		case strings.Contains(args, "updateProjectV2ItemFieldValue"):
+			updates = append(updates, cmd.Args[len(cmd.Args)-1])
+		}
+		return runner.Result{}, nil
+	})
+
+	if _, err := Start(context.Background(), "42", Options{Base: "main", Project: "Kanban", Status: "in progress", Runner: r}); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if len(edits) != 1 || !strings.HasSuffix(edits[0], "--add-project Kanban") {
+		t.Errorf("issue edits = %q, want the issue added to Kanban", edits)
+	}
+	if len(updates) != 1 || updates[0] != "value[singleSelectOptionId]=O2" {
+		t.Errorf("field updates = %q, want the In Progress option", updates)
+	}
+
+	// An unknown status fails before the branch is created
+	edits = nil
+	_, err := Start(context.Background(), "42", Options{Base: "main", Project: "Kanban", Status: "Doing", Runner: r})
+	if !errors.Is(err, mkissue.ErrValidation) || !strings.Contains(err.Error(), "unknown option 'Doing'") || len(edits) != 0 {
+		t.Errorf("Start() error = %v, edits = %q; want a validation error and no changes", err, edits)
+	}
+
+	_, err = Start(context.Background(), "42", Options{Status: "In Progress", Runner: runner.NewReplayer()})
+	if !errors.Is(err, mkissue.ErrValidation) {
+		t.Errorf("Start() without a project error = %v, want validation error", err)
+	}
+}
+
+func TestStartDeletesBranchWhenMarkingFails(t *testing.T) {
+	failedEdit := runner.Interaction{Name: "gh", Args: []string{"issue", "edit", "7", "--add-assignee", "@me"}, Stderr: "HTTP 422: Validation Failed", ExitCode: 1}
+	tests := []struct {
+		name    string
+		cleanup []runner.Interaction
+		want    string
+	}{
+		{"deleted", []runner.Interaction{testutil.Git("", "checkout", "--quiet", "-"), testutil.Git("", "branch", "-D", "7-fix-it")}, "branch '7-fix-it' was deleted"},
+		{"kept", []runner.Interaction{{Name: "git", Args: []string{"checkout", "--quiet", "-"}, Stderr: "error: local changes", ExitCode: 1}}, "branch '7-fix-it' was kept"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := runner.NewReplayer(append([]runner.Interaction{
+				issueView("7", `{"number":7,"title":"Fix it","state":"OPEN"}`),
+				branches("main\n"),
+				testutil.Git("", "switch", "--no-track", "-c", "7-fix-it", "main"),
+				failedEdit,
+			}, tt.cleanup...)...)
+			_, err := Start(context.Background(), "7", Options{Base: "main", Assign: true, Runner: r})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Start() error = %v, want %q", err, tt.want)
+			}
+			if err := r.Verify(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+// Package audit keeps an append-only log of the runs of utils that changed
+// something on GitHub, one JSON object per line, so that any label, issue or
+// tag can be traced back to the run that made it.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// EnvFile overrides the location of the audit log.
+const EnvFile = "UTILS_AUDIT_LOG"
+
+// OutcomeSuccess is the outcome of a run that succeeded; failed runs have the
+// kind of error as outcome, see mkissue.KindName.
+const OutcomeSuccess = "success"
+
+// Entry is a run of utils.
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Command is the subcommand, e.g. "mkissue" or "outbox flush".
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	Source  *Source  `json:"source,omitempty"`
+	// Repo is the repository that was changed, in owner/repo format.
+	Repo       string      `json:"repo,omitempty"`
+	Operations []Operation `json:"operations"`
+	// Outcome is OutcomeSuccess or the kind of error, e.g. "partial".
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Source is where the markdown file of a run was read from.
+type Source struct {
+	File   string `json:"file"`
+	Branch string `json:"branch,omitempty"`
+	Gist   string `json:"gist,omitempty"`
+	Repo   string `json:"repo,omitempty"`
+	// SHA is the commit the file was read from, when it came from git.
+	SHA string `json:"sha,omitempty"`
+}
+
+// Operation is a command that changed, or tried to change, something on GitHub.
+type Operation struct {
+	// Action describes the change, e.g. "label create bug" or "issue create".
+	Action string `json:"action"`
+	// URL and Number identify what was created, when the command reported it.
+	URL    string `json:"url,omitempty"`
+	Number int    `json:"number,omitempty"`
+	Failed bool   `json:"failed,omitempty"`
+}
+
+// Path returns the audit log: $UTILS_AUDIT_LOG, or audit.jsonl in the user
+// state directory ($XDG_STATE_HOME/gh-utils, by default ~/.local/state/gh-utils).
+func Path() (string, error) {
+	if path := os.Getenv(EnvFile); path != "" {
+		return path, nil
+	}
+	state := os.Getenv("XDG_STATE_HOME")
+	if state == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate the audit log: %w", err)
+		}
+		state = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(state, "gh-utils", "audit.jsonl"), nil
+}
+
+// Append adds e to the log at path, creating it when needed.
+func Append(path string, e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to write the audit log: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write the audit log: %w", err)
+	}
+	// A single write keeps lines of concurrent runs apart
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write the audit log: %w", err)
+	}
+	return f.Close()
+}
+
+// Read returns the entries of the log at path, oldest first. A missing log
+// has no entries.
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("invalid audit log '%s', line %d: %w", path, line, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// Query selects entries; empty fields match everything.
+type Query struct {
+	// Repo matches the changed repository, case-insensitively.
+	Repo string
+	// Since and Until bound the time of the run; Until is exclusive.
+	Since, Until time.Time
+	// File matches the source file by path, or by trailing path elements.
+	File string
+}
+
+// Match reports whether e is selected by q.
+func (q Query) Match(e Entry) bool {
+	if q.Repo != "" && !strings.EqualFold(q.Repo, e.Repo) {
+		return false
+	}
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Time.Before(q.Until) {
+		return false
+	}
+	if q.File != "" {
+		if e.Source == nil {
+			return false
+		}
+		want := filepath.ToSlash(filepath.Clean(q.File))
+		got := filepath.ToSlash(filepath.Clean(e.Source.File))
+		if got != want && !strings.HasSuffix(got, "/"+want) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the entries selected by q.
+func Filter(entries []Entry, q Query) []Entry {
+	var selected []Entry
+	for _, e := range entries {
+		if q.Match(e) {
+			selected = append(selected, e)
+		}
+	}
+	return selected
+}
+
+var (
+	// urlPattern finds the URL of an issue, pull request or release in the output of gh.
+	urlPattern = regexp.MustCompile(`https://[^\s/]+/([^\s/]+/[^\s/]+)/(?:issues|pull|releases)/(\S+)`)
+	// remotePattern extracts owner/repo from a git remote URL.
+	remotePattern = regexp.MustCompile(`[:/]([^/:]+/[^/]+?)(?:\.git)?/?$`)
+)
+
+// RepoFromRemote returns owner/repo of a git remote URL, e.g.
+// git@github.com:owner/repo.git, or "" when it has no such path.
+func RepoFromRemote(url string) string {
+	if m := remotePattern.FindStringSubmatch(strings.TrimSpace(url)); m != nil {
+		return m[1]
+	}
+	return ""
+}
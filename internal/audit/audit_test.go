@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "audit.jsonl")
+	entries := []Entry{
+		{
+			Time:    time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC),
+			Command: "mkissue",
+			Args:    []string{"mkissue", "--file", "specs/a.issue.md"},
+			Source:  &Source{File: "specs/a.issue.md", SHA: "abc123"},
+			Repo:    "owner/repo",
+			Operations: []Operation{
+				{Action: "label create bug"},
+				{Action: "issue create", URL: "https://github.com/owner/repo/issues/7", Number: 7},
+			},
+			Outcome: OutcomeSuccess,
+		},
+		{
+			Time:       time.Date(2026, 5, 2, 10, 0, 0, 0, time.UTC),
+			Command:    "stable mark",
+			Operations: []Operation{{Action: "git push", Failed: true}},
+			Outcome:    "network",
+			Error:      "failed to push 'stable'",
+		},
+	}
+	for _, e := range entries {
+		if err := Append(path, e); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, entries) {
+		t.Errorf("Read() = %+v, want %+v", got, entries)
+	}
+
+	if got, err := Read(filepath.Join(t.TempDir(), "missing.jsonl")); err != nil || got != nil {
+		t.Errorf("Read(missing) = %v, %v; want no entries", got, err)
+	}
+	if err := os.WriteFile(path, []byte("{}\nnot json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path); err == nil {
+		t.Error("Read() expected error for an invalid line")
+	}
+}
+
+func TestQueryMatch(t *testing.T) {
+	e := Entry{
+		Time:   time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC),
+		Repo:   "Owner/Repo",
+		Source: &Source{File: "specs/features/a.issue.md"},
+	}
+	day := func(d int) time.Time { return time.Date(2026, 5, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name  string
+		query Query
+		want  bool
+	}{
+		{name: "empty query", want: true},
+		{name: "repo ignores case", query: Query{Repo: "owner/repo"}, want: true},
+		{name: "other repo", query: Query{Repo: "owner/other"}},
+		{name: "within dates", query: Query{Since: day(1), Until: day(2)}, want: true},
+		{name: "before since", query: Query{Since: day(2)}},
+		{name: "until is exclusive", query: Query{Until: e.Time}},
+		{name: "full path", query: Query{File: "specs/features/a.issue.md"}, want: true},
+		{name: "trailing path", query: Query{File: "./features/a.issue.md"}, want: true},
+		{name: "partial name", query: Query{File: "a.issue.md"}, want: true},
+		{name: "other file", query: Query{File: "b.issue.md"}},
+		{name: "suffix of a name", query: Query{File: "issue.md"}},
+	}
+	for _, tt := range tests {
+		if got := tt.query.Match(e); got != tt.want {
+			t.Errorf("%s: Match() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if (Query{File: "a.issue.md"}).Match(Entry{}) {
+		t.Error("Match() selected an entry without a source by file")
+	}
+}
+
+func TestPath(t *testing.T) {
+	t.Setenv(EnvFile, "")
+	t.Setenv("XDG_STATE_HOME", "/state")
+	if got, _ := Path(); got != filepath.Join("/state", "gh-utils", "audit.jsonl") {
+		t.Errorf("Path() = %s", got)
+	}
+	t.Setenv(EnvFile, "/tmp/audit.jsonl")
+	if got, _ := Path(); got != "/tmp/audit.jsonl" {
+		t.Errorf("Path() = %s, want $%s", got, EnvFile)
+	}
+}
+
+func TestRepoFromRemote(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:owner/repo.git":            "owner/repo",
+		"https://github.com/owner/repo.git":        "owner/repo",
+		"https://github.com/owner/repo\n":          "owner/repo",
+		"ssh://git@ghe.example.com/org/tool.js":    "org/tool.js",
+		"https://ghe.example.com/org/service.git/": "org/service",
+		"/srv/git/bare":                            "git/bare",
+	}
+	for url, want := range tests {
+		if got := RepoFromRemote(url); got != want {
+			t.Errorf("RepoFromRemote(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
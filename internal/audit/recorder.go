@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// Recorder wraps a Runner and keeps the operations of the commands that
+// change state on GitHub (runner.Command.Mutating or Audited), including the
+// undo commands of a rollback.
+type Recorder struct {
+	next runner.Runner
+
+	mu         sync.Mutex
+	operations []Operation
+}
+
+// NewRecorder returns a Recorder that passes every command on to next.
+func NewRecorder(next runner.Runner) *Recorder {
+	return &Recorder{next: next}
+}
+
+// Run runs cmd with the wrapped Runner and records it when it is mutating or
+// audited.
+func (r *Recorder) Run(ctx context.Context, cmd runner.Command) (runner.Result, error) {
+	res, err := r.next.Run(ctx, cmd)
+	if !cmd.Mutating && !cmd.Audited {
+		return res, err
+	}
+
+	op := Operation{Action: action(cmd), Failed: err != nil}
+	if m := urlPattern.FindStringSubmatch(string(res.Stdout)); m != nil {
+		op.URL = m[0]
+		op.Number, _ = strconv.Atoi(m[2])
+	}
+	r.mu.Lock()
+	r.operations = append(r.operations, op)
+	r.mu.Unlock()
+	return res, err
+}
+
+// Operations returns a copy of the operations recorded so far.
+func (r *Recorder) Operations() []Operation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Operation(nil), r.operations...)
+}
+
+// Repo returns owner/repo of the first URL reported by an operation, or "".
+func (r *Recorder) Repo() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, op := range r.operations {
+		if m := urlPattern.FindStringSubmatch(op.URL); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// action describes a command without its flags, e.g. "label create bug",
+// "issue create", "api POST repos/o/r/statuses/abc" or "git push origin v1.0".
+func action(cmd runner.Command) string {
+	if cmd.Name == "gh" && len(cmd.Args) > 0 && cmd.Args[0] == "api" {
+		method, endpoint := "GET", ""
+		for i := 1; i < len(cmd.Args); i++ {
+			switch arg := cmd.Args[i]; {
+			case (arg == "--method" || arg == "-X") && i+1 < len(cmd.Args):
+				method = cmd.Args[i+1]
+				i++
+			case strings.HasPrefix(arg, "-"):
+				// Skip the value of flags like -f key=value
+				if !strings.Contains(arg, "=") && i+1 < len(cmd.Args) {
+					i++
+				}
+			case endpoint == "":
+				endpoint = arg
+			}
+		}
+		return strings.TrimSpace("api " + method + " " + endpoint)
+	}
+
+	if cmd.Name != "gh" {
+		// Mutating git commands are pushes, whose flags take no values
+		words := []string{cmd.Name}
+		for _, arg := range cmd.Args {
+			if !strings.HasPrefix(arg, "-") {
+				words = append(words, arg)
+			}
+		}
+		return strings.Join(words, " ")
+	}
+	// Other gh commands are a noun and a verb, followed by the subject
+	var words []string
+	for _, arg := range cmd.Args {
+		if strings.HasPrefix(arg, "-") || len(words) == 3 {
+			break
+		}
+		words = append(words, arg)
+	}
+	return strings.Join(words, " ")
+}
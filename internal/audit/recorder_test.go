@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder(runner.Func(func(_ context.Context, cmd runner.Command) (runner.Result, error) {
+		if cmd.Args[0] == "label" && cmd.Args[1] == "delete" {
+			res := runner.Result{Stderr: []byte("HTTP 403"), ExitCode: 1}
+			return res, &runner.ExitError{Command: cmd, Result: res}
+		}
+		if cmd.Args[0] == "issue" {
+			return runner.Result{Stdout: []byte("Creating issue in owner/repo\n\nhttps://github.com/owner/repo/issues/42\n")}, nil
+		}
+		return runner.Result{}, nil
+	}))
+
+	commands := []runner.Command{
+		{Name: "gh", Args: []string{"label", "list", "--json", "name"}},
+		{Name: "gh", Args: []string{"label", "create", "needs-triage", "--color", "fbca04"}, Mutating: true},
+		{Name: "gh", Args: []string{"issue", "create", "--title", "T", "--body-file", "-"}, Mutating: true},
+		{Name: "gh", Args: []string{"label", "delete", "needs-triage", "--yes"}, Mutating: true},
+		{Name: "gh", Args: []string{"api", "--method", "POST", "repos/o/r/statuses/abc", "-f", "state=success"}, Audited: true},
+		{Name: "git", Args: []string{"push", "--force", "origin", "refs/tags/stable"}, Mutating: true},
+	}
+	for _, cmd := range commands {
+		_, _ = r.Run(context.Background(), cmd)
+	}
+
+	want := []Operation{
+		{Action: "label create needs-triage"},
+		{Action: "issue create", URL: "https://github.com/owner/repo/issues/42", Number: 42},
+		{Action: "label delete needs-triage", Failed: true},
+		{Action: "api POST repos/o/r/statuses/abc"},
+		{Action: "git push origin refs/tags/stable"},
+	}
+	if got := r.Operations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Operations() =\n%+v\nwant\n%+v", got, want)
+	}
+	if got := r.Repo(); got != "owner/repo" {
+		t.Errorf("Repo() = %q, want owner/repo", got)
+	}
+}
@@ -0,0 +1,478 @@
+// Package config loads the utils configuration file, .utils.yml, from the
+// root of the current git repository.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileName is the name of the configuration file at the repository root.
+const FileName = ".utils.yml"
+
+// EnvFile overrides the location of the configuration file.
+const EnvFile = "UTILS_CONFIG"
+
+// Config is the utils configuration. Every section has defaults, so a
+// repository without a configuration file gets a usable Config.
+type Config struct {
+	// Path is the file the configuration was read from, or "" for the defaults.
+	Path string
+
+	ReleaseNotes ReleaseNotes
+	TrunkWorthy  TrunkWorthy
+	Hosts        Hosts
+	Secrets      Secrets
+	Mentions     Mentions
+}
+
+// ReleaseNotes configures how utils releasenotes groups changes.
+type ReleaseNotes struct {
+	// Categories are matched in order; a change goes into the first category
+	// that has one of its labels.
+	Categories []Category
+	// Other is the heading for changes that match no category; "" leaves them out.
+	Other string
+	// Exclude lists labels that keep a change out of the notes.
+	Exclude []string
+}
+
+// Category is a section of the release notes.
+type Category struct {
+	Title  string
+	Labels []string
+}
+
+// TrunkWorthy configures the checks utils trunk-worthy runs.
+type TrunkWorthy struct {
+	// Parallelism bounds how many checks of a wave run at once; 0 means one per CPU.
+	Parallelism int
+	// Checks are the defined checks by name.
+	Checks map[string]Check
+	// Waves lists the check names to run, wave by wave. A wave only starts
+	// when all checks of the previous wave passed.
+	Waves [][]string
+}
+
+// Hosts configures the GitHub hosts gh talks to, e.g. github.com and a GitHub
+// Enterprise Server instance.
+type Hosts struct {
+	// Default is the host used when neither --hostname nor a HOST/OWNER/REPO
+	// repository picks one; "" leaves it to gh.
+	Default string
+	// Aliases maps short names, accepted wherever a host is given, to host names.
+	Aliases map[string]string
+}
+
+// Resolve returns the host name for name, which may be an alias.
+func (h Hosts) Resolve(name string) string {
+	if host, ok := h.Aliases[name]; ok {
+		return host
+	}
+	return name
+}
+
+// Secrets configures the secret scanner that checks issues before they are
+// published, on top of its built-in patterns.
+type Secrets struct {
+	// Patterns are the custom patterns to report.
+	Patterns []SecretPattern
+	// Allow lists regular expressions of matches that are not secrets, such as
+	// example tokens in documentation.
+	Allow []string
+}
+
+// SecretPattern is a custom pattern of the secret scanner.
+type SecretPattern struct {
+	Name  string
+	Regex string
+}
+
+// Mentions configures what utils mkissue does with @user and @org/team
+// mentions that would notify people when an issue is published.
+type Mentions struct {
+	// Policy is allow, warn, escape or allowlist; "" warns.
+	Policy string
+	// Allowlist holds the logins and teams that may always be mentioned.
+	Allowlist []string
+	// Escape is how the escape policy escapes a mention: backticks or zwj.
+	Escape string
+}
+
+// Check is a command run by utils trunk-worthy.
+type Check struct {
+	// Name is the single-word name, also used as the commit status context.
+	Name string
+	// Command is run with sh -c from the repository root, or from the
+	// current directory outside a repository.
+	Command string
+	// Display is shown instead of the capitalized name.
+	Display string
+}
+
+var (
+	checkNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	// hostPattern matches a host name with an optional port.
+	hostPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9.-]*(:[0-9]+)?$`)
+)
+
+// Default returns the configuration used when there is no configuration file.
+func Default() *Config {
+	return &Config{
+		ReleaseNotes: ReleaseNotes{
+			Categories: []Category{
+				{Title: "🚀 Features", Labels: []string{"enhancement", "feature"}},
+				{Title: "🐛 Bug Fixes", Labels: []string{"bug", "fix"}},
+				{Title: "📚 Documentation", Labels: []string{"documentation"}},
+			},
+			Other:   "Other Changes",
+			Exclude: []string{"skip-changelog"},
+		},
+	}
+}
+
+// Load reads the configuration file named by $UTILS_CONFIG, or .utils.yml in
+// the current directory or the closest parent that is a git repository root.
+// Without a configuration file it returns Default().
+func Load() (*Config, error) {
+	path := os.Getenv(EnvFile)
+	if path == "" {
+		path = find()
+	}
+	if path == "" {
+		return Default(), nil
+	}
+	return LoadFile(path)
+}
+
+// LoadFile reads the configuration from path. Sections and keys that are not
+// set keep their defaults.
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config '%s': %w", path, err)
+	}
+	cfg.Path = path
+	return cfg, nil
+}
+
+// Parse parses the content of a configuration file.
+func Parse(data []byte) (*Config, error) {
+	tree, err := ParseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	cfg := Default()
+	if tree == nil {
+		return cfg, nil
+	}
+	root, ok := tree.(map[string]any)
+	if !ok {
+		return nil, errors.New("expected a mapping at the top level")
+	}
+	if err := cfg.ReleaseNotes.decode(root["releasenotes"]); err != nil {
+		return nil, fmt.Errorf("releasenotes: %w", err)
+	}
+	if err := cfg.TrunkWorthy.decode(root["trunk-worthy"]); err != nil {
+		return nil, fmt.Errorf("trunk-worthy: %w", err)
+	}
+	if err := cfg.Hosts.decode(root["host"], root["hosts"]); err != nil {
+		return nil, err
+	}
+	if err := cfg.Secrets.decode(root["secrets"]); err != nil {
+		return nil, fmt.Errorf("secrets: %w", err)
+	}
+	if err := cfg.Mentions.decode(root["mentions"]); err != nil {
+		return nil, fmt.Errorf("mentions: %w", err)
+	}
+	return cfg, nil
+}
+
+func (r *ReleaseNotes) decode(v any) error {
+	m, err := Map(v)
+	if err != nil || m == nil {
+		return err
+	}
+	if v, ok := m["categories"]; ok {
+		items, err := List(v)
+		if err != nil {
+			return fmt.Errorf("categories: %w", err)
+		}
+		r.Categories = nil
+		for i, item := range items {
+			c, err := Map(item)
+			if err != nil {
+				return fmt.Errorf("categories[%d]: %w", i, err)
+			}
+			category := Category{Title: String(c["title"])}
+			if category.Title == "" {
+				return fmt.Errorf("categories[%d]: title is required", i)
+			}
+			if category.Labels, err = Strings(c["labels"]); err != nil {
+				return fmt.Errorf("categories[%d].labels: %w", i, err)
+			}
+			r.Categories = append(r.Categories, category)
+		}
+	}
+	if v, ok := m["other"]; ok {
+		r.Other = String(v)
+	}
+	if v, ok := m["exclude"]; ok {
+		if r.Exclude, err = Strings(v); err != nil {
+			return fmt.Errorf("exclude: %w", err)
+		}
+	}
+	return nil
+}
+
+func (t *TrunkWorthy) decode(v any) error {
+	m, err := Map(v)
+	if err != nil || m == nil {
+		return err
+	}
+	if t.Parallelism, err = Int(m["parallelism"]); err != nil || t.Parallelism < 0 {
+		return fmt.Errorf("parallelism: expected a positive number, got %q", String(m["parallelism"]))
+	}
+
+	checks, err := Map(m["checks"])
+	if err != nil {
+		return fmt.Errorf("checks: %w", err)
+	}
+	t.Checks = map[string]Check{}
+	for name, v := range checks {
+		if !checkNamePattern.MatchString(name) {
+			return fmt.Errorf("checks: invalid name %q: use a single lowercase word", name)
+		}
+		check := Check{Name: name, Command: String(v)}
+		if c, ok := v.(map[string]any); ok {
+			check.Command, check.Display = String(c["command"]), String(c["name"])
+		}
+		if check.Command == "" {
+			return fmt.Errorf("checks.%s: command is required", name)
+		}
+		t.Checks[name] = check
+	}
+
+	waves, err := List(m["waves"])
+	if err != nil {
+		return fmt.Errorf("waves: %w", err)
+	}
+	seen := map[string]bool{}
+	for i, v := range waves {
+		wave, err := Strings(v)
+		if err != nil {
+			return fmt.Errorf("waves[%d]: %w", i, err)
+		}
+		for _, name := range wave {
+			if _, ok := t.Checks[name]; !ok {
+				return fmt.Errorf("waves[%d]: unknown check %q", i, name)
+			}
+			if seen[name] {
+				return fmt.Errorf("waves[%d]: check %q is already in an earlier wave", i, name)
+			}
+			seen[name] = true
+		}
+		// Empty waves are skipped, like a commented out wave
+		if len(wave) > 0 {
+			t.Waves = append(t.Waves, wave)
+		}
+	}
+	return nil
+}
+
+func (h *Hosts) decode(host, hosts any) error {
+	if _, ok := host.(string); host != nil && !ok {
+		return errors.New("host: expected a host name")
+	}
+	m, err := Map(hosts)
+	if err != nil {
+		return fmt.Errorf("hosts: %w", err)
+	}
+	h.Aliases = map[string]string{}
+	for name, v := range m {
+		if !hostPattern.MatchString(name) {
+			return fmt.Errorf("hosts: invalid host name %q", name)
+		}
+		settings, err := Map(v)
+		if err != nil {
+			return fmt.Errorf("hosts.%s: %w", name, err)
+		}
+		aliases, err := Strings(settings["aliases"])
+		if err != nil {
+			return fmt.Errorf("hosts.%s.aliases: %w", name, err)
+		}
+		for _, alias := range aliases {
+			if other, dup := h.Aliases[alias]; dup && other != name {
+				return fmt.Errorf("hosts.%s.aliases: %q is already an alias of %s", name, alias, other)
+			}
+			h.Aliases[alias] = name
+		}
+	}
+	h.Default = h.Resolve(String(host))
+	return nil
+}
+
+func (s *Secrets) decode(v any) error {
+	m, err := Map(v)
+	if err != nil || m == nil {
+		return err
+	}
+	patterns, err := List(m["patterns"])
+	if err != nil {
+		return fmt.Errorf("patterns: %w", err)
+	}
+	for i, item := range patterns {
+		p, err := Map(item)
+		if err != nil {
+			return fmt.Errorf("patterns[%d]: %w", i, err)
+		}
+		pattern := SecretPattern{Name: String(p["name"]), Regex: String(p["regex"])}
+		if pattern.Name == "" || pattern.Regex == "" {
+			return fmt.Errorf("patterns[%d]: name and regex are required", i)
+		}
+		if _, err := regexp.Compile(pattern.Regex); err != nil {
+			return fmt.Errorf("patterns[%d]: invalid regex: %w", i, err)
+		}
+		s.Patterns = append(s.Patterns, pattern)
+	}
+	if s.Allow, err = Strings(m["allow"]); err != nil {
+		return fmt.Errorf("allow: %w", err)
+	}
+	for i, allow := range s.Allow {
+		if _, err := regexp.Compile(allow); err != nil {
+			return fmt.Errorf("allow[%d]: invalid regex: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (m *Mentions) decode(v any) error {
+	settings, err := Map(v)
+	if err != nil || settings == nil {
+		return err
+	}
+	m.Policy = String(settings["policy"])
+	switch m.Policy {
+	case "", "allow", "warn", "escape", "allowlist":
+	default:
+		return fmt.Errorf("policy must be allow, warn, escape or allowlist, got %q", m.Policy)
+	}
+	m.Escape = String(settings["escape"])
+	if m.Escape != "" && m.Escape != "backticks" && m.Escape != "zwj" {
+		return fmt.Errorf("escape must be backticks or zwj, got %q", m.Escape)
+	}
+	if m.Allowlist, err = Strings(settings["allowlist"]); err != nil {
+		return fmt.Errorf("allowlist: %w", err)
+	}
+	return nil
+}
+
+// find returns the .utils.yml in the working directory or its closest parent
+// that contains one, stopping at the repository root.
+func find() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Map returns v as a mapping; nil stays nil.
+func Map(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a mapping, got %q", String(v))
+	}
+	return m, nil
+}
+
+// List returns v as a sequence; a single value becomes a one-element list.
+func List(v any) ([]any, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		return v, nil
+	case map[string]any:
+		return nil, errors.New("expected a list, got a mapping")
+	}
+	return []any{v}, nil
+}
+
+// Strings returns v as a list of scalars.
+func Strings(v any) ([]string, error) {
+	items, err := List(v)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, item := range items {
+		switch item.(type) {
+		case []any, map[string]any:
+			return nil, errors.New("expected a list of values")
+		}
+		if s := String(item); s != "" {
+			values = append(values, s)
+		}
+	}
+	return values, nil
+}
+
+// String returns a scalar value, or "" for nil and collections.
+func String(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+// Bool parses a boolean scalar (true/false, yes/no, on/off); nil is false.
+func Bool(v any) (bool, error) {
+	switch strings.ToLower(String(v)) {
+	case "":
+		if v != nil {
+			return false, errors.New("expected true or false")
+		}
+		return false, nil
+	case "true", "yes", "on":
+		return true, nil
+	case "false", "no", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected true or false, got %q", String(v))
+}
+
+// Int parses an integer scalar; nil is 0.
+func Int(v any) (int, error) {
+	if v == nil {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(String(v))
+	if err != nil {
+		return 0, fmt.Errorf("expected a number, got %q", String(v))
+	}
+	return n, nil
+}
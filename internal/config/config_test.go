@@ -0,0 +1,211 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	cfg, err := LoadFile(filepath.Join("testdata", "releasenotes.yml"))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	want := ReleaseNotes{
+		Categories: []Category{
+			{Title: "✨ New", Labels: []string{"enhancement"}},
+			{Title: "Fixed", Labels: []string{"bug"}},
+		},
+		Exclude: []string{"skip-changelog", "dependencies"},
+	}
+	if !reflect.DeepEqual(cfg.ReleaseNotes, want) {
+		t.Errorf("ReleaseNotes = %+v, want %+v", cfg.ReleaseNotes, want)
+	}
+}
+
+func TestParseKeepsDefaults(t *testing.T) {
+	cfg, err := Parse([]byte("releasenotes:\n  exclude: [wontfix]\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg.ReleaseNotes.Categories, Default().ReleaseNotes.Categories) {
+		t.Errorf("Categories = %+v, want the defaults", cfg.ReleaseNotes.Categories)
+	}
+	if !reflect.DeepEqual(cfg.ReleaseNotes.Exclude, []string{"wontfix"}) {
+		t.Errorf("Exclude = %v, want [wontfix]", cfg.ReleaseNotes.Exclude)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"- not a mapping\n",
+		"releasenotes: [a, b]\n",
+		"releasenotes:\n  categories:\n    - labels: [bug]\n",
+	} {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%q) expected error", input)
+		}
+	}
+}
+
+func TestLoadFindsRepositoryConfig(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "docs", "specs")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, FileName), []byte("releasenotes:\n  other: Misc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(sub); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	t.Setenv(EnvFile, "")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.ReleaseNotes.Other != "Misc" {
+		t.Errorf("Other = %q, want Misc (config %s)", cfg.ReleaseNotes.Other, cfg.Path)
+	}
+}
+
+func TestParseTrunkWorthy(t *testing.T) {
+	cfg, err := Parse([]byte(`trunk-worthy:
+  parallelism: 2
+  checks:
+    lint: golangci-lint run
+    build:
+      command: make build
+      name: Build (for this OS/Arch only)
+    coverage:
+      command: make coverage
+  waves:
+    - [lint, build]
+    - []
+    - [coverage]
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := TrunkWorthy{
+		Parallelism: 2,
+		Checks: map[string]Check{
+			"lint":     {Name: "lint", Command: "golangci-lint run"},
+			"build":    {Name: "build", Command: "make build", Display: "Build (for this OS/Arch only)"},
+			"coverage": {Name: "coverage", Command: "make coverage"},
+		},
+		Waves: [][]string{{"lint", "build"}, {"coverage"}},
+	}
+	if !reflect.DeepEqual(cfg.TrunkWorthy, want) {
+		t.Errorf("TrunkWorthy = %+v, want %+v", cfg.TrunkWorthy, want)
+	}
+}
+
+func TestParseTrunkWorthyErrors(t *testing.T) {
+	for _, input := range []string{
+		"trunk-worthy:\n  checks:\n    Lint: x\n",
+		"trunk-worthy:\n  checks:\n    lint: {name: Lint}\n",
+		"trunk-worthy:\n  checks:\n    lint: x\n  waves: [[lint], [build]]\n",
+		"trunk-worthy:\n  checks:\n    lint: x\n  waves: [[lint], [lint]]\n",
+		"trunk-worthy:\n  parallelism: many\n",
+	} {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%q) expected error", input)
+		}
+	}
+}
+
+func TestParseHosts(t *testing.T) {
+	cfg, err := Parse([]byte("host: ghes\nhosts:\n  ghes.example.com:\n    aliases: [ghes, work]\n  localhost:8080:\n    aliases: [stub]\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := Hosts{
+		Default: "ghes.example.com",
+		Aliases: map[string]string{"ghes": "ghes.example.com", "work": "ghes.example.com", "stub": "localhost:8080"},
+	}
+	if !reflect.DeepEqual(cfg.Hosts, want) {
+		t.Errorf("Hosts = %+v, want %+v", cfg.Hosts, want)
+	}
+	if got := cfg.Hosts.Resolve("github.com"); got != "github.com" {
+		t.Errorf("Resolve(github.com) = %s", got)
+	}
+}
+
+func TestParseHostsErrors(t *testing.T) {
+	for _, input := range []string{
+		"host: [a, b]\n",
+		"hosts: [ghes.example.com]\n",
+		"hosts:\n  https://ghes.example.com:\n    aliases: [ghes]\n",
+		"hosts:\n  ghes.example.com:\n    aliases: {a: b}\n",
+		"hosts:\n  a.example.com:\n    aliases: [x]\n  b.example.com:\n    aliases: [x]\n",
+	} {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%q) expected error", input)
+		}
+	}
+}
+
+func TestParseSecrets(t *testing.T) {
+	cfg, err := Parse([]byte(`secrets:
+  patterns:
+    - name: Acme token
+      regex: "acme_[a-z0-9]{32}"
+  allow: ["EXAMPLE"]
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := Secrets{
+		Patterns: []SecretPattern{{Name: "Acme token", Regex: "acme_[a-z0-9]{32}"}},
+		Allow:    []string{"EXAMPLE"},
+	}
+	if !reflect.DeepEqual(cfg.Secrets, want) {
+		t.Errorf("Secrets = %+v, want %+v", cfg.Secrets, want)
+	}
+
+	for _, input := range []string{
+		"secrets: [a]\n",
+		"secrets:\n  patterns: [{name: x}]\n",
+		"secrets:\n  patterns: [{name: x, regex: \"a(\"}]\n",
+		"secrets:\n  allow: [\"[\"]\n",
+	} {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%q) expected error", input)
+		}
+	}
+}
+
+func TestParseMentions(t *testing.T) {
+	cfg, err := Parse([]byte(`mentions:
+  policy: escape
+  escape: zwj
+  allowlist: [octocat, acme/docs]
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := Mentions{Policy: "escape", Escape: "zwj", Allowlist: []string{"octocat", "acme/docs"}}
+	if !reflect.DeepEqual(cfg.Mentions, want) {
+		t.Errorf("Mentions = %+v, want %+v", cfg.Mentions, want)
+	}
+
+	for _, input := range []string{
+		"mentions: [a]\n",
+		"mentions:\n  policy: block\n",
+		"mentions:\n  escape: quotes\n",
+		"mentions:\n  allowlist: {a: b}\n",
+	} {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%q) expected error", input)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParseYAML parses the first YAML document in data, for utils configuration
+// and frontmatter. Mappings become map[string]any, sequences []any and
+// scalars string, whatever their YAML type, so "3" and "yes" are read as they
+// are written; empty values, null and ~ become nil. Aliases are resolved and
+// keys must be scalars that appear once per mapping.
+func ParseYAML(data []byte) (any, error) {
+	// A block scalar on the last line keeps its line break, like on the others
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data[:len(data):len(data)], '\n')
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return nil, nil
+	}
+	return fromNode(doc.Content[0])
+}
+
+// fromNode converts a parsed node into maps, slices and strings.
+func fromNode(n *yaml.Node) (any, error) {
+	switch n.Kind {
+	case yaml.AliasNode:
+		return fromNode(n.Alias)
+	case yaml.ScalarNode:
+		if n.ShortTag() == "!!null" {
+			return nil, nil
+		}
+		return n.Value, nil
+	case yaml.SequenceNode:
+		list := []any{}
+		for _, item := range n.Content {
+			value, err := fromNode(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	case yaml.MappingNode:
+		m := map[string]any{}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i]
+			if key.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: keys must be scalars", key.Line)
+			}
+			if _, dup := m[key.Value]; dup {
+				return nil, fmt.Errorf("line %d: duplicate key %q", key.Line, key.Value)
+			}
+			value, err := fromNode(n.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			m[key.Value] = value
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("line %d: unsupported YAML node", n.Line)
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    any
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			input: "# only a comment\n",
+			want:  nil,
+		},
+		{
+			name:  "scalars",
+			input: "---\ntitle: \"Quoted: # not a comment\"\nplain: hello world # comment\nsingle: 'it''s'\nempty:\nnull: ~\n",
+			want: map[string]any{
+				"title":  "Quoted: # not a comment",
+				"plain":  "hello world",
+				"single": "it's",
+				"empty":  nil,
+				"null":   nil,
+			},
+		},
+		{
+			name:  "nested mappings and sequences",
+			input: "checks:\n  lint:\n    command: make lint\nwaves:\n  - [lint, build]\n  -\n    - test\nlabels:\n- bug\n- \"help wanted\"\n",
+			want: map[string]any{
+				"checks": map[string]any{"lint": map[string]any{"command": "make lint"}},
+				"waves":  []any{[]any{"lint", "build"}, []any{"test"}},
+				"labels": []any{"bug", "help wanted"},
+			},
+		},
+		{
+			name:  "sequence of mappings",
+			input: "categories:\n  - title: Features\n    labels: [enhancement, feature]\n  - title: Fixes\n    labels:\n      - bug\n",
+			want: map[string]any{
+				"categories": []any{
+					map[string]any{"title": "Features", "labels": []any{"enhancement", "feature"}},
+					map[string]any{"title": "Fixes", "labels": []any{"bug"}},
+				},
+			},
+		},
+		{
+			name:  "flow mappings",
+			input: `projects: [{title: Roadmap, fields: {Status: Todo, Iteration: "@current", Estimate: 3}}]`,
+			want: map[string]any{
+				"projects": []any{map[string]any{
+					"title":  "Roadmap",
+					"fields": map[string]any{"Status": "Todo", "Iteration": "@current", "Estimate": "3"},
+				}},
+			},
+		},
+		{
+			name:  "urls and colons in plain scalars",
+			input: "url: https://github.com/lakruzz/gh-utils\ntime: 12:30\n",
+			want:  map[string]any{"url": "https://github.com/lakruzz/gh-utils", "time": "12:30"},
+		},
+		{
+			name:  "block scalars",
+			input: "literal: |\n  line one\n\n  line two\nfolded: >-\n  one\n  two\nnext: x\n",
+			want:  map[string]any{"literal": "line one\n\nline two\n", "folded": "one two", "next": "x"},
+		},
+		{
+			name:  "typed scalars stay strings",
+			input: "count: 3\nenabled: yes\ndue: 2026-06-30\n",
+			want:  map[string]any{"count": "3", "enabled": "yes", "due": "2026-06-30"},
+		},
+		{
+			name:  "aliases",
+			input: "base: &base [bug]\nlabels: *base\n",
+			want:  map[string]any{"base": []any{"bug"}, "labels": []any{"bug"}},
+		},
+		{
+			name:  "only the first document",
+			input: "a: 1\n---\nb: 2\n",
+			want:  map[string]any{"a": "1"},
+		},
+		{
+			name:    "tab indentation",
+			input:   "a:\n\tb: 2\n",
+			wantErr: true,
+		},
+		{
+			name:    "non-scalar key",
+			input:   "? [a, b]\n: 1\n",
+			wantErr: true,
+		},
+		{
+			name:    "bad indentation",
+			input:   "a: 1\n   b: 2\n",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate key",
+			input:   "a: 1\na: 2\n",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated flow",
+			input:   "a: [1, 2\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseYAML([]byte(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseYAML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseYAML() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// Package ghhost picks the GitHub host that gh talks to, so the same commands
+// and files work against github.com and GitHub Enterprise Server instances.
+package ghhost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// Default is the host gh uses when none is given.
+const Default = "github.com"
+
+// EnvHost is the environment variable gh reads the host from, and EnvRepo the
+// one it reads the repository from, in [HOST/]OWNER/REPO format.
+const (
+	EnvHost = "GH_HOST"
+	EnvRepo = "GH_REPO"
+)
+
+var (
+	namePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+	hostPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9.-]*(:[0-9]+)?$`)
+)
+
+// Repo is a repository, optionally on a specific host.
+type Repo struct {
+	// Host is "" when the repository didn't name one.
+	Host  string
+	Owner string
+	Name  string
+}
+
+// ParseRepo parses a repository in OWNER/REPO or HOST/OWNER/REPO format.
+func ParseRepo(s string) (Repo, error) {
+	parts := strings.Split(s, "/")
+	var r Repo
+	switch len(parts) {
+	case 2:
+		r = Repo{Owner: parts[0], Name: parts[1]}
+	case 3:
+		r = Repo{Host: parts[0], Owner: parts[1], Name: parts[2]}
+		if !ValidHost(r.Host) {
+			return Repo{}, errors.New("invalid repository format: must be 'owner/repo' or 'host/owner/repo'")
+		}
+	default:
+		return Repo{}, errors.New("invalid repository format: must be 'owner/repo' or 'host/owner/repo'")
+	}
+	if !namePattern.MatchString(r.Owner) || !namePattern.MatchString(r.Name) {
+		return Repo{}, errors.New("invalid repository format: must be 'owner/repo' or 'host/owner/repo'")
+	}
+	return r, nil
+}
+
+// ValidHost reports whether host is a host name with an optional port.
+func ValidHost(host string) bool {
+	return hostPattern.MatchString(host)
+}
+
+// FullName returns the repository in OWNER/REPO format, as used in API paths.
+func (r Repo) FullName() string {
+	return r.Owner + "/" + r.Name
+}
+
+// String returns the repository in the format it was given.
+func (r Repo) String() string {
+	if r.Host == "" {
+		return r.FullName()
+	}
+	return r.Host + "/" + r.FullName()
+}
+
+// APIArgs returns the flags that point 'gh api' at the host of the repository.
+func (r Repo) APIArgs() []string {
+	if r.Host == "" {
+		return nil
+	}
+	return []string{"--hostname", r.Host}
+}
+
+// ParseRemote parses the URL of a git remote, such as
+// git@github.com:owner/repo.git or https://github.com/owner/repo, into the
+// repository and the host it's on.
+func ParseRemote(remote string) (Repo, error) {
+	remote = strings.TrimSpace(remote)
+	var host, path string
+	if u, err := url.Parse(remote); err == nil && u.Host != "" {
+		host, path = u.Host, u.Path
+		if u.Scheme != "http" && u.Scheme != "https" {
+			// The port of an ssh URL isn't the port of the API
+			host = u.Hostname()
+		}
+	} else if before, after, ok := strings.Cut(remote, ":"); ok && !strings.Contains(before, "/") {
+		host, path = before[strings.LastIndex(before, "@")+1:], after
+	}
+	r, err := ParseRepo(strings.TrimSuffix(strings.Trim(path, "/"), ".git"))
+	if err != nil || r.Host != "" || !ValidHost(host) {
+		return Repo{}, fmt.Errorf("'%s' is not the URL of a GitHub repository", remote)
+	}
+	r.Host = host
+	return r, nil
+}
+
+// FromServerURL returns the host of a server URL such as $GITHUB_SERVER_URL,
+// or "" for github.com and URLs without a host.
+func FromServerURL(serverURL string) string {
+	u, err := url.Parse(serverURL)
+	if err != nil || u.Host == "" || u.Host == Default {
+		return ""
+	}
+	return u.Host
+}
+
+// WithHost returns a Runner that runs every gh command against host by
+// setting GH_HOST, unless the command sets it itself. Commands that pass
+// --hostname, or that work on the repository of the local checkout, still
+// follow what they were given.
+func WithHost(next runner.Runner, host string) runner.Runner {
+	return runner.Func(func(ctx context.Context, cmd runner.Command) (runner.Result, error) {
+		if cmd.Name == "gh" && !hasEnv(cmd.Env, EnvHost) {
+			cmd.Env = append(append([]string{}, cmd.Env...), EnvHost+"="+host)
+		}
+		return next.Run(ctx, cmd)
+	})
+}
+
+// WithRepo returns a Runner that runs every gh command against r by setting
+// GH_REPO, and GH_HOST to the host of r, unless the command sets them itself.
+func WithRepo(next runner.Runner, r Repo) runner.Runner {
+	return runner.Func(func(ctx context.Context, cmd runner.Command) (runner.Result, error) {
+		if cmd.Name == "gh" {
+			env := append([]string{}, cmd.Env...)
+			if !hasEnv(env, EnvRepo) {
+				env = append(env, EnvRepo+"="+r.String())
+			}
+			if r.Host != "" && !hasEnv(env, EnvHost) {
+				env = append(env, EnvHost+"="+r.Host)
+			}
+			cmd.Env = env
+		}
+		return next.Run(ctx, cmd)
+	})
+}
+
+func hasEnv(env []string, name string) bool {
+	for _, kv := range env {
+		if strings.HasPrefix(kv, name+"=") {
+			return true
+		}
+	}
+	return false
+}
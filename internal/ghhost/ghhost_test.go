@@ -0,0 +1,132 @@
+package ghhost
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+func TestParseRepo(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Repo
+		wantErr bool
+	}{
+		{input: "owner/repo", want: Repo{Owner: "owner", Name: "repo"}},
+		{input: "ghes.example.com/owner/repo.js", want: Repo{Host: "ghes.example.com", Owner: "owner", Name: "repo.js"}},
+		{input: "localhost:8080/o/r", want: Repo{Host: "localhost:8080", Owner: "o", Name: "r"}},
+		{input: "repo", wantErr: true},
+		{input: "owner/", wantErr: true},
+		{input: "a/b/c/d", wantErr: true},
+		{input: "https:/owner/repo", wantErr: true},
+		{input: "owner/repo;rm", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRepo(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRepo(%q) = %+v, %v; want %+v", tt.input, got, err, tt.want)
+		}
+		if err == nil && got.String() != tt.input {
+			t.Errorf("ParseRepo(%q).String() = %s", tt.input, got.String())
+		}
+	}
+
+	r := Repo{Host: "ghes.example.com", Owner: "o", Name: "r"}
+	if got := r.APIArgs(); !reflect.DeepEqual(got, []string{"--hostname", "ghes.example.com"}) {
+		t.Errorf("APIArgs() = %v", got)
+	}
+	if got := (Repo{Owner: "o", Name: "r"}).APIArgs(); got != nil {
+		t.Errorf("APIArgs() without host = %v", got)
+	}
+}
+
+func TestFromServerURL(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com":             "",
+		"https://ghes.example.com":       "ghes.example.com",
+		"https://ghes.example.com:8443/": "ghes.example.com:8443",
+		"":                               "",
+	}
+	for serverURL, want := range tests {
+		if got := FromServerURL(serverURL); got != want {
+			t.Errorf("FromServerURL(%q) = %q, want %q", serverURL, got, want)
+		}
+	}
+}
+
+func TestParseRemote(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:owner/repo.git\n":          "github.com/owner/repo",
+		"https://github.com/owner/repo":            "github.com/owner/repo",
+		"https://ghes.example.com:8443/o/r.git":    "ghes.example.com:8443/o/r",
+		"ssh://git@ghes.example.com:2222/o/r.git":  "ghes.example.com/o/r",
+		"org-123@ssh.ghes.example.com:owner/repo/": "ssh.ghes.example.com/owner/repo",
+		"/srv/git/repo.git":                        "",
+		"https://github.com/owner/repo/tree/main":  "",
+		"file:///srv/git/owner/repo.git":           "",
+	}
+	for remote, want := range tests {
+		got, err := ParseRemote(remote)
+		if want == "" {
+			if err == nil {
+				t.Errorf("ParseRemote(%q) = %s, want error", remote, got)
+			}
+			continue
+		}
+		if err != nil || got.String() != want {
+			t.Errorf("ParseRemote(%q) = %s, %v; want %s", remote, got, err, want)
+		}
+	}
+}
+
+func TestWithRepo(t *testing.T) {
+	var got []runner.Command
+	r := WithRepo(runner.Func(func(_ context.Context, cmd runner.Command) (runner.Result, error) {
+		got = append(got, cmd)
+		return runner.Result{}, nil
+	}), Repo{Host: "ghes.example.com", Owner: "o", Name: "r"})
+
+	for _, cmd := range []runner.Command{
+		{Name: "gh", Args: []string{"issue", "create"}},
+		{Name: "gh", Args: []string{"api", "user"}, Env: []string{"GH_HOST=github.com"}},
+		{Name: "git", Args: []string{"push"}},
+	} {
+		_, _ = r.Run(context.Background(), cmd)
+	}
+
+	want := [][]string{{"GH_REPO=ghes.example.com/o/r", "GH_HOST=ghes.example.com"}, {"GH_HOST=github.com", "GH_REPO=ghes.example.com/o/r"}, nil}
+	for i, cmd := range got {
+		if !reflect.DeepEqual(cmd.Env, want[i]) {
+			t.Errorf("command %d env = %v, want %v", i, cmd.Env, want[i])
+		}
+	}
+}
+
+func TestWithHost(t *testing.T) {
+	var got []runner.Command
+	r := WithHost(runner.Func(func(_ context.Context, cmd runner.Command) (runner.Result, error) {
+		got = append(got, cmd)
+		return runner.Result{}, nil
+	}), "ghes.example.com")
+
+	env := []string{"GH_REPO=o/r"}
+	for _, cmd := range []runner.Command{
+		{Name: "gh", Args: []string{"issue", "create"}, Env: env},
+		{Name: "gh", Args: []string{"api", "user"}, Env: []string{"GH_HOST=github.com"}},
+		{Name: "git", Args: []string{"push"}},
+	} {
+		_, _ = r.Run(context.Background(), cmd)
+	}
+
+	want := [][]string{{"GH_REPO=o/r", "GH_HOST=ghes.example.com"}, {"GH_HOST=github.com"}, nil}
+	for i, cmd := range got {
+		if !reflect.DeepEqual(cmd.Env, want[i]) {
+			t.Errorf("command %d env = %v, want %v", i, cmd.Env, want[i])
+		}
+	}
+	if !reflect.DeepEqual(env, []string{"GH_REPO=o/r"}) {
+		t.Errorf("WithHost() changed the env of the caller: %v", env)
+	}
+}
@@ -0,0 +1,162 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+)
+
+// Interaction is one command together with its outcome, as stored in fixture files.
+type Interaction struct {
+	Name     string   `json:"name"`
+	Args     []string `json:"args"`
+	Env      []string `json:"env,omitempty"`
+	Stdin    string   `json:"stdin,omitempty"`
+	Stdout   string   `json:"stdout,omitempty"`
+	Stderr   string   `json:"stderr,omitempty"`
+	ExitCode int      `json:"exit_code,omitempty"`
+	// Error is set when the command failed without an exit status,
+	// e.g. because the executable was not found.
+	Error string `json:"error,omitempty"`
+}
+
+func (i Interaction) command() Command {
+	return Command{Name: i.Name, Args: i.Args, Env: i.Env}
+}
+
+// Recorder wraps a Runner and keeps a log of every interaction so that a real
+// session can be saved as a fixture and replayed later.
+type Recorder struct {
+	next Runner
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// NewRecorder returns a Recorder that passes every command on to next.
+func NewRecorder(next Runner) *Recorder {
+	return &Recorder{next: next}
+}
+
+// Run runs cmd with the wrapped Runner and records the outcome.
+func (r *Recorder) Run(ctx context.Context, cmd Command) (Result, error) {
+	res, err := r.next.Run(ctx, cmd)
+
+	i := Interaction{
+		Name:     cmd.Name,
+		Args:     append([]string{}, cmd.Args...),
+		Env:      cmd.Env,
+		Stdin:    string(cmd.Stdin),
+		Stdout:   string(res.Stdout),
+		Stderr:   string(res.Stderr),
+		ExitCode: res.ExitCode,
+	}
+	var exitErr *ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		i.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, i)
+	r.mu.Unlock()
+	return res, err
+}
+
+// Interactions returns a copy of the interactions recorded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction{}, r.interactions...)
+}
+
+// Save writes the recorded interactions to a JSON fixture file.
+func (r *Recorder) Save(path string) error {
+	data, err := json.MarshalIndent(r.Interactions(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recording: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	return nil
+}
+
+// Replayer serves commands from recorded interactions, in order. Any command
+// that does not match the next expected interaction fails, so code under test
+// can never reach a real gh or git process.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	next         int
+}
+
+// NewReplayer returns a Replayer that expects exactly the given interactions.
+func NewReplayer(interactions ...Interaction) *Replayer {
+	return &Replayer{interactions: interactions}
+}
+
+// LoadReplayer reads a fixture file written by Recorder.Save.
+func LoadReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	var interactions []Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("failed to parse recording '%s': %w", path, err)
+	}
+	return NewReplayer(interactions...), nil
+}
+
+// Run returns the recorded outcome of cmd, or an error when cmd is not the
+// next expected interaction.
+func (r *Replayer) Run(ctx context.Context, cmd Command) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, fmt.Errorf("%s: %w", cmd.Name, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.next >= len(r.interactions) {
+		return Result{}, fmt.Errorf("replay: unexpected command %q: no more recorded interactions", cmd.String())
+	}
+	want := r.interactions[r.next]
+	if cmd.Name != want.Name || !reflect.DeepEqual(nonNil(cmd.Args), nonNil(want.Args)) ||
+		!reflect.DeepEqual(nonNil(cmd.Env), nonNil(want.Env)) || string(cmd.Stdin) != want.Stdin {
+		return Result{}, fmt.Errorf("replay: unexpected command %q, want %q (interaction %d)",
+			cmd.String(), want.command().String(), r.next+1)
+	}
+	r.next++
+
+	res := Result{Stdout: []byte(want.Stdout), Stderr: []byte(want.Stderr), ExitCode: want.ExitCode}
+	switch {
+	case want.Error != "":
+		return res, errors.New(want.Error)
+	case want.ExitCode != 0:
+		return res, &ExitError{Command: cmd, Result: res}
+	}
+	return res, nil
+}
+
+// Verify returns an error when some recorded interactions were never replayed.
+func (r *Replayer) Verify() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next < len(r.interactions) {
+		return fmt.Errorf("replay: %d of %d recorded interactions were not used, next is %q",
+			len(r.interactions)-r.next, len(r.interactions), r.interactions[r.next].command().String())
+	}
+	return nil
+}
+
+func nonNil(args []string) []string {
+	if args == nil {
+		return []string{}
+	}
+	return args
+}
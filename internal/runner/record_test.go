@@ -0,0 +1,84 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	fake := Func(func(_ context.Context, cmd Command) (Result, error) {
+		if cmd.Args[0] == "fail" {
+			res := Result{Stderr: []byte("HTTP 404"), ExitCode: 1}
+			return res, &ExitError{Command: cmd, Result: res}
+		}
+		return Result{Stdout: []byte("out:" + string(cmd.Stdin))}, nil
+	})
+
+	rec := NewRecorder(fake)
+	ctx := context.Background()
+	if _, err := rec.Run(ctx, Command{Name: "gh", Args: []string{"ok"}, Stdin: []byte("body")}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if _, err := rec.Run(ctx, Command{Name: "gh", Args: []string{"fail"}}); err == nil {
+		t.Fatalf("Run() expected error")
+	}
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := rec.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	rep, err := LoadReplayer(path)
+	if err != nil {
+		t.Fatalf("LoadReplayer() error = %v", err)
+	}
+	res, err := rep.Run(ctx, Command{Name: "gh", Args: []string{"ok"}, Stdin: []byte("body")})
+	if err != nil || string(res.Stdout) != "out:body" {
+		t.Errorf("Run() = %q, %v; want %q, nil", res.Stdout, err, "out:body")
+	}
+	_, err = rep.Run(ctx, Command{Name: "gh", Args: []string{"fail"}})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Result.ExitCode != 1 || string(exitErr.Result.Stderr) != "HTTP 404" {
+		t.Errorf("Run() error = %v, want replayed exit error", err)
+	}
+	if err := rep.Verify(); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestReplayerRejectsUnexpectedCommands(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		cmd  Command
+	}{
+		{"different args", Command{Name: "gh", Args: []string{"issue", "delete"}}},
+		{"different program", Command{Name: "git", Args: []string{"issue", "create"}}},
+		{"different stdin", Command{Name: "gh", Args: []string{"issue", "create"}, Stdin: []byte("other")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rep := NewReplayer(Interaction{Name: "gh", Args: []string{"issue", "create"}, Stdin: "body"})
+			_, err := rep.Run(ctx, tt.cmd)
+			if err == nil || !strings.Contains(err.Error(), "unexpected command") {
+				t.Errorf("Run() error = %v, want unexpected command", err)
+			}
+		})
+	}
+
+	rep := NewReplayer()
+	if _, err := rep.Run(ctx, Command{Name: "gh"}); err == nil || !strings.Contains(err.Error(), "no more recorded interactions") {
+		t.Errorf("Run() error = %v, want no more recorded interactions", err)
+	}
+}
+
+func TestReplayerVerify(t *testing.T) {
+	rep := NewReplayer(Interaction{Name: "gh", Args: []string{"label", "list"}})
+	if err := rep.Verify(); err == nil {
+		t.Errorf("Verify() expected error for unused interaction")
+	}
+}
@@ -0,0 +1,309 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy controls how failed commands are retried.
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// BaseDelay is the backoff before the second attempt; it doubles on every retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the computed backoff. Server provided waits are not capped,
+	// but they are still bounded by the context deadline.
+	MaxDelay time.Duration
+
+	// Notify is called before sleeping between attempts. It may be nil.
+	Notify func(cmd Command, attempt int, wait time.Duration, cause error)
+
+	// The hooks below default to the real clock and randomness; tests replace them.
+	Now    func() time.Time
+	Sleep  func(ctx context.Context, d time.Duration) error
+	Jitter func(d time.Duration) time.Duration
+}
+
+// DefaultPolicy returns the retry policy used for all GitHub calls.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+	}
+}
+
+// Default returns the Runner used by all commands: local processes with the
+// default retry policy, reporting each retry on stderr.
+func Default() Runner {
+	p := DefaultPolicy()
+	p.Notify = func(cmd Command, attempt int, wait time.Duration, cause error) {
+		fmt.Fprintf(os.Stderr, "%s failed (attempt %d): %s\nRetrying in %s...\n",
+			cmd.Name, attempt, strings.TrimSpace(Stderr(cause)), wait.Round(time.Second))
+	}
+	return WithRetry(Exec{}, p)
+}
+
+// WithRetry wraps r so that transient failures are retried according to p.
+func WithRetry(r Runner, p Policy) Runner {
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 1
+	}
+	if p.Now == nil {
+		p.Now = time.Now
+	}
+	if p.Sleep == nil {
+		p.Sleep = sleep
+	}
+	if p.Jitter == nil {
+		p.Jitter = fullJitter
+	}
+	return &retrying{next: r, policy: p}
+}
+
+type retrying struct {
+	next   Runner
+	policy Policy
+}
+
+func (r *retrying) Run(ctx context.Context, cmd Command) (Result, error) {
+	p := r.policy
+	for attempt := 1; ; attempt++ {
+		res, headers, err := r.run(ctx, cmd)
+		if err == nil || attempt >= p.MaxAttempts || ctx.Err() != nil {
+			return res, err
+		}
+
+		retry := Classify(cmd, res, err)
+		if !retry.Retryable {
+			return res, err
+		}
+
+		wait := serverWait(headers, p.Now())
+		if wait <= 0 {
+			wait = p.Jitter(p.backoff(attempt))
+		}
+		if deadline, ok := ctx.Deadline(); ok && p.Now().Add(wait).After(deadline) {
+			return res, err
+		}
+
+		if p.Notify != nil {
+			p.Notify(cmd, attempt, wait, err)
+		}
+		if sleepErr := p.Sleep(ctx, wait); sleepErr != nil {
+			return res, err
+		}
+	}
+}
+
+// run runs cmd once. The response headers of gh api calls carry the waits
+// GitHub asks for, so they are requested with --include and returned apart
+// from the output; the result looks as if cmd ran as it is.
+func (r *retrying) run(ctx context.Context, cmd Command) (Result, string, error) {
+	if !includesHeaders(cmd) {
+		res, err := r.next.Run(ctx, cmd)
+		return res, "", err
+	}
+	included := cmd
+	included.Args = append(append([]string{}, cmd.Args...), "--include")
+	res, err := r.next.Run(ctx, included)
+	headers, body := splitHeaders(res.Stdout)
+	res.Stdout = body
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		exitErr.Command, exitErr.Result = cmd, res
+	}
+	return res, headers, err
+}
+
+// includesHeaders reports whether the headers of cmd are to be requested. A
+// paginated call prints them for every page, so it goes without.
+func includesHeaders(cmd Command) bool {
+	if cmd.Name != "gh" || len(cmd.Args) == 0 || cmd.Args[0] != "api" {
+		return false
+	}
+	for _, arg := range cmd.Args[1:] {
+		switch arg {
+		case "--paginate", "--include", "-i", "--silent":
+			return false
+		}
+	}
+	return true
+}
+
+// splitHeaders splits the status line and headers that gh api --include
+// prints, up to the first empty line, from the response that follows.
+func splitHeaders(stdout []byte) (string, []byte) {
+	if !bytes.HasPrefix(stdout, []byte("HTTP/")) {
+		return "", stdout
+	}
+	rest := stdout
+	for len(rest) > 0 {
+		line, after, _ := bytes.Cut(rest, []byte("\n"))
+		if len(bytes.TrimSpace(line)) == 0 {
+			return string(stdout[:len(stdout)-len(after)]), after
+		}
+		rest = after
+	}
+	return string(stdout), nil
+}
+
+// backoff returns the exponential delay before the given retry, capped at MaxDelay.
+func (p Policy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if p.MaxDelay > 0 && d >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return d
+}
+
+// fullJitter picks a random delay in [d/2, d] to spread out concurrent retries.
+func fullJitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	// #nosec G404 -- jitter does not need a cryptographically secure source
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Decision is the outcome of classifying a failed command.
+type Decision struct {
+	Retryable bool
+	// RateLimited is set when GitHub rejected the call because of a rate limit.
+	RateLimited bool
+	// Network is set when the failure was caused by the network or a server
+	// error rather than by the request itself.
+	Network bool
+}
+
+// ghExitAuth is the exit code gh uses when authentication is required.
+const ghExitAuth = 4
+
+var (
+	httpStatusPattern = regexp.MustCompile(`HTTP (\d{3})`)
+	retryAfterPattern = regexp.MustCompile(`(?im)^\s*Retry-After:\s*(\d+)\s*$`)
+	rateResetPattern  = regexp.MustCompile(`(?im)^\s*X-RateLimit-Reset:\s*(\d+)\s*$`)
+	rateLeftPattern   = regexp.MustCompile(`(?im)^\s*X-RateLimit-Remaining:\s*0\s*$`)
+)
+
+// rateLimitMessages are fragments of GitHub responses for primary and secondary
+// rate limits and abuse detection.
+var rateLimitMessages = []string{
+	"rate limit exceeded",
+	"secondary rate limit",
+	"abuse detection",
+}
+
+// networkMessages are fragments of transport errors reported by gh and git that
+// happen before a request reaches GitHub.
+var networkMessages = []string{
+	"connection refused",
+	"could not resolve host",
+	"no such host",
+	"tls handshake timeout",
+}
+
+// transientMessages may happen after a request reached GitHub, so they are
+// only retried for commands that are safe to repeat.
+var transientMessages = []string{
+	"connection reset by peer",
+	"i/o timeout",
+	"unexpected eof",
+	"operation timed out",
+	"connection timed out",
+	"server error",
+	"bad gateway",
+	"service unavailable",
+	"gateway timeout",
+}
+
+// Classify decides whether a failed command may be retried. It looks at the gh
+// exit code, the HTTP status reported on stdout/stderr, and well-known
+// transient error messages.
+func Classify(cmd Command, res Result, err error) Decision {
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) {
+		// The process could not be started or was cancelled; retrying won't help.
+		return Decision{}
+	}
+	if cmd.Name == "gh" && res.ExitCode == ghExitAuth {
+		return Decision{}
+	}
+
+	output := string(res.Stderr) + "\n" + string(res.Stdout)
+	lower := strings.ToLower(output)
+	status := 0
+	if m := httpStatusPattern.FindStringSubmatch(output); m != nil {
+		status, _ = strconv.Atoi(m[1])
+	}
+
+	if status == 429 || containsAny(lower, rateLimitMessages) {
+		return Decision{Retryable: true, RateLimited: true}
+	}
+	if containsAny(lower, networkMessages) {
+		return Decision{Retryable: true, Network: true}
+	}
+	transient := containsAny(lower, transientMessages)
+	switch status {
+	case 500, 502, 503, 504:
+		transient = true
+	}
+	if !transient {
+		return Decision{}
+	}
+	return Decision{Retryable: !cmd.Mutating, Network: true}
+}
+
+// serverWait returns how long GitHub asked us to wait in the response headers,
+// based on Retry-After or, when the rate limit is exhausted, the
+// X-RateLimit-Reset timestamp.
+func serverWait(headers string, now time.Time) time.Duration {
+	if m := retryAfterPattern.FindStringSubmatch(headers); m != nil {
+		seconds, _ := strconv.Atoi(m[1])
+		return time.Duration(seconds) * time.Second
+	}
+	if rateLeftPattern.MatchString(headers) {
+		if m := rateResetPattern.FindStringSubmatch(headers); m != nil {
+			epoch, _ := strconv.ParseInt(m[1], 10, 64)
+			if wait := time.Unix(epoch, 0).Sub(now); wait > 0 {
+				return wait
+			}
+		}
+	}
+	return 0
+}
+
+func containsAny(s string, fragments []string) bool {
+	for _, f := range fragments {
+		if strings.Contains(s, f) {
+			return true
+		}
+	}
+	return false
+}
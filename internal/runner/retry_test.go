@@ -0,0 +1,344 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeRunner returns the queued results in order and records every call.
+type fakeRunner struct {
+	results  []Result
+	calls    int
+	commands []Command
+}
+
+func (f *fakeRunner) Run(_ context.Context, cmd Command) (Result, error) {
+	res := f.results[f.calls]
+	f.calls++
+	f.commands = append(f.commands, cmd)
+	if res.ExitCode != 0 {
+		return res, &ExitError{Command: cmd, Result: res}
+	}
+	return res, nil
+}
+
+// testPolicy records the requested sleeps instead of sleeping.
+func testPolicy(slept *[]time.Duration) Policy {
+	p := DefaultPolicy()
+	p.Sleep = func(_ context.Context, d time.Duration) error {
+		*slept = append(*slept, d)
+		return nil
+	}
+	p.Jitter = func(d time.Duration) time.Duration { return d }
+	return p
+}
+
+func failure(stderr string) Result {
+	return Result{ExitCode: 1, Stderr: []byte(stderr)}
+}
+
+// apiFailure is a failed gh api --include call with the given headers.
+func apiFailure(stderr, headers string) Result {
+	res := failure(stderr)
+	res.Stdout = []byte("HTTP/2.0 429 Too Many Requests\n" + headers + "\r\n{\"message\":\"slow down\"}")
+	return res
+}
+
+var apiCall = Command{Name: "gh", Args: []string{"api", "repos/o/r"}}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name        string
+		cmd         Command
+		res         Result
+		wantRetry   bool
+		wantLimited bool
+	}{
+		{"bad gateway", Command{Name: "gh"}, failure("HTTP 502: Bad Gateway"), true, false},
+		{"service unavailable", Command{Name: "gh"}, failure("HTTP 503"), true, false},
+		{"not found", Command{Name: "gh"}, failure("HTTP 404: Not Found"), false, false},
+		{"validation failed", Command{Name: "gh"}, failure("HTTP 422: Validation Failed"), false, false},
+		{"secondary rate limit", Command{Name: "gh"}, failure("HTTP 403: You have exceeded a secondary rate limit"), true, true},
+		{"primary rate limit", Command{Name: "gh"}, failure("API rate limit exceeded for user ID 1."), true, true},
+		{"abuse detection", Command{Name: "gh"}, failure("You have triggered an abuse detection mechanism"), true, true},
+		{"too many requests", Command{Name: "gh"}, failure("HTTP 429"), true, true},
+		{"auth required", Command{Name: "gh"}, Result{ExitCode: 4, Stderr: []byte("HTTP 502")}, false, false},
+		{"network failure", Command{Name: "git"}, failure("fatal: Could not resolve host: github.com"), true, false},
+		{"mutating 502 is not retried", Command{Name: "gh", Mutating: true}, failure("HTTP 502"), false, false},
+		{"mutating rate limit is retried", Command{Name: "gh", Mutating: true}, failure("secondary rate limit"), true, true},
+		{"unknown git failure", Command{Name: "git"}, failure("fatal: invalid object name"), false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Classify(tt.cmd, tt.res, &ExitError{Command: tt.cmd, Result: tt.res})
+			if got.Retryable != tt.wantRetry {
+				t.Errorf("Classify() Retryable = %v, want %v", got.Retryable, tt.wantRetry)
+			}
+			if got.RateLimited != tt.wantLimited {
+				t.Errorf("Classify() RateLimited = %v, want %v", got.RateLimited, tt.wantLimited)
+			}
+		})
+	}
+}
+
+func TestClassifyStartFailure(t *testing.T) {
+	got := Classify(Command{Name: "gh"}, Result{}, errors.New("executable file not found in $PATH"))
+	if got.Retryable {
+		t.Errorf("Classify() should not retry when the process could not start")
+	}
+}
+
+func TestServerWait(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name   string
+		output string
+		want   time.Duration
+	}{
+		{"retry after", "HTTP/2.0 403 Forbidden\nRetry-After: 42\n", 42 * time.Second},
+		{"rate limit reset", "X-RateLimit-Remaining: 0\nX-RateLimit-Reset: 1700000010\n", 10 * time.Second},
+		{"reset ignored while requests remain", "X-RateLimit-Remaining: 12\nX-RateLimit-Reset: 1700000010\n", 0},
+		{"reset in the past", "X-RateLimit-Remaining: 0\nX-RateLimit-Reset: 1600000000\n", 0},
+		{"no headers", "HTTP 502", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serverWait(tt.output, now); got != tt.want {
+				t.Errorf("serverWait() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithRetryRecovers(t *testing.T) {
+	fake := &fakeRunner{results: []Result{failure("HTTP 502"), failure("HTTP 503"), {Stdout: []byte("ok")}}}
+	var slept []time.Duration
+	r := WithRetry(fake, testPolicy(&slept))
+
+	res, err := r.Run(context.Background(), Command{Name: "gh"})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if string(res.Stdout) != "ok" {
+		t.Errorf("Run() stdout = %q, want %q", res.Stdout, "ok")
+	}
+	if fake.calls != 3 {
+		t.Errorf("Run() made %d calls, want 3", fake.calls)
+	}
+	want := []time.Duration{time.Second, 2 * time.Second}
+	if len(slept) != len(want) || slept[0] != want[0] || slept[1] != want[1] {
+		t.Errorf("Run() slept %v, want %v", slept, want)
+	}
+}
+
+func TestWithRetryHonorsRetryAfter(t *testing.T) {
+	fake := &fakeRunner{results: []Result{apiFailure("HTTP 429", "Retry-After: 7\r\n"), {}}}
+	var slept []time.Duration
+	r := WithRetry(fake, testPolicy(&slept))
+
+	if _, err := r.Run(context.Background(), apiCall); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(slept) != 1 || slept[0] != 7*time.Second {
+		t.Errorf("Run() slept %v, want [7s]", slept)
+	}
+
+	// Headers on stderr aren't GitHub's
+	fake = &fakeRunner{results: []Result{failure("HTTP 429\nRetry-After: 7"), {}}}
+	slept = nil
+	r = WithRetry(fake, testPolicy(&slept))
+	if _, err := r.Run(context.Background(), Command{Name: "gh"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(slept) != 1 || slept[0] != time.Second {
+		t.Errorf("Run() slept %v, want the backoff", slept)
+	}
+}
+
+func TestWithRetryRateLimitReset(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	fake := &fakeRunner{results: []Result{apiFailure("API rate limit exceeded", "X-Ratelimit-Remaining: 0\r\nX-Ratelimit-Reset: 1700000010\r\n"), {}}}
+	var slept []time.Duration
+	p := testPolicy(&slept)
+	p.Now = func() time.Time { return now }
+	r := WithRetry(fake, p)
+
+	if _, err := r.Run(context.Background(), apiCall); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(slept) != 1 || slept[0] != 10*time.Second {
+		t.Errorf("Run() slept %v, want [10s]", slept)
+	}
+}
+
+func TestWithRetryIncludesHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		cmd      Command
+		stdout   string
+		wantArgs []string
+		want     string
+	}{
+		{"api", apiCall, "HTTP/2.0 200 OK\nX-Ratelimit-Remaining: 4999\r\n\r\n{\"id\":1}", []string{"api", "repos/o/r", "--include"}, `{"id":1}`},
+		{"api with jq", Command{Name: "gh", Args: []string{"api", "repos/o/r", "--jq", ".id"}}, "HTTP/2.0 200 OK\nEtag: x\r\n\r\n1\n", []string{"api", "repos/o/r", "--jq", ".id", "--include"}, "1\n"},
+		{"paginated", Command{Name: "gh", Args: []string{"api", "--paginate", "repos/o/r/issues"}}, "[]", []string{"api", "--paginate", "repos/o/r/issues"}, "[]"},
+		{"not api", Command{Name: "gh", Args: []string{"issue", "list"}}, "[]", []string{"issue", "list"}, "[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeRunner{results: []Result{{Stdout: []byte(tt.stdout)}}}
+			var slept []time.Duration
+			res, err := WithRetry(fake, testPolicy(&slept)).Run(context.Background(), tt.cmd)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if !reflect.DeepEqual(fake.commands[0].Args, tt.wantArgs) {
+				t.Errorf("ran %v, want %v", fake.commands[0].Args, tt.wantArgs)
+			}
+			if string(res.Stdout) != tt.want {
+				t.Errorf("Run() stdout = %q, want %q", res.Stdout, tt.want)
+			}
+		})
+	}
+
+	// A failure reports the command as it was given, without the headers
+	fake := &fakeRunner{results: []Result{apiFailure("HTTP 404: Not Found", "")}}
+	_, err := WithRetry(fake, DefaultPolicy()).Run(context.Background(), apiCall)
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || !reflect.DeepEqual(exitErr.Command, apiCall) || string(exitErr.Result.Stdout) != `{"message":"slow down"}` {
+		t.Errorf("Run() error = %#v", err)
+	}
+}
+
+func TestWithRetryGivesUp(t *testing.T) {
+	fake := &fakeRunner{results: []Result{failure("HTTP 502"), failure("HTTP 502"), failure("HTTP 502")}}
+	var slept []time.Duration
+	p := testPolicy(&slept)
+	p.MaxAttempts = 3
+	r := WithRetry(fake, p)
+
+	_, err := r.Run(context.Background(), Command{Name: "gh"})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Run() error = %v, want *ExitError", err)
+	}
+	if fake.calls != 3 {
+		t.Errorf("Run() made %d calls, want 3", fake.calls)
+	}
+}
+
+func TestWithRetryDoesNotRetryPermanentFailures(t *testing.T) {
+	fake := &fakeRunner{results: []Result{failure("HTTP 404: Not Found"), {}}}
+	var slept []time.Duration
+	r := WithRetry(fake, testPolicy(&slept))
+
+	if _, err := r.Run(context.Background(), Command{Name: "gh"}); err == nil {
+		t.Fatalf("Run() expected error")
+	}
+	if fake.calls != 1 {
+		t.Errorf("Run() made %d calls, want 1", fake.calls)
+	}
+}
+
+func TestWithRetryStopsAtDeadline(t *testing.T) {
+	fake := &fakeRunner{results: []Result{apiFailure("HTTP 429", "Retry-After: 120\r\n"), {}}}
+	var slept []time.Duration
+	r := WithRetry(fake, testPolicy(&slept))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := r.Run(ctx, apiCall); err == nil {
+		t.Fatalf("Run() expected error when the wait exceeds the deadline")
+	}
+	if fake.calls != 1 || len(slept) != 0 {
+		t.Errorf("Run() made %d calls and slept %v, want 1 call and no sleep", fake.calls, slept)
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	p := Policy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := p.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestFullJitterRange(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		got := fullJitter(10 * time.Second)
+		if got < 5*time.Second || got > 10*time.Second {
+			t.Fatalf("fullJitter() = %v, want within [5s, 10s]", got)
+		}
+	}
+}
+
+func TestExecExitError(t *testing.T) {
+	_, err := Exec{}.Run(context.Background(), Command{Name: "git", Args: []string{"not-a-git-command"}})
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Run() error = %v, want *ExitError", err)
+	}
+	if exitErr.Result.ExitCode == 0 || len(exitErr.Result.Stderr) == 0 {
+		t.Errorf("Run() exit code = %d, stderr = %q; want failure with stderr", exitErr.Result.ExitCode, exitErr.Result.Stderr)
+	}
+}
+
+func TestExecKilledOnCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := Exec{}.Run(ctx, Command{Name: "sleep", Args: []string{"10"}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Run() returned after %v, want the process to be killed promptly", elapsed)
+	}
+}
+
+func TestWithRetryStopsWhenCancelled(t *testing.T) {
+	fake := &fakeRunner{results: []Result{failure("HTTP 502"), {}}}
+	p := DefaultPolicy()
+	p.Jitter = func(d time.Duration) time.Duration { return d }
+	r := WithRetry(fake, p)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.Run(ctx, Command{Name: "gh"}); err == nil {
+		t.Fatalf("Run() expected error")
+	}
+	if fake.calls != 1 {
+		t.Errorf("Run() made %d calls, want 1", fake.calls)
+	}
+}
+
+func TestClassifyNetwork(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  Command
+		res  Result
+		want bool
+	}{
+		{"unresolved host", Command{Name: "git"}, failure("Could not resolve host: github.com"), true},
+		{"bad gateway on mutating command", Command{Name: "gh", Mutating: true}, failure("HTTP 502"), true},
+		{"not found", Command{Name: "gh"}, failure("HTTP 404"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Classify(tt.cmd, tt.res, &ExitError{Command: tt.cmd, Result: tt.res})
+			if got.Network != tt.want {
+				t.Errorf("Classify() Network = %v, want %v", got.Network, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// Package runner executes the external gh and git processes used by gh-utils.
+// All invocations go through the Runner interface so that retries, timeouts and
+// fakes in tests can be layered on without changing the calling code.
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// waitDelay bounds how long Run waits for output after ctx is cancelled and the
+// process was killed, in case it left children holding the pipes open.
+const waitDelay = 5 * time.Second
+
+// Command describes a single invocation of an external program.
+type Command struct {
+	Name  string
+	Args  []string
+	Stdin []byte
+	// Env holds extra environment variables in NAME=value form, e.g. GH_HOST.
+	Env []string
+	// Dir is the directory the command runs in; "" is the current directory.
+	Dir string
+	// Mutating marks commands that change state on GitHub (create, edit, delete).
+	// They are only retried when the failure guarantees nothing was applied.
+	Mutating bool
+	// Audited marks commands that change state on GitHub but are safe to
+	// retry, such as setting a commit status. They are audited like Mutating
+	// commands.
+	Audited bool
+}
+
+// String returns the command line in a human readable form, preceded by the
+// extra environment variables.
+func (c Command) String() string {
+	words := append(append([]string{}, c.Env...), c.Name)
+	return strings.TrimSpace(strings.Join(append(words, c.Args...), " "))
+}
+
+// Result holds the captured output of a finished command.
+type Result struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+}
+
+// Runner runs commands.
+type Runner interface {
+	Run(ctx context.Context, cmd Command) (Result, error)
+}
+
+// Func adapts an ordinary function to the Runner interface.
+type Func func(ctx context.Context, cmd Command) (Result, error)
+
+// Run calls f(ctx, cmd).
+func (f Func) Run(ctx context.Context, cmd Command) (Result, error) {
+	return f(ctx, cmd)
+}
+
+// ExitError is returned when a command ran but exited with a non-zero status.
+type ExitError struct {
+	Command Command
+	Result  Result
+}
+
+func (e *ExitError) Error() string {
+	stderr := strings.TrimSpace(string(e.Result.Stderr))
+	if stderr == "" {
+		return fmt.Sprintf("%s exited with status %d", e.Command.Name, e.Result.ExitCode)
+	}
+	return fmt.Sprintf("%s exited with status %d: %s", e.Command.Name, e.Result.ExitCode, stderr)
+}
+
+// Stderr returns the captured stderr of the failed command, or the error text
+// itself when err is not an *ExitError.
+func Stderr(err error) string {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return string(exitErr.Result.Stderr)
+	}
+	return err.Error()
+}
+
+// Exec runs commands as local processes using os/exec.
+type Exec struct{}
+
+// Run starts the command and waits for it to finish. The process is killed when
+// ctx is cancelled, and the context error is returned.
+// Note: exec.Command passes arguments separately, not through shell, preventing injection
+func (Exec) Run(ctx context.Context, cmd Command) (Result, error) {
+	c := exec.CommandContext(ctx, cmd.Name, cmd.Args...)
+	c.WaitDelay = waitDelay
+	c.Dir = cmd.Dir
+	var stdout, stderr bytes.Buffer
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if cmd.Stdin != nil {
+		c.Stdin = bytes.NewReader(cmd.Stdin)
+	}
+	if len(cmd.Env) > 0 {
+		c.Env = append(os.Environ(), cmd.Env...)
+	}
+
+	err := c.Run()
+	res := Result{Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return res, fmt.Errorf("%s: %w", cmd.Name, ctxErr)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			res.ExitCode = exitErr.ExitCode()
+			return res, &ExitError{Command: cmd, Result: res}
+		}
+		return res, err
+	}
+	return res, nil
+}
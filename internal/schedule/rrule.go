@@ -0,0 +1,304 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// weekdays are the day names of recurrence rules.
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// byDay is a BYDAY item: a weekday with an optional ordinal, e.g. 2MO for the
+// second Monday or -1FR for the last Friday of the month.
+type byDay struct {
+	n   int
+	day time.Weekday
+}
+
+// rule is a parsed recurrence rule, the subset of RFC 5545 that describes
+// sprints, releases and other calendars: FREQ (DAILY, WEEKLY, MONTHLY or
+// YEARLY), INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY, BYMONTH and WKST.
+type rule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []byDay
+	byMonthDay []int
+	byMonth    []time.Month
+	wkst       time.Weekday
+	// start is the DTSTART; occurrences are at its time of day.
+	start time.Time
+}
+
+// parseRule parses a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO". The rule
+// may start with RRULE: and hold a DTSTART, as in
+// "DTSTART:20260105T090000Z;RRULE:FREQ=WEEKLY".
+func parseRule(expr string, start time.Time) (*rule, error) {
+	r := &rule{interval: 1, wkst: time.Monday}
+	parts := strings.FieldsFunc(expr, func(c rune) bool { return c == ';' || c == '\n' || c == ' ' })
+	for _, part := range parts {
+		if len(part) > 6 && strings.EqualFold(part[:6], "RRULE:") {
+			part = part[6:]
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			key, value, ok = strings.Cut(part, ":")
+		}
+		key = strings.ToUpper(key)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("expected KEY=VALUE, got '%s'", part)
+		}
+		var err error
+		switch key {
+		case "FREQ":
+			r.freq = strings.ToUpper(value)
+			if !slices.Contains([]string{"DAILY", "WEEKLY", "MONTHLY", "YEARLY"}, r.freq) {
+				return nil, fmt.Errorf("FREQ must be DAILY, WEEKLY, MONTHLY or YEARLY, got '%s'", value)
+			}
+		case "INTERVAL":
+			if r.interval, err = strconv.Atoi(value); err != nil || r.interval <= 0 {
+				return nil, fmt.Errorf("invalid INTERVAL '%s'", value)
+			}
+		case "COUNT":
+			if r.count, err = strconv.Atoi(value); err != nil || r.count <= 0 {
+				return nil, fmt.Errorf("invalid COUNT '%s'", value)
+			}
+		case "UNTIL":
+			if r.until, err = parseRuleTime(value); err != nil {
+				return nil, fmt.Errorf("UNTIL: %w", err)
+			}
+		case "DTSTART":
+			if r.start, err = parseRuleTime(value); err != nil {
+				return nil, fmt.Errorf("DTSTART: %w", err)
+			}
+		case "WKST":
+			day, ok := weekdays[strings.ToUpper(value)]
+			if !ok {
+				return nil, fmt.Errorf("invalid WKST '%s'", value)
+			}
+			r.wkst = day
+		case "BYDAY":
+			for _, item := range strings.Split(strings.ToUpper(value), ",") {
+				split := max(len(item)-2, 0)
+				ordinal, name := item[:split], item[split:]
+				day, ok := weekdays[name]
+				n := 0
+				if ordinal != "" {
+					n, err = strconv.Atoi(strings.TrimPrefix(ordinal, "+"))
+				}
+				if !ok || err != nil || n < -5 || n > 5 || ordinal != "" && n == 0 {
+					return nil, fmt.Errorf("invalid BYDAY '%s'", item)
+				}
+				r.byDay = append(r.byDay, byDay{n: n, day: day})
+			}
+		case "BYMONTHDAY":
+			for _, item := range strings.Split(value, ",") {
+				d, err := strconv.Atoi(item)
+				if err != nil || d == 0 || d < -31 || d > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY '%s'", item)
+				}
+				r.byMonthDay = append(r.byMonthDay, d)
+			}
+		case "BYMONTH":
+			for _, item := range strings.Split(value, ",") {
+				m, err := strconv.Atoi(item)
+				if err != nil || m < 1 || m > 12 {
+					return nil, fmt.Errorf("invalid BYMONTH '%s'", item)
+				}
+				r.byMonth = append(r.byMonth, time.Month(m))
+			}
+			slices.Sort(r.byMonth)
+		default:
+			return nil, fmt.Errorf("unsupported part '%s'", key)
+		}
+	}
+
+	if r.freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	switch {
+	case r.start.IsZero() && start.IsZero():
+		return nil, errors.New("a DTSTART or a start is required")
+	case r.start.IsZero():
+		r.start = start
+	case !start.IsZero() && !start.Equal(r.start):
+		return nil, fmt.Errorf("DTSTART %s and the start %s differ", r.start.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+	for _, d := range r.byDay {
+		if d.n != 0 && (r.freq == "DAILY" || r.freq == "WEEKLY") {
+			return nil, fmt.Errorf("BYDAY ordinals need FREQ=MONTHLY or YEARLY")
+		}
+	}
+	if r.freq == "YEARLY" && len(r.byDay) > 0 && len(r.byMonth) == 0 {
+		return nil, errors.New("BYDAY with FREQ=YEARLY needs BYMONTH")
+	}
+	return r, nil
+}
+
+// parseRuleTime parses an iCalendar date (20060102) or UTC date-time
+// (20060102T150405Z), or any time ParseTime accepts.
+func parseRuleTime(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return ParseTime(value)
+}
+
+// next returns the first occurrence after t, or the zero time when the rule
+// ends before it or has none within the horizon.
+func (r *rule) next(after time.Time) time.Time {
+	// Occurrences are counted from the start, for COUNT
+	count := 0
+	limit := after.Add(horizon)
+	for k := 0; ; k++ {
+		period := r.period(k)
+		if period.After(limit) {
+			return time.Time{}
+		}
+		for _, t := range r.candidates(period) {
+			if t.Before(r.start) {
+				continue
+			}
+			count++
+			if r.count > 0 && count > r.count || !r.until.IsZero() && t.After(r.until) {
+				return time.Time{}
+			}
+			if t.After(after) {
+				return t
+			}
+		}
+	}
+}
+
+// period returns the first day of the k-th period of the rule.
+func (r *rule) period(k int) time.Time {
+	y, m, d := r.start.Date()
+	n := k * r.interval
+	switch r.freq {
+	case "DAILY":
+		return time.Date(y, m, d+n, 0, 0, 0, 0, time.UTC)
+	case "WEEKLY":
+		offset := (int(r.start.Weekday()) - int(r.wkst) + 7) % 7
+		return time.Date(y, m, d-offset+7*n, 0, 0, 0, 0, time.UTC)
+	case "MONTHLY":
+		return time.Date(y, m+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(y+n, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// candidates returns the occurrences of the period starting at day, in order,
+// at the time of day of the start.
+func (r *rule) candidates(day time.Time) []time.Time {
+	var days []time.Time
+	switch r.freq {
+	case "DAILY":
+		if r.matches(day) {
+			days = append(days, day)
+		}
+	case "WEEKLY":
+		offsets := []int{(int(r.start.Weekday()) - int(r.wkst) + 7) % 7}
+		if len(r.byDay) > 0 {
+			offsets = offsets[:0]
+			for _, d := range r.byDay {
+				offsets = append(offsets, (int(d.day)-int(r.wkst)+7)%7)
+			}
+			slices.Sort(offsets)
+			offsets = slices.Compact(offsets)
+		}
+		for _, offset := range offsets {
+			if d := day.AddDate(0, 0, offset); r.matches(d) {
+				days = append(days, d)
+			}
+		}
+	case "MONTHLY":
+		if len(r.byMonth) == 0 || slices.Contains(r.byMonth, day.Month()) {
+			days = r.monthDays(day.Year(), day.Month())
+		}
+	default:
+		months := r.byMonth
+		if len(months) == 0 {
+			months = []time.Month{r.start.Month()}
+		}
+		for _, m := range months {
+			days = append(days, r.monthDays(day.Year(), m)...)
+		}
+	}
+	h, m, s := r.start.Clock()
+	times := make([]time.Time, len(days))
+	for i, d := range days {
+		times[i] = d.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second)
+	}
+	return times
+}
+
+// matches reports whether day passes the BYMONTH, BYMONTHDAY and BYDAY
+// filters of a daily or weekly rule.
+func (r *rule) matches(day time.Time) bool {
+	if len(r.byMonth) > 0 && !slices.Contains(r.byMonth, day.Month()) {
+		return false
+	}
+	n := daysIn(day.Year(), day.Month())
+	if len(r.byMonthDay) > 0 && !r.matchesMonthDay(day.Day(), n) {
+		return false
+	}
+	return len(r.byDay) == 0 || r.freq == "WEEKLY" || r.matchesDay(day, n)
+}
+
+// monthDays returns the days of the month the rule occurs on.
+func (r *rule) monthDays(year int, month time.Month) []time.Time {
+	n := daysIn(year, month)
+	var days []time.Time
+	for d := 1; d <= n; d++ {
+		day := time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+		switch {
+		case len(r.byMonthDay) == 0 && len(r.byDay) == 0:
+			if d != r.start.Day() {
+				continue
+			}
+		case len(r.byMonthDay) > 0 && !r.matchesMonthDay(d, n), len(r.byDay) > 0 && !r.matchesDay(day, n):
+			continue
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+// matchesMonthDay reports whether day d of a month of n days is in BYMONTHDAY,
+// where -1 is the last day.
+func (r *rule) matchesMonthDay(d, n int) bool {
+	for _, v := range r.byMonthDay {
+		if v == d || v < 0 && n+1+v == d {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesDay reports whether day, in a month of n days, is in BYDAY.
+func (r *rule) matchesDay(day time.Time, n int) bool {
+	d := day.Day()
+	for _, b := range r.byDay {
+		if day.Weekday() != b.day {
+			continue
+		}
+		if b.n == 0 || b.n > 0 && (d-1)/7+1 == b.n || b.n < 0 && (n-d)/7+1 == -b.n {
+			return true
+		}
+	}
+	return false
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,120 @@
+// Package schedule computes the occurrences of recurring events described by
+// a cron expression or an iCalendar recurrence rule (RRULE), such as the
+// issues utils recur creates every sprint or month.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// horizon bounds the search for the next occurrence, so schedules that never
+// match, such as February 30, end instead of searching forever.
+const horizon = 10 * 366 * 24 * time.Hour
+
+// Schedule is a parsed cron expression or recurrence rule. All times are UTC,
+// like the schedules of GitHub Actions.
+type Schedule struct {
+	// Expr is the expression the schedule was parsed from.
+	Expr string
+	// Start is the first moment the schedule can occur at; zero means any time.
+	Start time.Time
+
+	next func(after time.Time) time.Time
+}
+
+// Parse parses a cron expression (five fields or a macro such as @weekly) or
+// a recurrence rule (FREQ=...). Occurrences before start, which may be zero,
+// are left out. A rule is anchored at its DTSTART or, without one, at start,
+// so one of them is required.
+func Parse(expr string, start time.Time) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, errors.New("empty schedule")
+	}
+	if !start.IsZero() {
+		start = start.UTC()
+	}
+	s := &Schedule{Expr: expr, Start: start}
+	if isRule(expr) {
+		r, err := parseRule(expr, start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recurrence rule '%s': %w", expr, err)
+		}
+		s.Start, s.next = r.start, r.next
+		return s, nil
+	}
+	c, err := parseCron(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression '%s': %w", expr, err)
+	}
+	s.next = c.next
+	return s, nil
+}
+
+// Next returns the first occurrence after t, or the zero time when there is none.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.UTC()
+	if !s.Start.IsZero() && t.Before(s.Start) {
+		t = s.Start.Add(-time.Nanosecond)
+	}
+	return s.next(t)
+}
+
+// Between returns the occurrences after from up to and including to, oldest first.
+func (s *Schedule) Between(from, to time.Time) []time.Time {
+	var times []time.Time
+	for t := s.Next(from); !t.IsZero() && !t.After(to); t = s.Next(t) {
+		times = append(times, t)
+	}
+	return times
+}
+
+// Latest returns the last occurrence at or before t, or the zero time when
+// there is none within the horizon.
+func (s *Schedule) Latest(t time.Time) time.Time {
+	t = t.UTC()
+	// Search back in growing windows, so frequent schedules stay cheap
+	for _, window := range []time.Duration{24 * time.Hour, 32 * 24 * time.Hour, 367 * 24 * time.Hour, horizon} {
+		from := t.Add(-window)
+		if !s.Start.IsZero() && from.Before(s.Start) {
+			from = s.Start.Add(-time.Nanosecond)
+		}
+		if times := s.Between(from, t); len(times) > 0 {
+			return times[len(times)-1]
+		}
+		if !s.Start.IsZero() && !from.After(s.Start) {
+			break
+		}
+	}
+	return time.Time{}
+}
+
+// Iteration returns the 1-based number of the occurrence at t, counted from
+// the start of the schedule.
+func (s *Schedule) Iteration(t time.Time) (int, error) {
+	if s.Start.IsZero() {
+		return 0, errors.New("the schedule has no start to count iterations from")
+	}
+	n := 0
+	for o := s.Next(s.Start.Add(-time.Nanosecond)); !o.IsZero() && !o.After(t); o = s.Next(o) {
+		n++
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("%s is before the first occurrence", t.UTC().Format(time.RFC3339))
+	}
+	return n, nil
+}
+
+// ParseTime parses a start time given as a date (2006-01-02), a date and
+// time without a zone (2006-01-02T15:04), taken as UTC, or an RFC 3339 time.
+func ParseTime(value string) (time.Time, error) {
+	for _, layout := range []string{time.DateOnly, "2006-01-02T15:04", "2006-01-02T15:04:05", time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%s', expected a date such as 2026-01-05 or an RFC 3339 time", value)
+}
@@ -0,0 +1,120 @@
+package schedule
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func at(value string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name  string
+		expr  string
+		start string
+		after string
+		want  string
+	}{
+		{"weekly cron", "0 9 * * 1", "", "2026-10-18 12:00", "2026-10-19 09:00"},
+		{"step", "*/15 * * * *", "", "2026-10-18 10:07", "2026-10-18 10:15"},
+		{"list of days", "0 0 1,15 * *", "", "2026-10-02 00:00", "2026-10-15 00:00"},
+		{"weekday names", "0 9 * * mon-fri", "", "2026-10-16 10:00", "2026-10-19 09:00"},
+		{"day of month or week", "0 0 13 * 5", "", "2026-10-10 00:00", "2026-10-13 00:00"},
+		{"sunday as 7", "0 9 * * 7", "", "2026-10-17 00:00", "2026-10-18 09:00"},
+		{"macro", "@monthly", "", "2026-10-18 00:00", "2026-11-01 00:00"},
+		{"cron before start", "0 9 * * 1", "2026-11-01 00:00", "2026-10-18 00:00", "2026-11-02 09:00"},
+		{"never", "0 0 30 2 *", "", "2026-10-18 00:00", ""},
+		{"every other week", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", "2026-01-05 09:00", "2026-10-18 00:00", "2026-10-26 09:00"},
+		{"last friday", "RRULE:FREQ=MONTHLY;BYDAY=-1FR", "2026-01-01 00:00", "2026-10-18 00:00", "2026-10-30 00:00"},
+		{"second tuesday", "FREQ=MONTHLY;BYDAY=2TU", "2026-01-01 00:00", "2026-10-18 00:00", "2026-11-10 00:00"},
+		{"skips short months", "DTSTART:20260131T100000Z;RRULE:FREQ=MONTHLY", "", "2026-01-31 10:00", "2026-03-31 10:00"},
+		{"last day of march", "FREQ=YEARLY;BYMONTH=3;BYMONTHDAY=-1", "2026-01-01 08:00", "2026-01-01 00:00", "2026-03-31 08:00"},
+		{"until", "FREQ=DAILY;UNTIL=20261020", "2026-10-01 09:00", "2026-10-19 09:00", ""},
+		{"weekdays", "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", "2026-10-01 09:00", "2026-10-16 09:00", "2026-10-19 09:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var start time.Time
+			if tt.start != "" {
+				start = at(tt.start)
+			}
+			s, err := Parse(tt.expr, start)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			var want time.Time
+			if tt.want != "" {
+				want = at(tt.want)
+			}
+			if got := s.Next(at(tt.after)); !got.Equal(want) {
+				t.Errorf("Next(%s) = %v, want %v", tt.after, got, want)
+			}
+		})
+	}
+}
+
+func TestBetweenLatestIteration(t *testing.T) {
+	s, err := Parse("FREQ=DAILY;COUNT=3", at("2026-10-01 09:00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Time{at("2026-10-01 09:00"), at("2026-10-02 09:00"), at("2026-10-03 09:00")}
+	if got := s.Between(at("2026-09-01 00:00"), at("2026-12-01 00:00")); !reflect.DeepEqual(got, want) {
+		t.Errorf("Between() = %v, want %v", got, want)
+	}
+
+	s, err = Parse("0 9 * * 1", at("2026-10-05 00:00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Latest(at("2026-10-18 12:00")); !got.Equal(at("2026-10-12 09:00")) {
+		t.Errorf("Latest() = %v", got)
+	}
+	if got := s.Latest(at("2026-10-04 12:00")); !got.IsZero() {
+		t.Errorf("Latest() before the start = %v, want none", got)
+	}
+	if got, err := s.Iteration(at("2026-10-19 09:00")); err != nil || got != 3 {
+		t.Errorf("Iteration() = %d, %v, want 3", got, err)
+	}
+
+	s, err = Parse("0 9 * * 1", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Iteration(at("2026-10-19 09:00")); err == nil {
+		t.Error("Iteration() without a start expected error")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	start := at("2026-01-05 09:00")
+	for _, expr := range []string{
+		"",
+		"0 9 * *",
+		"61 * * * *",
+		"0 9 * * 1-",
+		"0 9 5-1 * *",
+		"*/0 * * * *",
+		"FREQ=HOURLY",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYSETPOS=1",
+		"FREQ=YEARLY;BYDAY=MO",
+		"INTERVAL=2",
+		"DTSTART:20260106;FREQ=WEEKLY",
+	} {
+		if _, err := Parse(expr, start); err == nil {
+			t.Errorf("Parse(%q) expected error", expr)
+		}
+	}
+	if _, err := Parse("FREQ=WEEKLY", time.Time{}); err == nil {
+		t.Error("Parse() of a rule without a start expected error")
+	}
+}
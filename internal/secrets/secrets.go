@@ -0,0 +1,180 @@
+// Package secrets finds tokens, keys and other secrets in text before it is
+// published on GitHub, such as terminal output pasted into an issue.
+package secrets
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/lakruzz/gh-utils/internal/config"
+)
+
+// Rule is a named pattern of secrets. When the pattern has a capture group,
+// the first group is the secret; otherwise the whole match is.
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// HighEntropy is the name of the findings that match no rule but look random.
+const HighEntropy = "High-entropy string"
+
+// Builtin are the rules every scanner checks.
+var Builtin = []Rule{
+	{"GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,255})\b`)},
+	{"GitHub fine-grained token", regexp.MustCompile(`\b(github_pat_[A-Za-z0-9_]{22,255})\b`)},
+	{"AWS access key", regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`)},
+	{"AWS secret key", regexp.MustCompile(`(?i)aws_?secret_?access_?key\b["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`)},
+	{"Private key", regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+	{"JSON Web Token", regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})`)},
+}
+
+// Settings of the high-entropy check: words of at least minEntropyLength
+// characters from the base64 alphabet, with upper and lower case letters and
+// digits, whose Shannon entropy is at least minEntropy bits per character.
+// Hexadecimal strings, such as commit SHAs, stay below it.
+const (
+	minEntropyLength = 32
+	minEntropy       = 4.3
+)
+
+var entropyWord = regexp.MustCompile(fmt.Sprintf(`[A-Za-z0-9+_-]{%d,}={0,2}`, minEntropyLength))
+
+// Finding is a possible secret.
+type Finding struct {
+	// Rule is the name of the rule that matched.
+	Rule string
+	// Source names the scanned text, such as "title", "body" or a file name.
+	Source string
+	// Line is the 1-based line of the match within the source.
+	Line int
+	// Masked is the match with all but its first four characters masked.
+	Masked string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s (%s)", f.Source, f.Line, f.Rule, f.Masked)
+}
+
+// Scanner checks text against the built-in and custom rules.
+type Scanner struct {
+	rules []Rule
+	allow []*regexp.Regexp
+}
+
+// New returns a scanner with the built-in rules and the custom patterns and
+// allowed matches of cfg.
+func New(cfg config.Secrets) (*Scanner, error) {
+	s := &Scanner{rules: append([]Rule(nil), Builtin...)}
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", p.Name, err)
+		}
+		s.rules = append(s.rules, Rule{Name: p.Name, Pattern: re})
+	}
+	for _, a := range cfg.Allow {
+		re, err := regexp.Compile(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed match '%s': %w", a, err)
+		}
+		s.allow = append(s.allow, re)
+	}
+	return s, nil
+}
+
+// Default returns a scanner with the built-in rules only.
+func Default() *Scanner {
+	return &Scanner{rules: Builtin}
+}
+
+// Scan returns the possible secrets in text, in order of lines, attributed to source.
+func (s *Scanner) Scan(source, text string) []Finding {
+	var findings []Finding
+	for i, line := range strings.Split(text, "\n") {
+		// Spans already reported, so a token isn't also reported as random
+		var spans [][]int
+		for _, rule := range s.rules {
+			for _, m := range rule.Pattern.FindAllStringSubmatchIndex(line, -1) {
+				start, end := m[0], m[1]
+				if len(m) > 2 && m[2] >= 0 {
+					start, end = m[2], m[3]
+				}
+				if s.allowed(line[start:end]) {
+					continue
+				}
+				spans = append(spans, []int{start, end})
+				findings = append(findings, Finding{Rule: rule.Name, Source: source, Line: i + 1, Masked: mask(line[start:end])})
+			}
+		}
+		for _, m := range entropyWord.FindAllStringIndex(line, -1) {
+			word := line[m[0]:m[1]]
+			if overlaps(spans, m) || !random(word) || s.allowed(word) {
+				continue
+			}
+			findings = append(findings, Finding{Rule: HighEntropy, Source: source, Line: i + 1, Masked: mask(word)})
+		}
+	}
+	return findings
+}
+
+// allowed reports whether match is listed as not being a secret.
+func (s *Scanner) allowed(match string) bool {
+	for _, re := range s.allow {
+		if re.MatchString(match) {
+			return true
+		}
+	}
+	return false
+}
+
+func overlaps(spans [][]int, span []int) bool {
+	for _, s := range spans {
+		if span[0] < s[1] && s[0] < span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// random reports whether word looks like a generated secret rather than a
+// word or an identifier.
+func random(word string) bool {
+	if len(word) < minEntropyLength {
+		return false
+	}
+	var upper, lower, digit bool
+	for _, r := range word {
+		upper = upper || unicode.IsUpper(r)
+		lower = lower || unicode.IsLower(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+	return upper && lower && digit && entropy(word) >= minEntropy
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// mask keeps the first four characters of a secret and masks the rest.
+func mask(secret string) string {
+	runes := []rune(secret)
+	if len(runes) <= 4 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:4]) + strings.Repeat("*", min(len(runes)-4, 8))
+}
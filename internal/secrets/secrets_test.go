@@ -0,0 +1,71 @@
+package secrets
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/internal/config"
+)
+
+// Test secrets are built at run time, so the source doesn't trip scanners.
+var (
+	githubToken = "ghp" + "_" + strings.Repeat("a1B2c3D4e5", 4)
+	jwt         = "eyJ" + "hbGciOiJIUzI1NiJ9.eyJzdWIiOiIxMjM0NTY3ODkwIn0.dozjgNryP4J3jVmNHl0w5N_XgL0n3I9PlFUP0THsR8U"
+	randomKey   = "q8Zr" + "T2xWv9LmKp4NsYb7HcJd3FgE6aUo1iQw"
+)
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Finding
+	}{
+		{"github token", "token: " + githubToken, []Finding{{Rule: "GitHub token", Line: 1, Masked: "ghp_********"}}},
+		{"fine-grained token", "x\ngithub" + "_pat_11ABCDEFG0123456789_abcdefghijkl", []Finding{{Rule: "GitHub fine-grained token", Line: 2, Masked: "gith********"}}},
+		{"aws access key", "AKIA" + "IOSFODNN7EXAMPLE", []Finding{{Rule: "AWS access key", Line: 1, Masked: "AKIA********"}}},
+		{
+			"aws secret key",
+			"aws_secret_access_key = " + "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY",
+			[]Finding{{Rule: "AWS secret key", Line: 1, Masked: "wJal********"}},
+		},
+		{"private key", "-----BEGIN " + "OPENSSH PRIVATE KEY-----", []Finding{{Rule: "Private key", Line: 1, Masked: "----********"}}},
+		{"jwt", "Authorization: Bearer " + jwt, []Finding{{Rule: "JSON Web Token", Line: 1, Masked: "eyJh********"}}},
+		{"high entropy", "secret=" + randomKey, []Finding{{Rule: HighEntropy, Line: 1, Masked: "q8Zr********"}}},
+		{"commit sha", "Fixed in 3f786850e387550fdab836ed7e6dc881de23001b", nil},
+		{"identifier", "Call TestCreateRollsBackMilestoneWhenIssueFails2 first", nil},
+		{"prose", "No secrets here, just a [link](https://github.com/owner/repo/blob/main/docs/x.md).", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := range tt.want {
+				tt.want[i].Source = "body"
+			}
+			if got := Default().Scan("body", tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scan() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanCustomRules(t *testing.T) {
+	s, err := New(config.Secrets{
+		Patterns: []config.SecretPattern{{Name: "Acme token", Regex: `acme_[a-z0-9]{8}`}},
+		Allow:    []string{`EXAMPLE`, `^acme_00000000$`},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	text := "acme_0a1b2c3d\nacme_00000000\nAKIA" + "IOSFODNN7EXAMPLE"
+	want := []Finding{{Rule: "Acme token", Source: "notes.md", Line: 1, Masked: "acme********"}}
+	if got := s.Scan("notes.md", text); !reflect.DeepEqual(got, want) {
+		t.Errorf("Scan() = %+v, want %+v", got, want)
+	}
+	if got := want[0].String(); got != "notes.md:1: Acme token (acme********)" {
+		t.Errorf("String() = %q", got)
+	}
+
+	if _, err := New(config.Secrets{Allow: []string{"("}}); err == nil {
+		t.Error("New() expected an error for an invalid regex")
+	}
+}
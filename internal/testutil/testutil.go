@@ -0,0 +1,100 @@
+// Package testutil holds the helpers shared by the tests of the commands:
+// replayers that must be used up, and git repositories with a bare remote.
+package testutil
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lakruzz/gh-utils/internal/runner"
+)
+
+// Expect returns a Runner that serves the interactions in order and fails the
+// test if any of them is left unused.
+func Expect(t *testing.T, interactions ...runner.Interaction) *runner.Replayer {
+	t.Helper()
+	return Verified(t, runner.NewReplayer(interactions...))
+}
+
+// Verified fails the test if r has interactions left unused when it ends.
+func Verified(t *testing.T, r *runner.Replayer) *runner.Replayer {
+	t.Helper()
+	t.Cleanup(func() {
+		if err := r.Verify(); err != nil {
+			t.Error(err)
+		}
+	})
+	return r
+}
+
+// Git returns an interaction of git printing stdout.
+func Git(stdout string, args ...string) runner.Interaction {
+	return runner.Interaction{Name: "git", Args: args, Stdout: stdout}
+}
+
+// Repo is a clone of a bare remote in a temporary directory.
+type Repo struct {
+	t *testing.T
+	// Dir is the clone, and Remote the bare repository it was cloned from.
+	Dir    string
+	Remote string
+}
+
+// NewRepo creates a bare remote, clones it as origin, adds commits to main and
+// pushes them, and changes into the clone. It returns the SHAs of the commits.
+// Git runs with a test identity and without the global configuration.
+func NewRepo(t *testing.T, commits int) (*Repo, []string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	for _, env := range []string{"GIT_AUTHOR_NAME", "GIT_COMMITTER_NAME"} {
+		t.Setenv(env, "Test")
+	}
+	for _, env := range []string{"GIT_AUTHOR_EMAIL", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(env, "test@example.com")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+
+	root := t.TempDir()
+	r := &Repo{t: t, Dir: root, Remote: filepath.Join(root, "remote.git")}
+	r.Git("init", "--quiet", "--bare", "--initial-branch=main", r.Remote)
+	r.Git("clone", "--quiet", r.Remote, "clone")
+	r.Dir = filepath.Join(root, "clone")
+
+	var shas []string
+	for i := 0; i < commits; i++ {
+		r.Git("commit", "--quiet", "--allow-empty", "-m", "commit")
+		shas = append(shas, r.Git("rev-parse", "HEAD"))
+	}
+	if commits > 0 {
+		r.Git("push", "--quiet", "-u", "origin", "main")
+		r.Git("remote", "set-head", "origin", "main")
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(r.Dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	return r, shas
+}
+
+// Git runs git in the clone and returns its trimmed output, failing the test
+// when git fails.
+func (r *Repo) Git(args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+	return strings.TrimSpace(string(output))
+}